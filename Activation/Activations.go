@@ -1,33 +1,188 @@
 package activation
 
 import (
+	"fmt"
 	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ThakurMayank5/gonn/vectors"
 )
 
 // ActivationFunction represents the type of activation function
 type ActivationFunction string
 
 const (
-	ReLU    ActivationFunction = "relu"
-	Sigmoid ActivationFunction = "sigmoid"
-	Tanh    ActivationFunction = "tanh"
-	Softmax ActivationFunction = "softmax"
+	ReLU        ActivationFunction = "relu"
+	LeakyReLU   ActivationFunction = "leaky_relu"
+	ELU         ActivationFunction = "elu"
+	SELU        ActivationFunction = "selu"
+	GELU        ActivationFunction = "gelu"
+	Swish       ActivationFunction = "swish"
+	SiLU        ActivationFunction = "silu"
+	Softplus    ActivationFunction = "softplus"
+	HardSigmoid ActivationFunction = "hard_sigmoid"
+	Linear      ActivationFunction = "linear"
+	Sigmoid     ActivationFunction = "sigmoid"
+	Tanh        ActivationFunction = "tanh"
+	Softmax     ActivationFunction = "softmax"
+)
+
+// Default parameters of the parametric activations
+const (
+	defaultLeakyReLUSlope = 0.01
+	defaultELUAlpha       = 1.0
+)
+
+// SELU constants from Klambauer et al., "Self-Normalizing Neural Networks"
+const (
+	seluAlpha = 1.6732632423543772
+	seluScale = 1.0507009873554805
 )
 
+// Activation pairs an element-wise activation with its derivative.
+// Derivative takes the pre-activation value z, like Func.
+type Activation struct {
+	Func       func(float64) float64
+	Derivative func(float64) float64
+}
+
+// registryMu guards activations, which Register extends at runtime
+var registryMu sync.RWMutex
+
+// activations holds every element-wise activation, keyed by name
+var activations = map[ActivationFunction]Activation{
+	ReLU:        {reluFunc, reluDerivative},
+	LeakyReLU:   leakyReLU(defaultLeakyReLUSlope),
+	ELU:         elu(defaultELUAlpha),
+	SELU:        {seluFunc, seluDerivative},
+	GELU:        {geluFunc, geluDerivative},
+	Swish:       {swishFunc, swishDerivative},
+	SiLU:        {swishFunc, swishDerivative},
+	Softplus:    {softplusFunc, sigmoidFunc},
+	HardSigmoid: {hardSigmoidFunc, hardSigmoidDerivative},
+	Linear:      {linearFunc, linearDerivative},
+	Sigmoid:     {sigmoidFunc, sigmoidDerivative},
+	Tanh:        {tanhFunc, tanhDerivative},
+}
+
+// parametricActivations build an activation from the parameter given in its
+// name, e.g. "leaky_relu(0.2)"
+var parametricActivations = map[ActivationFunction]func(float64) Activation{
+	LeakyReLU: leakyReLU,
+	ELU:       elu,
+}
+
+// LeakyReLUWithSlope returns a LeakyReLU with the given negative slope
+func LeakyReLUWithSlope(slope float64) ActivationFunction {
+	return withParameter(LeakyReLU, slope)
+}
+
+// ELUWithAlpha returns an ELU with the given alpha
+func ELUWithAlpha(alpha float64) ActivationFunction {
+	return withParameter(ELU, alpha)
+}
+
+func withParameter(name ActivationFunction, value float64) ActivationFunction {
+	return ActivationFunction(string(name) + "(" + strconv.FormatFloat(value, 'g', -1, 64) + ")")
+}
+
+// Register adds a custom element-wise activation under name, making it usable
+// anywhere a built-in one is: layer definitions, prediction, backpropagation
+// and saved model files. The derivative is taken with respect to the
+// pre-activation input. Models loaded from disk need their custom activations
+// registered before LoadWeights is called.
+func Register(name ActivationFunction, fn, derivative func(float64) float64) error {
+
+	if name == "" {
+		return fmt.Errorf("activation name cannot be empty")
+	}
+
+	if fn == nil || derivative == nil {
+		return fmt.Errorf("activation %q needs both a function and its derivative", name)
+	}
+
+	if strings.ContainsAny(string(name), "()") {
+		return fmt.Errorf("activation name %q cannot contain parentheses", name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := activations[name]; exists || name == Softmax {
+		return fmt.Errorf("activation %q is already registered", name)
+	}
+
+	activations[name] = Activation{Func: fn, Derivative: derivative}
+
+	return nil
+}
+
+// IsRegistered reports whether name resolves to a known activation, including Softmax
+func IsRegistered(name ActivationFunction) bool {
+	if name == Softmax {
+		return true
+	}
+	_, ok := Get(name)
+	return ok
+}
+
+// Get returns the activation and derivative registered under name.
+// Softmax is not element-wise and is handled separately by SoftmaxFunc.
+func Get(name ActivationFunction) (Activation, bool) {
+
+	registryMu.RLock()
+	act, ok := activations[name]
+	registryMu.RUnlock()
+
+	if ok {
+		return act, true
+	}
+
+	// Parametric form: name(value)
+	base, param, found := strings.Cut(string(name), "(")
+	if !found || !strings.HasSuffix(param, ")") {
+		return Activation{}, false
+	}
+
+	build, ok := parametricActivations[ActivationFunction(base)]
+	if !ok {
+		return Activation{}, false
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSuffix(param, ")"), 64)
+	if err != nil {
+		return Activation{}, false
+	}
+
+	// Keep the built activation so later lookups are a plain map read
+	act = build(value)
+
+	registryMu.Lock()
+	activations[name] = act
+	registryMu.Unlock()
+
+	return act, true
+}
+
 func GetActivationFunction(name ActivationFunction) func(float64) float64 {
-	switch name {
-	case ReLU:
-		return reluFunc
-	case Sigmoid:
-		return sigmoidFunc
-	case Tanh:
-		return tanhFunc
-	case Softmax:
-		// Softmax is applied to entire vector, not element-wise
+	// Softmax is applied to entire vector, not element-wise
+	act, ok := Get(name)
+	if !ok {
 		return nil
-	default:
+	}
+	return act.Func
+}
+
+// GetActivationDerivative returns the derivative of an element-wise activation
+// with respect to its pre-activation input, or nil if name is unknown
+func GetActivationDerivative(name ActivationFunction) func(float64) float64 {
+	act, ok := Get(name)
+	if !ok {
 		return nil
 	}
+	return act.Derivative
 }
 
 func reluFunc(x float64) float64 {
@@ -37,17 +192,139 @@ func reluFunc(x float64) float64 {
 	return 0
 }
 
+func reluDerivative(x float64) float64 {
+	if x > 0 {
+		return 1.0
+	}
+	return 0.0
+}
+
+func leakyReLU(slope float64) Activation {
+	return Activation{
+		Func: func(x float64) float64 {
+			if x > 0 {
+				return x
+			}
+			return slope * x
+		},
+		Derivative: func(x float64) float64 {
+			if x > 0 {
+				return 1.0
+			}
+			return slope
+		},
+	}
+}
+
+func elu(alpha float64) Activation {
+	return Activation{
+		Func: func(x float64) float64 {
+			if x > 0 {
+				return x
+			}
+			return alpha * math.Expm1(x)
+		},
+		Derivative: func(x float64) float64 {
+			if x > 0 {
+				return 1.0
+			}
+			return alpha * math.Exp(x)
+		},
+	}
+}
+
+func seluFunc(x float64) float64 {
+	if x > 0 {
+		return seluScale * x
+	}
+	return seluScale * seluAlpha * math.Expm1(x)
+}
+
+func seluDerivative(x float64) float64 {
+	if x > 0 {
+		return seluScale
+	}
+	return seluScale * seluAlpha * math.Exp(x)
+}
+
+// geluFunc is the exact GELU, x * Phi(x) with Phi the standard normal CDF
+func geluFunc(x float64) float64 {
+	return 0.5 * x * (1.0 + math.Erf(x/math.Sqrt2))
+}
+
+func geluDerivative(x float64) float64 {
+	cdf := 0.5 * (1.0 + math.Erf(x/math.Sqrt2))
+	pdf := math.Exp(-0.5*x*x) / math.Sqrt(2.0*math.Pi)
+	return cdf + x*pdf
+}
+
+// swishFunc is x * sigmoid(x), also known as SiLU
+func swishFunc(x float64) float64 {
+	return x * sigmoidFunc(x)
+}
+
+func swishDerivative(x float64) float64 {
+	s := sigmoidFunc(x)
+	return s + x*s*(1.0-s)
+}
+
+// softplusFunc is log(1 + e^x), its derivative is the sigmoid
+func softplusFunc(x float64) float64 {
+	// max(x, 0) + log(1 + e^(-|x|)) avoids overflow for large x
+	return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+}
+
+// hardSigmoidFunc is the piecewise linear approximation clip(x/6 + 1/2, 0, 1)
+func hardSigmoidFunc(x float64) float64 {
+	return math.Max(0, math.Min(1, x/6.0+0.5))
+}
+
+func hardSigmoidDerivative(x float64) float64 {
+	if x > -3 && x < 3 {
+		return 1.0 / 6.0
+	}
+	return 0.0
+}
+
+func linearFunc(x float64) float64 {
+	return x
+}
+
+func linearDerivative(x float64) float64 {
+	return 1.0
+}
+
 func sigmoidFunc(x float64) float64 {
 	return 1 / (1 + math.Exp(-x))
 }
 
+func sigmoidDerivative(x float64) float64 {
+	// sigmoid'(z) = sigmoid(z) * (1 - sigmoid(z))
+	s := sigmoidFunc(x)
+	return s * (1.0 - s)
+}
+
 func tanhFunc(x float64) float64 {
 	return math.Tanh(x)
 }
 
+func tanhDerivative(x float64) float64 {
+	// tanh'(z) = 1 - tanh(z)^2
+	t := math.Tanh(x)
+	return 1.0 - (t * t)
+}
+
 // SoftmaxFunc applies softmax to a vector
-func SoftmaxFunc(x []float64) []float64 {
-	result := make([]float64, len(x))
+func SoftmaxFunc[T vectors.Float](x []T) []T {
+	result := make([]T, len(x))
+	SoftmaxInto(result, x)
+	return result
+}
+
+// SoftmaxInto writes the softmax of x to result, which must have the same
+// length as x. result and x may be the same slice. The exponentials are
+// summed in float64 whatever the element type.
+func SoftmaxInto[T vectors.Float](result, x []T) {
 	max := x[0]
 	for i := 1; i < len(x); i++ {
 		if x[i] > max {
@@ -58,13 +335,13 @@ func SoftmaxFunc(x []float64) []float64 {
 	// Subtract max for numerical stability
 	sum := 0.0
 	for i := 0; i < len(x); i++ {
-		result[i] = math.Exp(x[i] - max)
-		sum += result[i]
+		e := math.Exp(float64(x[i] - max))
+		result[i] = T(e)
+		sum += e
 	}
 
 	// Normalize
 	for i := 0; i < len(result); i++ {
-		result[i] /= sum
+		result[i] = T(float64(result[i]) / sum)
 	}
-	return result
 }
@@ -2,30 +2,49 @@ package neuralnetwork
 
 import (
 	"fmt"
+	"math/rand"
 
 	activation "github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/backend"
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
 )
 
-// Optimizer represents the optimization algorithm
-type Optimizer string
-
 // LossFunction represents the loss function
 type LossFunction string
 
 // Initialization represents weight initialization strategy
-type Initialization string
+type Initialization = layers.Initialization
+
+const (
+	MeanSquaredError              LossFunction = "mse"
+	MeanAbsoluteError             LossFunction = "mae"
+	Huber                         LossFunction = "huber"
+	LogCosh                       LossFunction = "log_cosh"
+	Hinge                         LossFunction = "hinge"
+	KLDivergence                  LossFunction = "kl_divergence"
+	BinaryCrossEntropy            LossFunction = "binary_crossentropy"
+	CategoricalCrossEntropy       LossFunction = "categorical_crossentropy"
+	SparseCategoricalCrossEntropy LossFunction = "sparse_categorical_crossentropy"
+)
 
 const (
-	XavierUniformInitializer  Initialization = "xavier_uniform"
-	XavierNormalInitializer   Initialization = "xavier_normal"
-	KaimingUniformInitializer Initialization = "kaiming_uniform"
-	KaimingNormalInitializer  Initialization = "kaiming_normal"
+	XavierUniformInitializer  = layers.XavierUniform
+	XavierNormalInitializer   = layers.XavierNormal
+	KaimingUniformInitializer = layers.KaimingUniform
+	KaimingNormalInitializer  = layers.KaimingNormal
 )
 
 // InputLayer represents the input layer configuration
 type InputLayer struct {
 	Neurons            int
 	ActivationFunction activation.ActivationFunction
+
+	// Shape is the shape of one sample as a Network sees it, e.g. [28, 28, 1]
+	// for grayscale images stored as [height, width, channels]. Samples are
+	// still given as flat rows of Neurons values, and InitializeWeights sets
+	// Neurons from Shape when it is zero. Empty means [Neurons].
+	Shape []int
 }
 
 // OutputLayer represents the output layer configuration
@@ -33,22 +52,103 @@ type OutputLayer struct {
 	Neurons            int
 	ActivationFunction activation.ActivationFunction
 	Initialization     Initialization
+
+	// Regularization sets L1/L2 penalties and a weight constraint for this layer
+	Regularization Regularization
 }
 
-// TrainingConfig represents training hyperparameters
-type TrainingConfig struct {
+// Float is the set of element types a model can be built on, float32 or float64
+type Float = vectors.Float
+
+// TrainingConfigOf represents training hyperparameters of a model with element type T
+type TrainingConfigOf[T Float] struct {
 	Epochs          int
 	LearningRate    float64
-	Optimizer       Optimizer
+	Optimizer       OptimizerName
 	LossFunction    LossFunction
 	BatchSize       int
 	ValidationSplit float64
+
+	// CustomOptimizer, if set, is used instead of the one named by Optimizer
+	CustomOptimizer OptimizerOf[T]
+
+	// LearningRateSchedule, if set, adjusts LearningRate at the start of every epoch
+	LearningRateSchedule LearningRateSchedule
+
+	// EarlyStopping, if set, ends Fit before Epochs once the validation
+	// metric it watches stops improving
+	EarlyStopping *EarlyStopping
+
+	// Seed makes shuffling and dropout reproducible. Zero seeds from the clock.
+	Seed int64
+
+	// Workers splits each mini-batch into this many shards whose gradients are
+	// computed in parallel. Zero or one trains on a single goroutine. Networks
+	// with batch normalization are never sharded.
+	Workers int
+
+	// ClipNorm, if positive, rescales the gradients of every step so their
+	// global L2 norm is at most ClipNorm, which keeps exploding gradients of
+	// recurrent networks in check
+	ClipNorm float64
+
+	// OutputLosses sets the loss of every output of a Graph network, in
+	// order. Empty entries use LossFunction, or follow the output's
+	// activation when that is empty too. Targets hold the targets of every
+	// output side by side: one value for a sparse categorical cross-entropy
+	// output, one per output value otherwise.
+	OutputLosses []LossFunction
+
+	// LossWeights scales the loss of every output of a Graph network, in
+	// order. Nil weighs them all 1.
+	LossWeights []float64
+
+	// Optimizer hyperparameters, zero values fall back to the usual defaults
+	Momentum    float64
+	Rho         float64
+	Beta1       float64
+	Beta2       float64
+	Epsilon     float64
+	WeightDecay float64 // decays the weights, not biases or normalization parameters
+}
+
+// TrainingConfig represents training hyperparameters of a float64 model
+type TrainingConfig = TrainingConfigOf[float64]
+
+// ModelWeightsAndBiasesOf stores the model parameters as contiguous tensors.
+// For trainable layer l, Weights[l] has shape [neurons, inputs] and
+// Biases[l] has shape [neurons].
+type ModelWeightsAndBiasesOf[T Float] struct {
+	Weights []*vectors.Tensor[T]
+	Biases  []*vectors.Tensor[T]
+
+	// Normalization parameters per trainable layer, nil for layers without normalization
+	Gammas           []*vectors.Tensor[T]
+	Betas            []*vectors.Tensor[T]
+	RunningMeans     []*vectors.Tensor[T]
+	RunningVariances []*vectors.Tensor[T]
 }
 
-// ModelWeightsAndBiases stores the model parameters
-type ModelWeightsAndBiases struct {
-	Weights [][][]float64
-	Biases  [][]float64
+// ModelWeightsAndBiases stores the parameters of a float64 model
+type ModelWeightsAndBiases = ModelWeightsAndBiasesOf[float64]
+
+// WeightRows returns the weights in the [layer][neuron][input] layout, as
+// slices sharing the tensors' memory, for code reading Weights[l][j][k]
+func (params *ModelWeightsAndBiasesOf[T]) WeightRows() [][][]T {
+	rows := make([][][]T, len(params.Weights))
+	for l, w := range params.Weights {
+		rows[l] = w.Rows()
+	}
+	return rows
+}
+
+// BiasValues returns the biases in the [layer][neuron] layout, as slices sharing the tensors' memory
+func (params *ModelWeightsAndBiasesOf[T]) BiasValues() [][]T {
+	values := make([][]T, len(params.Biases))
+	for l, b := range params.Biases {
+		values[l] = b.Data
+	}
+	return values
 }
 
 // Layer represents a hidden layer
@@ -56,50 +156,127 @@ type Layer struct {
 	Neurons            int
 	ActivationFunction activation.ActivationFunction
 	Initialization     Initialization
+
+	// Dropout is the fraction of this layer's outputs zeroed during training
+	// (inverted dropout). Prediction and evaluation never drop.
+	Dropout float64
+
+	// Normalization is applied to the pre-activations, before the activation function
+	Normalization Normalization
+
+	// Regularization sets L1/L2 penalties and a weight constraint for this layer
+	Regularization Regularization
 }
 
-// NeuralNetwork represents the neural network architecture
-type NeuralNetwork struct {
+// NeuralNetworkOf represents the neural network architecture, with
+// parameters and activations of element type T
+type NeuralNetworkOf[T Float] struct {
 	InputLayer       InputLayer
 	Layers           []Layer
 	OutputLayer      OutputLayer
-	WeightsAndBiases ModelWeightsAndBiases
+	WeightsAndBiases ModelWeightsAndBiasesOf[T]
+
+	// Network, if set, replaces Layers and OutputLayer: inputs of
+	// InputLayer.Neurons values are run through it and the parameters it
+	// reports are trained. Its output samples must be flat vectors. A
+	// Network trains on a single goroutine, and training fails when Workers
+	// is above 1 or Layers or OutputLayer are set as well. L1/L2 penalties
+	// and weight constraints are not supported with a Network. A layers.Graph
+	// may have several inputs, which share the input rows, and several
+	// outputs, which share the target rows.
+	Network layers.Layer[T]
+
+	// Backend runs the array operations of training and inference, nil uses backend.CPU
+	Backend backend.Backend[T]
+
+	// inference is the network of Layers and OutputLayer that Predict runs,
+	// built on first use. Training shards build their own.
+	inference *denseNetwork[T]
+}
+
+// NeuralNetwork is a float64 neural network
+type NeuralNetwork = NeuralNetworkOf[float64]
+
+// ModelOf represents the complete model with network and training config.
+// T selects the precision used for training and inference: float32 halves
+// memory and bandwidth, float64 is the default.
+type ModelOf[T Float] struct {
+	NeuralNetwork  NeuralNetworkOf[T]
+	TrainingConfig TrainingConfigOf[T]
+
+	optimizer            OptimizerOf[T]
+	loadedOptimizerState *OptimizerStateOf[T]
+
+	rng *rand.Rand
+
+	// Training buffers reused by every step, one workspace per shard
+	workspaces []*workspace[T]
+	stepParams [][]T
+	stepGrads  [][]T
+	stepDecay  []bool
+
+	// constraintRow converts weight rows for constraints when T is not float64
+	constraintRow []float64
+}
+
+// Model is a float64 model
+type Model = ModelOf[float64]
+
+// DType returns the precision of the model, "float32" or "float64"
+func (model *ModelOf[T]) DType() string {
+	return vectors.DType[T]()
 }
 
-// Model represents the complete model with network and training config
-type Model struct {
-	NeuralNetwork  NeuralNetwork
-	TrainingConfig TrainingConfig
+// backend returns the backend the network runs on
+func (nn *NeuralNetworkOf[T]) backend() backend.Backend[T] {
+	if nn.Backend == nil {
+		return backend.Default[T]()
+	}
+	return nn.Backend
 }
 
 // AddLayer adds a hidden layer to the neural network
-func (nn *NeuralNetwork) AddLayer(layer Layer) {
+func (nn *NeuralNetworkOf[T]) AddLayer(layer Layer) {
 	nn.Layers = append(nn.Layers, layer)
 }
 
 // SetOutputLayer sets the output layer configuration
-func (nn *NeuralNetwork) SetOutputLayer(layer OutputLayer) {
+func (nn *NeuralNetworkOf[T]) SetOutputLayer(layer OutputLayer) {
 	nn.OutputLayer = layer
 }
 
 // SetInputLayer sets the input layer configuration
-func (nn *NeuralNetwork) SetInputLayer(layer InputLayer) {
+func (nn *NeuralNetworkOf[T]) SetInputLayer(layer InputLayer) {
 	nn.InputLayer = layer
 }
 
 // Summary prints the neural network architecture
-func (nn *NeuralNetwork) Summary() {
-
-	TotalLayers := len(nn.Layers) + 2 // Input and Output layers
+func (nn *NeuralNetworkOf[T]) Summary() {
 
 	fmt.Println("Neural Network Summary:")
-	fmt.Println("Total Layers:", TotalLayers)
+
+	if nn.Network == nil {
+		TotalLayers := len(nn.Layers) + 2 // Input and Output layers
+		fmt.Println("Total Layers:", TotalLayers)
+	}
+
 	fmt.Println("Input Layer Neurons:", nn.InputLayer.Neurons)
 	fmt.Println("Input Layer Activation Function:", nn.InputLayer.ActivationFunction)
 
+	if nn.Network != nil {
+		nn.networkSummary()
+		return
+	}
+
 	for i, layer := range nn.Layers {
 		fmt.Println("Layer", i+1, "Neurons:", layer.Neurons)
 		fmt.Println("Layer", i+1, "Activation Function:", layer.ActivationFunction)
+		if layer.Dropout > 0 {
+			fmt.Println("Layer", i+1, "Dropout:", layer.Dropout)
+		}
+		if layer.Normalization != NoNormalization {
+			fmt.Println("Layer", i+1, "Normalization:", layer.Normalization)
+		}
 	}
 
 	fmt.Println("Output Layer Neurons:", nn.OutputLayer.Neurons)
@@ -108,28 +285,19 @@ func (nn *NeuralNetwork) Summary() {
 }
 
 // InitializeWeights initializes the model weights and biases
-func (model *Model) InitializeWeights() error {
+func (model *ModelOf[T]) InitializeWeights() error {
 
-	totalLayers := len(model.NeuralNetwork.Layers) + 2 // Input and Output layers
+	if model.NeuralNetwork.Network != nil {
+		return model.buildNetwork()
+	}
 
-	totalTrainableLayers := totalLayers - 1 // Exclude input layer
+	totalTrainableLayers := len(model.NeuralNetwork.Layers) + 1 // Exclude input layer
 
-	biases := make([][]float64, totalTrainableLayers)
+	// Biases start at zero
+	biases := make([]*vectors.Tensor[T], totalTrainableLayers)
 
 	for i := range biases {
-		if i == totalTrainableLayers-1 {
-
-			biases[i] = make([]float64, model.NeuralNetwork.OutputLayer.Neurons)
-			continue
-		}
-		biases[i] = make([]float64, model.NeuralNetwork.Layers[i].Neurons)
-	}
-
-	// Bias initialization
-	for i := range biases {
-		for j := range biases[i] {
-			biases[i][j] = 0.0
-		}
+		biases[i] = vectors.NewTensor[T](fanOut(&model.NeuralNetwork, i))
 	}
 
 	model.NeuralNetwork.WeightsAndBiases.Biases = biases
@@ -137,13 +305,19 @@ func (model *Model) InitializeWeights() error {
 	// Allocate weight tensors
 	allocateWeights(&model.NeuralNetwork)
 
+	// Normalized layers start as the identity: gamma = 1, beta = 0
+	allocateNormalization(&model.NeuralNetwork)
+
+	// Weights are drawn from the model's source, seeded by TrainingConfig.Seed
+	rng := model.random()
+
 	// Initialize each hidden layer with its own strategy
 	for i, layer := range model.NeuralNetwork.Layers {
 		init := layer.Initialization
 		if init == "" {
 			init = XavierNormalInitializer // default
 		}
-		initLayerWeights(&model.NeuralNetwork, i, init)
+		initLayerWeights(&model.NeuralNetwork, i, init, rng)
 	}
 
 	// Initialize output layer
@@ -151,7 +325,7 @@ func (model *Model) InitializeWeights() error {
 	if outputInit == "" {
 		outputInit = KaimingNormalInitializer // default
 	}
-	initLayerWeights(&model.NeuralNetwork, len(model.NeuralNetwork.Layers), outputInit)
+	initLayerWeights(&model.NeuralNetwork, len(model.NeuralNetwork.Layers), outputInit, rng)
 
 	return nil
 }
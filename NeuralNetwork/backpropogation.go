@@ -1,287 +1,165 @@
 package neuralnetwork
 
 import (
-	"fmt"
 	"math"
+	"math/rand"
 
-	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/vectors"
 )
 
-// This uses mini batch gradient descent
-func (model *Model) BackpropagateBatch(batchInputs [][]float64, batchTargets [][]float64) error {
+// This uses mini batch gradient descent.
+// Gradients are accumulated into buffers owned by the model and the
+// parameters are updated in place once the backward pass is done, so after
+// the first batch a step does not allocate (with Workers <= 1).
+func (model *ModelOf[T]) BackpropagateBatch(batchInputs [][]T, batchTargets [][]T) error {
 
-	oldWeights := model.NeuralNetwork.WeightsAndBiases.Weights
-
-	newWeights := make([][][]float64, len(oldWeights))
+	optimizer, err := model.getOptimizer()
+	if err != nil {
+		return err
+	}
 
-	for l := range oldWeights {
-		newWeights[l] = make([][]float64, len(oldWeights[l]))
+	nn := &model.NeuralNetwork
 
-		for j := range oldWeights[l] {
-			newWeights[l][j] = make([]float64, len(oldWeights[l][j]))
-			copy(newWeights[l][j], oldWeights[l][j])
-		}
+	// Batch averaged gradients of every parameter, applied together once the backward pass is done
+	ws, err := model.gradients(batchInputs, batchTargets)
+	if err != nil {
+		return err
 	}
 
-	oldBiases := model.NeuralNetwork.WeightsAndBiases.Biases
+	// Update all parameters in place with a single optimizer step
+	model.stepParams, model.stepGrads = networkParameters(ws.net, model.stepParams[:0], model.stepGrads[:0])
 
-	newBiases := make([][]float64, len(oldBiases))
+	clipGradients(model.stepGrads, model.TrainingConfig.ClipNorm)
 
-	for l := range oldBiases {
-		newBiases[l] = make([]float64, len(oldBiases[l]))
-		copy(newBiases[l], oldBiases[l])
+	// Keep weight decay off the biases and normalization parameters
+	if decayer, ok := optimizer.(WeightDecayer); ok {
+		model.stepDecay = decayMask(ws.net, model.stepDecay[:0])
+		decayer.SetDecayMask(model.stepDecay)
 	}
 
-	batch_size := len(batchInputs)
-
-	deltas := make([][]float64, batch_size)
-
-	// Initialize memory for deltas of output layers
-	for i := 0; i < batch_size; i++ {
+	// Update only the rows of sparse parameters that were looked up, when the optimizer can
+	sparse, ok := optimizer.(SparseOptimizerOf[T])
+	rows := networkRows(ws.net)
 
-		deltas[i] = make([]float64, model.NeuralNetwork.OutputLayer.Neurons)
+	if ok && rows != nil {
+		err = sparse.StepSparse(model.stepParams, model.stepGrads, rows)
+	} else {
+		err = optimizer.Step(model.stepParams, model.stepGrads)
 	}
 
-	z, a, predictions, err := model.PredictBatch(batchInputs, batchTargets)
-
 	if err != nil {
 		return err
 	}
 
-	// Output Layer Backpropagation
-
-	for i := 0; i < batch_size; i++ {
-		for j := 0; j < model.NeuralNetwork.OutputLayer.Neurons; j++ {
+	// Project the updated weights back onto their constraints
+	model.constraintRow = nn.applyConstraints(nn.WeightsAndBiases.Weights, model.constraintRow)
 
-			if model.NeuralNetwork.OutputLayer.ActivationFunction != activation.Softmax {
-				activationDerivativeFunc := getActivationDerivative(model.NeuralNetwork.OutputLayer.ActivationFunction)
+	return nil
 
-				if activationDerivativeFunc == nil {
-					return fmt.Errorf("unsupported activation function for backpropagation: %s", model.NeuralNetwork.OutputLayer.ActivationFunction)
-				}
+}
 
-				// For non-softmax activations, we need to multiply by the derivative of the activation function
-				deltas[i][j] = (predictions[i][j] - batchTargets[i][j]) * activationDerivativeFunc(z[i][len(model.NeuralNetwork.WeightsAndBiases.Biases)-1][j])
+// gradients computes the gradients of the training objective for a
+// mini-batch: the loss averaged over the batch plus the L1 / L2 penalties
+// of the current weights. It returns the workspace whose network holds them
+// in its Grads, which are overwritten by the next call.
+func (model *ModelOf[T]) gradients(batchInputs [][]T, batchTargets [][]T) (*workspace[T], error) {
 
-				continue
-			}
+	nn := &model.NeuralNetwork
 
-			// For softmax with cross-entropy, the delta is simply (pred - target)
-			deltas[i][j] = predictions[i][j] - batchTargets[i][j]
-		}
+	if err := nn.checkNetwork(model.TrainingConfig.Workers); err != nil {
+		return nil, err
 	}
 
-	lastLayer := len(model.NeuralNetwork.WeightsAndBiases.Weights) - 1
-	prevLayer := lastLayer - 1
-
-	outputNeurons := model.NeuralNetwork.OutputLayer.Neurons
-	prevNeurons := model.NeuralNetwork.Layers[len(model.NeuralNetwork.Layers)-1].Neurons
-
-	gradW := make([][]float64, outputNeurons)
-	gradB := make([]float64, outputNeurons)
-
-	for j := 0; j < outputNeurons; j++ {
-		gradW[j] = make([]float64, prevNeurons)
+	ws, err := model.batchGradients(batchInputs, batchTargets)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < batch_size; i++ {
+	if ws.dense != nil {
+		nn.addRegularizationGradients(&ws.dense.grads)
+	}
 
-		for j := 0; j < outputNeurons; j++ {
+	return ws, nil
+}
 
-			for k := 0; k < prevNeurons; k++ {
+// backward runs the forward and backward pass over the samples of one shard
+// and writes the gradients of the loss, summed over those samples and
+// multiplied by scale, to the Grads of the shard's network. Dropout masks are
+// drawn from rng.
+//
+// The loss is recorded on the workspace's tape on top of the network's
+// output, its gradient with respect to that output is then passed back
+// through the layers.
+func (model *ModelOf[T]) backward(ws *workspace[T], batchInputs [][]T, batchTargets [][]T, scale T, rng *rand.Rand) error {
 
-				gradW[j][k] += deltas[i][j] * a[i][prevLayer][k]
-			}
+	nn := &model.NeuralNetwork
 
-			gradB[j] += deltas[i][j]
-		}
+	loss, err := model.lossFunction()
+	if err != nil {
+		return err
 	}
 
-	scale := 1.0 / float64(batch_size)
-
-	for j := 0; j < outputNeurons; j++ {
-		for k := 0; k < prevNeurons; k++ {
-			gradW[j][k] *= scale
-		}
-		gradB[j] *= scale
+	if ws.net, err = model.network(ws, rng); err != nil {
+		return err
 	}
 
-	for j := 0; j < outputNeurons; j++ {
-
-		for k := 0; k < prevNeurons; k++ {
+	cache := &ws.cache
 
-			newWeights[lastLayer][j][k] -=
-				model.TrainingConfig.LearningRate * gradW[j][k]
-		}
-
-		newBiases[lastLayer][j] -=
-			model.TrainingConfig.LearningRate * gradB[j]
+	if cache.input, err = nn.networkInput(cache.input, batchInputs); err != nil {
+		return err
 	}
 
-	// Backprop For Hidden Layers
-
-	for l := (len(model.NeuralNetwork.Layers)) - 1; l >= 0; l-- {
-
-		currentLayerNeurons := model.NeuralNetwork.Layers[l].Neurons
-
-		nextLayerNeurons := 0
-
-		if l == len(model.NeuralNetwork.Layers)-1 {
-			nextLayerNeurons = model.NeuralNetwork.OutputLayer.Neurons
-		} else {
-			nextLayerNeurons = model.NeuralNetwork.Layers[l+1].Neurons
-		}
-
-		// Reallocating memory for deltas of hidden layers
-
-		// for each batch
-		newDeltas := make([][]float64, batch_size)
-
-		// for each neuron in the current layer
-		for i := 0; i < batch_size; i++ {
-			newDeltas[i] = make([]float64, currentLayerNeurons)
-		}
-
-		// Calculating new deltas
-
-		// each batch
-		for i := 0; i < batch_size; i++ {
-
-			// each neuron in the current layer
-			for j := 0; j < currentLayerNeurons; j++ {
-
-				var nextWeights [][]float64
-
-				if l == len(model.NeuralNetwork.Layers)-1 {
-					nextWeights = model.NeuralNetwork.WeightsAndBiases.Weights[lastLayer]
-				} else {
-					nextWeights = model.NeuralNetwork.WeightsAndBiases.Weights[l+1]
-				}
-
-				// each neuron in the next layer
-				for k := 0; k < nextLayerNeurons; k++ {
-					newDeltas[i][j] += deltas[i][k] * nextWeights[k][j]
-				}
-
-				// perform activation derivative multiplication for hidden layers
-
-				activationDerivativeFunc := getActivationDerivative(model.NeuralNetwork.Layers[l].ActivationFunction)
-
-				if activationDerivativeFunc == nil {
-					return fmt.Errorf("unsupported activation function for backpropagation: %s", model.NeuralNetwork.Layers[l].ActivationFunction)
-				}
-
-				newDeltas[i][j] *= activationDerivativeFunc(z[i][l][j])
-
-			}
-
-		}
-
-		// Update deltas for the next iteration
-		deltas = newDeltas
-
-		// Compute gradients and update weights and biases for layer l
-
-		gradWHidden := make([][]float64, currentLayerNeurons)
-		gradBHidden := make([]float64, currentLayerNeurons)
-
-		prevNeurons := 0
-
-		if l == 0 {
-			prevNeurons = model.NeuralNetwork.InputLayer.Neurons
-		} else {
-
-			prevNeurons = model.NeuralNetwork.Layers[l-1].Neurons
-		}
-
-		for j := 0; j < currentLayerNeurons; j++ {
-			gradWHidden[j] = make([]float64, prevNeurons)
-		}
-
-		// each sample from batch
-		for i := 0; i < batch_size; i++ {
-
-			// each neuron in current
-			for j := 0; j < currentLayerNeurons; j++ {
-
-				for k := 0; k < prevNeurons; k++ {
-
-					if l != 0 {
-
-						// grad for weight connecting neuron k in previous layer to neuron j in current layer += delta of neuron j * activation of neuron k in previous layer
-						gradWHidden[j][k] += deltas[i][j] * a[i][l-1][k]
-					} else {
-						// grad for weight connecting input k to neuron j in first hidden layer += delta of neuron j * input k
-						gradWHidden[j][k] += deltas[i][j] * batchInputs[i][k]
-					}
-
-				}
-
-				// sum of all gradients of samples in batch
-				gradBHidden[j] += deltas[i][j]
-
-			}
-
-		}
-
-		for j := 0; j < currentLayerNeurons; j++ {
+	out, err := nn.networkForward(ws.net, cache.input, true)
+	if err != nil {
+		return err
+	}
 
-			gradBHidden[j] /= float64(batch_size)
+	ws.outputGrad = vectors.Reuse(ws.outputGrad, out.Shape...)
 
-			for k := 0; k < prevNeurons; k++ {
+	prediction, err := nn.output(&cache.tape, out, ws.outputGrad)
+	if err != nil {
+		return err
+	}
 
-				gradWHidden[j][k] /= float64(batch_size)
-			}
+	lossNode, err := cache.tape.Loss(prediction, batchTargets, loss, scale)
+	if err != nil {
+		return err
+	}
 
-		}
+	if err := cache.tape.Backward(lossNode); err != nil {
+		return err
+	}
 
-		// Update weights and biases for layer l
+	_, err = ws.net.Backward(ws.outputGrad)
 
-		for j := 0; j < currentLayerNeurons; j++ {
+	return err
+}
 
-			for k := 0; k < prevNeurons; k++ {
+// clipGradients scales grads down in place so that their global L2 norm,
+// taken over every tensor together, is at most maxNorm. A maxNorm of zero or
+// less leaves them unchanged.
+func clipGradients[T Float](grads [][]T, maxNorm float64) {
 
-				newWeights[l][j][k] -=
-					model.TrainingConfig.LearningRate * gradWHidden[j][k]
-			}
+	if maxNorm <= 0 {
+		return
+	}
 
-			newBiases[l][j] -=
-				model.TrainingConfig.LearningRate * gradBHidden[j]
+	sum := 0.0
+	for _, g := range grads {
+		for _, v := range g {
+			sum += float64(v) * float64(v)
 		}
-
 	}
 
-	// replace the model weights and biases with the new values
-	model.NeuralNetwork.WeightsAndBiases.Weights = newWeights
-	model.NeuralNetwork.WeightsAndBiases.Biases = newBiases
-
-	return nil
-
-}
+	norm := math.Sqrt(sum)
+	if norm <= maxNorm {
+		return
+	}
 
-// getActivationDerivative returns the derivative function for an activation
-func getActivationDerivative(activationFunc activation.ActivationFunction) func(float64) float64 {
-	switch activationFunc {
-	case activation.ReLU:
-		return func(z float64) float64 {
-			if z > 0 {
-				return 1.0
-			}
-			return 0.0
-		}
-	case activation.Sigmoid:
-		return func(z float64) float64 {
-			// sigmoid'(z) = sigmoid(z) * (1 - sigmoid(z))
-			s := 1.0 / (1.0 + math.Exp(-z))
-			return s * (1.0 - s)
-		}
-	case activation.Tanh:
-		return func(z float64) float64 {
-			// tanh'(z) = 1 - tanh(z)^2
-			t := math.Tanh(z)
-			return 1.0 - (t * t)
+	scale := T(maxNorm / norm)
+	for _, g := range grads {
+		for i := range g {
+			g[i] *= scale
 		}
-	default:
-		return nil
 	}
 }
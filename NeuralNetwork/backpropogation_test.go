@@ -0,0 +1,416 @@
+package neuralnetwork_test
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
+)
+
+func newTestModel(t testing.TB, hidden []nn.Layer, output nn.OutputLayer, config nn.TrainingConfig) *nn.Model {
+	t.Helper()
+
+	model := &nn.Model{
+		NeuralNetwork: nn.NeuralNetwork{
+			InputLayer:  nn.InputLayer{Neurons: 8},
+			Layers:      hidden,
+			OutputLayer: output,
+		},
+		TrainingConfig: config,
+	}
+
+	if err := model.InitializeWeights(); err != nil {
+		t.Fatal(err)
+	}
+
+	return model
+}
+
+func testBatch(batchSize, inputs, outputs int) ([][]float64, [][]float64) {
+	rng := rand.New(rand.NewSource(1))
+
+	x := make([][]float64, batchSize)
+	y := make([][]float64, batchSize)
+
+	for i := range x {
+		x[i] = make([]float64, inputs)
+		for k := range x[i] {
+			x[i][k] = rng.NormFloat64()
+		}
+		y[i] = make([]float64, outputs)
+		y[i][rng.Intn(outputs)] = 1
+	}
+
+	return x, y
+}
+
+func TestBackpropagateBatchDoesNotAllocate(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		hidden []nn.Layer
+		output nn.OutputLayer
+		config nn.TrainingConfig
+	}{
+		{
+			name:   "softmax cross-entropy with sgd",
+			hidden: []nn.Layer{{Neurons: 16, ActivationFunction: activation.ReLU}},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+			config: nn.TrainingConfig{LearningRate: 0.01, Optimizer: nn.SGD},
+		},
+		{
+			name: "dropout, normalization and regularization with adam",
+			hidden: []nn.Layer{
+				{Neurons: 16, ActivationFunction: activation.Tanh, Dropout: 0.2, Normalization: nn.BatchNorm},
+				{Neurons: 12, ActivationFunction: activation.LeakyReLUWithSlope(0.1), Normalization: nn.LayerNorm,
+					Regularization: nn.Regularization{L1: 1e-4, L2: 1e-3, Constraint: nn.MaxNorm{Value: 3}}},
+			},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+			config: nn.TrainingConfig{LearningRate: 0.01, Optimizer: nn.Adam, Seed: 1},
+		},
+		{
+			name:   "sigmoid outputs with binary cross-entropy",
+			hidden: []nn.Layer{{Neurons: 16, ActivationFunction: activation.GELU}},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Sigmoid},
+			config: nn.TrainingConfig{LearningRate: 0.01, Optimizer: nn.RMSprop, LossFunction: nn.BinaryCrossEntropy},
+		},
+		{
+			name:   "gradient clipping with momentum",
+			hidden: []nn.Layer{{Neurons: 16, ActivationFunction: activation.Tanh}},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+			config: nn.TrainingConfig{LearningRate: 0.01, Optimizer: nn.Momentum, ClipNorm: 0.01},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			model := newTestModel(t, tt.hidden, tt.output, tt.config)
+			x, y := testBatch(16, 8, 4)
+
+			// The first step sizes the buffers and the optimizer state
+			if err := model.BackpropagateBatch(x, y); err != nil {
+				t.Fatal(err)
+			}
+
+			allocs := testing.AllocsPerRun(20, func() {
+				if err := model.BackpropagateBatch(x, y); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			if allocs != 0 {
+				t.Errorf("BackpropagateBatch allocated %v times per step, want 0", allocs)
+			}
+		})
+	}
+}
+
+func TestAdamWDoesNotDecayBiases(t *testing.T) {
+
+	model := newTestModel(t,
+		[]nn.Layer{{Neurons: 4, ActivationFunction: activation.ReLU}},
+		nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+		nn.TrainingConfig{LearningRate: 0.1, Optimizer: nn.AdamW, WeightDecay: 0.5},
+	)
+
+	// A hidden neuron with no weights and a negative bias never fires, so
+	// its bias gets no gradient
+	weights := model.NeuralNetwork.WeightsAndBiases.Weights[0]
+	biases := model.NeuralNetwork.WeightsAndBiases.Biases[0]
+
+	clear(weights.Row(0))
+	biases.Data[0] = -1
+
+	x, y := testBatch(8, 8, 4)
+
+	if err := model.BackpropagateBatch(x, y); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := biases.Data[0]; got != -1 {
+		t.Errorf("bias with zero gradient = %v after an AdamW step, want -1", got)
+	}
+}
+
+func TestClipNorm(t *testing.T) {
+
+	// With plain SGD at a learning rate of 1 the step is minus the clipped gradient
+	config := nn.TrainingConfig{LearningRate: 1, Optimizer: nn.SGD, ClipNorm: 0.01}
+
+	model := newTestModel(t,
+		[]nn.Layer{{Neurons: 6, ActivationFunction: activation.Tanh}},
+		nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+		config,
+	)
+
+	params := &model.NeuralNetwork.WeightsAndBiases
+
+	var before []float64
+	for l := range params.Weights {
+		before = append(before, params.Weights[l].Data...)
+		before = append(before, params.Biases[l].Data...)
+	}
+
+	x, y := testBatch(8, 8, 4)
+	if err := model.BackpropagateBatch(x, y); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, k := 0.0, 0
+	for l := range params.Weights {
+		for _, values := range [][]float64{params.Weights[l].Data, params.Biases[l].Data} {
+			for _, v := range values {
+				sum += (v - before[k]) * (v - before[k])
+				k++
+			}
+		}
+	}
+
+	if norm := math.Sqrt(sum); math.Abs(norm-config.ClipNorm) > 1e-12 {
+		t.Errorf("step has norm %g, want %g", norm, config.ClipNorm)
+	}
+}
+
+func BenchmarkBackpropagateBatch(b *testing.B) {
+
+	model := newTestModel(b,
+		[]nn.Layer{
+			{Neurons: 64, ActivationFunction: activation.ReLU},
+			{Neurons: 32, ActivationFunction: activation.ReLU},
+		},
+		nn.OutputLayer{Neurons: 10, ActivationFunction: activation.Softmax},
+		nn.TrainingConfig{LearningRate: 0.01, Optimizer: nn.Adam},
+	)
+
+	x, y := testBatch(32, 8, 10)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if err := model.BackpropagateBatch(x, y); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSeededInitialization(t *testing.T) {
+
+	hidden := []nn.Layer{{Neurons: 6, ActivationFunction: activation.ReLU}}
+	output := nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax}
+
+	weights := func(seed int64) [][]float64 {
+		model := newTestModel(t, hidden, output, nn.TrainingConfig{Seed: seed})
+		var w [][]float64
+		for _, tensor := range model.NeuralNetwork.WeightsAndBiases.Weights {
+			w = append(w, tensor.Data)
+		}
+		return w
+	}
+
+	first, second, other := weights(1), weights(1), weights(2)
+
+	for l := range first {
+		if !slices.Equal(first[l], second[l]) {
+			t.Errorf("layer %d: weights differ between two models seeded alike", l+1)
+		}
+		if slices.Equal(first[l], other[l]) {
+			t.Errorf("layer %d: weights are the same under different seeds", l+1)
+		}
+	}
+}
+
+// handWrittenGradients computes the batch averaged gradients of the weights
+// and biases of a dense model without normalization or dropout the way
+// BackpropagateBatch did before it ran on an autodiff tape: the output delta
+// p - t of a softmax under cross-entropy, or dL/dp ⊙ f'(z) of an MSE, then
+// δ_l = (δ_{l+1} · W_{l+1}) ⊙ f'(z_l) down the layers, dW_l = δ_lᵀ · A_{l-1}
+// and db_l = Σ δ_l, plus 2·L2·w for the weights of penalized layers
+func handWrittenGradients(model *nn.Model, x, y [][]float64) (dw, db [][]float64) {
+
+	network := &model.NeuralNetwork
+	params := &network.WeightsAndBiases
+
+	layers := len(params.Weights)
+	acts := make([]activation.ActivationFunction, layers)
+	l2 := make([]float64, layers)
+	for l, layer := range network.Layers {
+		acts[l], l2[l] = layer.ActivationFunction, layer.Regularization.L2
+	}
+	acts[layers-1], l2[layers-1] = network.OutputLayer.ActivationFunction, network.OutputLayer.Regularization.L2
+
+	dw, db = make([][]float64, layers), make([][]float64, layers)
+	for l := range layers {
+		dw[l] = make([]float64, params.Weights[l].Len())
+		db[l] = make([]float64, params.Biases[l].Len())
+	}
+
+	batch := float64(len(x))
+
+	for i := range x {
+
+		// Forward pass, keeping the pre-activations z and activations a of every layer
+		a := [][]float64{x[i]}
+		z := make([][]float64, layers)
+
+		for l := range layers {
+
+			w := params.Weights[l]
+			z[l] = make([]float64, w.Shape[0])
+			out := make([]float64, w.Shape[0])
+
+			for j := range z[l] {
+				z[l][j] = params.Biases[l].Data[j]
+				for k, v := range a[l] {
+					z[l][j] += w.Row(j)[k] * v
+				}
+			}
+
+			if acts[l] == activation.Softmax {
+				sum := 0.0
+				for j, v := range z[l] {
+					out[j] = math.Exp(v - slices.Max(z[l]))
+					sum += out[j]
+				}
+				for j := range out {
+					out[j] /= sum
+				}
+			} else {
+				f := activation.GetActivationFunction(acts[l])
+				for j, v := range z[l] {
+					out[j] = f(v)
+				}
+			}
+
+			a = append(a, out)
+		}
+
+		// Output delta
+		p := a[layers]
+		delta := make([]float64, len(p))
+		for j := range p {
+			if acts[layers-1] == activation.Softmax {
+				delta[j] = p[j] - y[i][j]
+			} else {
+				delta[j] = 2 * (p[j] - y[i][j]) / float64(len(p)) * activation.GetActivationDerivative(acts[layers-1])(z[layers-1][j])
+			}
+		}
+
+		for l := layers - 1; l >= 0; l-- {
+
+			w := params.Weights[l]
+			for j, d := range delta {
+				db[l][j] += d / batch
+				for k, v := range a[l] {
+					dw[l][j*w.Shape[1]+k] += d * v / batch
+				}
+			}
+
+			if l == 0 {
+				break
+			}
+
+			previous := make([]float64, w.Shape[1])
+			derivative := activation.GetActivationDerivative(acts[l-1])
+			for k := range previous {
+				for j, d := range delta {
+					previous[k] += d * w.Row(j)[k]
+				}
+				previous[k] *= derivative(z[l-1][k])
+			}
+			delta = previous
+		}
+	}
+
+	for l := range layers {
+		for k, w := range params.Weights[l].Data {
+			dw[l][k] += 2 * l2[l] * w
+		}
+	}
+
+	return dw, db
+}
+
+func TestTapeMatchesHandWrittenGradients(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		hidden []nn.Layer
+		output nn.OutputLayer
+		loss   nn.LossFunction
+	}{
+		{
+			name: "softmax cross-entropy",
+			hidden: []nn.Layer{
+				{Neurons: 6, ActivationFunction: activation.Tanh},
+				{Neurons: 5, ActivationFunction: activation.ReLU},
+			},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+			loss:   nn.CategoricalCrossEntropy,
+		},
+		{
+			name:   "sigmoid mean squared error",
+			hidden: []nn.Layer{{Neurons: 6, ActivationFunction: activation.Sigmoid}},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Sigmoid},
+			loss:   nn.MeanSquaredError,
+		},
+		{
+			name: "linear mean squared error with l2 penalties",
+			hidden: []nn.Layer{
+				{Neurons: 7, ActivationFunction: activation.GELU, Regularization: nn.Regularization{L2: 0.01}},
+				{Neurons: 6, ActivationFunction: activation.ELU},
+				{Neurons: 5, ActivationFunction: activation.Softplus},
+			},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Linear, Regularization: nn.Regularization{L2: 0.05}},
+			loss:   nn.MeanSquaredError,
+		},
+		{
+			name:   "no hidden layers",
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+			loss:   nn.CategoricalCrossEntropy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// With plain SGD at a learning rate of 1 the step is minus the gradient
+			model := newTestModel(t, tt.hidden, tt.output, nn.TrainingConfig{LearningRate: 1, Optimizer: nn.SGD, LossFunction: tt.loss, Seed: 1})
+			params := &model.NeuralNetwork.WeightsAndBiases
+
+			// Random biases keep the hidden ReLUs of every sample off zero
+			rng := rand.New(rand.NewSource(2))
+			for _, b := range params.Biases {
+				for k := range b.Data {
+					b.Data[k] = 0.1 * rng.NormFloat64()
+				}
+			}
+
+			x, y := testBatch(6, 8, 4)
+
+			wantW, wantB := handWrittenGradients(model, x, y)
+
+			var before [][]float64
+			for l := range params.Weights {
+				before = append(before, slices.Clone(params.Weights[l].Data), slices.Clone(params.Biases[l].Data))
+			}
+
+			if err := model.BackpropagateBatch(x, y); err != nil {
+				t.Fatal(err)
+			}
+
+			for l := range params.Weights {
+				for i, pair := range [][2][]float64{{params.Weights[l].Data, wantW[l]}, {params.Biases[l].Data, wantB[l]}} {
+					for k, v := range pair[0] {
+						if got := before[2*l+i][k] - v; math.Abs(got-pair[1][k]) > 1e-12 {
+							t.Errorf("layer %d %s %d: gradient %v, want %v", l+1, []string{"weight", "bias"}[i], k, got, pair[1][k])
+						}
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,98 +1,88 @@
 package neuralnetwork
 
 import (
-	"fmt"
-
-	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/autodiff"
 	"github.com/ThakurMayank5/gonn/vectors"
 )
 
-// z is pre activation values, a is post activation values, predictions is the final output
-func (model *Model) PredictBatch(batchInputs [][]float64, batchTargets [][]float64) (z [][][]float64, a [][][]float64, predictions [][]float64, err error) {
-
-	batchSize := len(batchInputs)
-
-	predictions = make([][]float64, batchSize)
+// forwardCache holds the input of a forward pass and the tape the output
+// activation and the loss are recorded on, on top of the network's output
+type forwardCache[T Float] struct {
+	tape autodiff.Tape[T]
 
-	// Make each z[i] same size as bias size
+	input *vectors.Tensor[T] // the mini-batch, [batch, input shape...]
+}
 
-	z = make([][][]float64, batchSize)
+// z is pre activation values, a is post activation values, predictions is the final output.
+// z[l] and a[l] have shape [batch, neurons] for trainable layer l. A Network
+// exposes no intermediate values: z is nil and a only holds the output.
+func (model *ModelOf[T]) PredictBatch(batchInputs [][]T, batchTargets [][]T) (z []*vectors.Tensor[T], a []*vectors.Tensor[T], predictions [][]T, err error) {
 
-	a = make([][][]float64, batchSize)
+	nn := &model.NeuralNetwork
 
-	for i := range z {
-		z[i] = make([][]float64, len(model.NeuralNetwork.WeightsAndBiases.Biases))
-		a[i] = make([][]float64, len(model.NeuralNetwork.WeightsAndBiases.Biases))
+	x, err := nn.networkInput(nil, batchInputs)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	for i := range z {
-		for j := range z[i] {
-			z[i][j] = make([]float64, len(model.NeuralNetwork.WeightsAndBiases.Biases[j]))
-			a[i][j] = make([]float64, len(model.NeuralNetwork.WeightsAndBiases.Biases[j]))
-		}
-	}
+	if nn.Network != nil {
 
-	weights := model.NeuralNetwork.WeightsAndBiases.Weights
-	biases := model.NeuralNetwork.WeightsAndBiases.Biases
-
-	// iteration over mini-batch
-	for batch := range batchInputs {
-
-		x := batchInputs[batch]
-
-		// Iterate through each layer
-		for i := 0; i < len(weights); i++ {
-			{
-
-				// Default to ReLU for hidden layers
-				activationFunction := activation.ReLU
+		out, err := nn.networkForward(nn.Network, x, false)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 
-				if i == len(weights)-1 {
-					activationFunction = model.NeuralNetwork.OutputLayer.ActivationFunction
-				} else {
-					activationFunction = model.NeuralNetwork.Layers[i].ActivationFunction
-				}
+		out = out.Clone()
 
-				newX := make([]float64, len(biases[i])) // Initialized elements as 0
+		return nil, []*vectors.Tensor[T]{out}, out.Rows(), nil
+	}
 
-				// Iterate through each neuron in the current layer
-				for j := range biases[i] {
-					currWeights := weights[i][j]
-					dotProduct, err := vectors.DotProduct(x, currWeights)
+	d, err := nn.predictor()
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-					if err != nil {
-						return nil, nil, nil, fmt.Errorf("error computing dot product: %v", err)
-					}
+	// The stages run one at a time, as the Sequential would, to keep the
+	// output of every one
+	outputs := make([]*vectors.Tensor[T], len(d.sequential.Layers))
 
-					if activationFunction != activation.Softmax {
-						activationFunctionToUse := activation.GetActivationFunction(activationFunction)
+	d.sequential.SetBackend(nn.Backend)
 
-						newX[j] = activationFunctionToUse(dotProduct + biases[i][j])
-					} else {
-						newX[j] = dotProduct + biases[i][j] // Store pre-activation for softmax
-					}
+	for i, stage := range d.sequential.Layers {
+		if x, err = stage.Forward(x, false); err != nil {
+			return nil, nil, nil, err
+		}
+		outputs[i] = x
+	}
 
-					// Store pre-activation values for backpropagation
-					z[batch][i][j] = dotProduct + biases[i][j]
+	var cache forwardCache[T]
 
-					// Store post-activation values for backpropagation
-					a[batch][i][j] = newX[j]
+	output, err := nn.output(&cache.tape, x, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-				}
+	z = make([]*vectors.Tensor[T], len(d.z))
+	a = make([]*vectors.Tensor[T], len(d.z))
 
-				// Apply softmax if needed
-				if i == len(weights)-1 && activationFunction == activation.Softmax {
-					newX = activation.SoftmaxFunc(newX)
-				}
+	for l, stage := range d.z {
+		z[l] = outputs[stage].Clone()
+	}
 
-				x = newX
+	for l, stage := range d.a {
+		a[l] = outputs[stage].Clone()
+	}
 
-			}
-		}
+	a[len(a)-1] = output.Value.Clone()
 
-		predictions[batch] = x
+	return z, a, a[len(a)-1].Rows(), nil
+}
 
+// resize returns s with length n, reusing its backing array when possible.
+// Values are not preserved.
+func resize[T Float](s []T, n int) []T {
+	if cap(s) < n {
+		return make([]T, n)
 	}
-
-	return z, a, predictions, nil
+	return s[:n]
 }
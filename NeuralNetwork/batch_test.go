@@ -0,0 +1,110 @@
+package neuralnetwork_test
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
+)
+
+func TestBatchedPredictions(t *testing.T) {
+
+	model := newTestModel(t,
+		[]nn.Layer{{Neurons: 16, ActivationFunction: activation.ReLU, Normalization: nn.BatchNorm}},
+		nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+		nn.TrainingConfig{LearningRate: 0.1, Seed: 1},
+	)
+
+	// More samples than validation runs through the network at once
+	x, y := testBatch(300, 8, 4)
+
+	if err := model.BackpropagateBatch(x, y); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, predictions, err := model.PredictBatch(x, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := 0.0
+
+	for i := range x {
+
+		want, err := model.NeuralNetwork.Predict(x[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(predictions[i], want) {
+			t.Fatalf("sample %d: batched prediction %v, want %v", i, predictions[i], want)
+		}
+
+		loss, err := model.ForwardPassBatch(x[i:i+1], y[i:i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += loss
+	}
+
+	loss, err := model.ForwardPassBatch(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := sum / float64(len(x)); math.Abs(loss-want) > 1e-12 {
+		t.Errorf("validation loss %v, want the mean sample loss %v", loss, want)
+	}
+}
+
+// BenchmarkPredictBatch runs a batch of 128 samples through the
+// Fashion-MNIST MLP at once and one sample at a time
+func BenchmarkPredictBatch(b *testing.B) {
+
+	model := &nn.Model{
+		NeuralNetwork: nn.NeuralNetwork{
+			InputLayer: nn.InputLayer{Neurons: 784},
+			Layers: []nn.Layer{
+				{Neurons: 256, ActivationFunction: activation.ReLU},
+				{Neurons: 128, ActivationFunction: activation.ReLU},
+				{Neurons: 64, ActivationFunction: activation.ReLU},
+			},
+			OutputLayer: nn.OutputLayer{Neurons: 10, ActivationFunction: activation.Softmax},
+		},
+	}
+
+	if err := model.InitializeWeights(); err != nil {
+		b.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+
+	x := make([][]float64, 128)
+	for i := range x {
+		x[i] = make([]float64, 784)
+		for k := range x[i] {
+			x[i][k] = rng.Float64()
+		}
+	}
+
+	b.Run("batched", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, _, err := model.PredictBatch(x, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("per-sample", func(b *testing.B) {
+		for b.Loop() {
+			for _, sample := range x {
+				if _, err := model.NeuralNetwork.Predict(sample); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
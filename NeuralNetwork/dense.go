@@ -0,0 +1,186 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// denseNetwork is the network of Layers and OutputLayer: a layers.Sequential
+// built around the tensors of WeightsAndBiases, which it shares. Every
+// trainable layer is a Dense layer followed by its normalization, its
+// activation and its dropout. The activation of the output layer is left
+// out, the model applies it on the tape of the loss so that a softmax and a
+// cross-entropy are differentiated together.
+type denseNetwork[T Float] struct {
+	sequential *layers.Sequential[T]
+
+	// params holds the tensors the layers were built around and grads the
+	// gradients the layers write, one entry per trainable layer
+	params, grads ModelWeightsAndBiasesOf[T]
+
+	// hidden is the configuration of the hidden layers as of the build and
+	// rng the source dropout draws from
+	hidden []Layer
+	rng    *rand.Rand
+
+	// z and a index the stages of sequential whose outputs are the
+	// pre-activations of every trainable layer and the outputs of every
+	// hidden layer
+	z, a []int
+}
+
+// dense returns d when it was built for the current layers and parameters
+// of nn with dropout drawing from rng, and otherwise builds a new one
+func (nn *NeuralNetworkOf[T]) dense(d *denseNetwork[T], rng *rand.Rand) (*denseNetwork[T], error) {
+
+	if d != nil && d.rng == rng && d.matches(nn) {
+		return d, nil
+	}
+
+	params := &nn.WeightsAndBiases
+	trainable := len(nn.Layers) + 1
+
+	if len(params.Weights) != trainable || len(params.Biases) != trainable {
+		return nil, fmt.Errorf("model weights are not initialized")
+	}
+
+	d = &denseNetwork[T]{
+		sequential: layers.NewSequential[T](),
+		params: ModelWeightsAndBiasesOf[T]{
+			Weights:          slices.Clone(params.Weights),
+			Biases:           slices.Clone(params.Biases),
+			Gammas:           slices.Clone(params.Gammas),
+			Betas:            slices.Clone(params.Betas),
+			RunningMeans:     slices.Clone(params.RunningMeans),
+			RunningVariances: slices.Clone(params.RunningVariances),
+		},
+		grads: ModelWeightsAndBiasesOf[T]{
+			Weights: make([]*vectors.Tensor[T], trainable),
+			Biases:  make([]*vectors.Tensor[T], trainable),
+			Gammas:  make([]*vectors.Tensor[T], trainable),
+			Betas:   make([]*vectors.Tensor[T], trainable),
+		},
+		hidden: slices.Clone(nn.Layers),
+		rng:    rng,
+	}
+
+	// The layers holding the parameters of every trainable layer, whose
+	// gradients are known once the network is built
+	denses := make([]*layers.Dense[T], trainable)
+	norms := make([]layers.Layer[T], trainable)
+
+	for l := range trainable {
+
+		denses[l] = &layers.Dense[T]{}
+		if err := denses[l].SetParams(params.Weights[l], params.Biases[l]); err != nil {
+			return nil, fmt.Errorf("layer %d: %v", l+1, err)
+		}
+
+		d.sequential.Add(denses[l])
+
+		if norm := nn.normalization(l); norm != NoNormalization {
+
+			layer, err := params.normalizationLayer(l, norm)
+			if err != nil {
+				return nil, fmt.Errorf("layer %d: %v", l+1, err)
+			}
+
+			norms[l] = layer
+			d.sequential.Add(layer)
+		}
+
+		d.z = append(d.z, len(d.sequential.Layers)-1)
+
+		if l == len(nn.Layers) {
+			break
+		}
+
+		d.sequential.Add(&layers.Activation[T]{Function: nn.Layers[l].ActivationFunction})
+
+		if rate := nn.Layers[l].Dropout; rate > 0 {
+			d.sequential.Add(&layers.Dropout[T]{Rate: rate})
+		}
+
+		d.a = append(d.a, len(d.sequential.Layers)-1)
+	}
+
+	if err := d.sequential.Build(nn.inputShape(), rng); err != nil {
+		return nil, err
+	}
+
+	for l := range trainable {
+
+		grads := denses[l].Grads()
+		d.grads.Weights[l], d.grads.Biases[l] = grads[0], grads[1]
+
+		if norms[l] != nil {
+			grads := norms[l].Grads()
+			d.grads.Gammas[l], d.grads.Betas[l] = grads[0], grads[1]
+		}
+	}
+
+	return d, nil
+}
+
+// normalizationLayer returns a layer applying norm with the normalization
+// parameters of trainable layer l
+func (params *ModelWeightsAndBiasesOf[T]) normalizationLayer(l int, norm Normalization) (layers.Layer[T], error) {
+
+	if l >= len(params.Gammas) || params.Gammas[l] == nil || l >= len(params.Betas) || params.Betas[l] == nil {
+		return nil, fmt.Errorf("%s without normalization parameters", norm)
+	}
+
+	switch norm {
+
+	case BatchNorm:
+
+		if l >= len(params.RunningMeans) || l >= len(params.RunningVariances) || params.RunningMeans[l] == nil || params.RunningVariances[l] == nil {
+			return nil, fmt.Errorf("%s without running statistics", norm)
+		}
+
+		layer := &layers.BatchNorm[T]{Momentum: batchNormMomentum, Epsilon: normEpsilon}
+
+		if err := layer.SetState(params.RunningMeans[l], params.RunningVariances[l]); err != nil {
+			return nil, err
+		}
+
+		return layer, layer.SetParams(params.Gammas[l], params.Betas[l])
+
+	case LayerNorm:
+
+		layer := &layers.LayerNorm[T]{Epsilon: normEpsilon}
+
+		return layer, layer.SetParams(params.Gammas[l], params.Betas[l])
+	}
+
+	return nil, fmt.Errorf("unsupported normalization: %s", norm)
+}
+
+// matches reports whether d was built for the hidden layers of nn around its
+// current parameter tensors
+func (d *denseNetwork[T]) matches(nn *NeuralNetworkOf[T]) bool {
+
+	if len(d.hidden) != len(nn.Layers) {
+		return false
+	}
+
+	for l, layer := range nn.Layers {
+		built := d.hidden[l]
+		if layer.ActivationFunction != built.ActivationFunction || layer.Dropout != built.Dropout || layer.Normalization != built.Normalization {
+			return false
+		}
+	}
+
+	params := &nn.WeightsAndBiases
+
+	return slices.Equal(d.params.Weights, params.Weights) &&
+		slices.Equal(d.params.Biases, params.Biases) &&
+		slices.Equal(d.params.Gammas, params.Gammas) &&
+		slices.Equal(d.params.Betas, params.Betas) &&
+		slices.Equal(d.params.RunningMeans, params.RunningMeans) &&
+		slices.Equal(d.params.RunningVariances, params.RunningVariances)
+}
@@ -0,0 +1,236 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/ThakurMayank5/gonn/dataset"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Metric names a value Fit computes on the validation set after every epoch
+type Metric string
+
+const (
+	// ValidationLoss is the loss Fit prints, regularization penalty included
+	ValidationLoss Metric = "val_loss"
+
+	// ValidationAccuracy is the accuracy Evaluate reports, as a percentage
+	ValidationAccuracy Metric = "val_accuracy"
+)
+
+// MonitorMode tells whether lower or higher values of a metric are better
+type MonitorMode string
+
+const (
+	// AutoMode minimizes losses and maximizes accuracies
+	AutoMode MonitorMode = ""
+	MinMode  MonitorMode = "min"
+	MaxMode  MonitorMode = "max"
+)
+
+// EarlyStopping ends Fit once Monitor has not improved by more than MinDelta
+// for Patience epochs, and can put back the weights of the best epoch.
+type EarlyStopping struct {
+
+	// Monitor is the metric watched, empty watches ValidationLoss
+	Monitor Metric
+
+	Patience int
+	MinDelta float64
+
+	// Mode overrides whether Monitor is minimized or maximized
+	Mode MonitorMode
+
+	// RestoreBestWeights copies the parameters and normalization statistics
+	// of the best epoch back into the model when training ends. The
+	// optimizer state is kept as it is.
+	RestoreBestWeights bool
+}
+
+// StopReason tells why Fit returned
+type StopReason string
+
+const (
+	// Completed means every epoch of TrainingConfig.Epochs ran
+	Completed StopReason = "completed"
+
+	// StoppedEarly means EarlyStopping ended training before the last epoch
+	StoppedEarly StopReason = "early_stopping"
+)
+
+// FitResult reports how training went
+type FitResult struct {
+
+	// Epochs is the number of epochs run
+	Epochs int
+
+	StopReason StopReason
+
+	// Monitor is the metric watched for the best epoch, ValidationLoss
+	// without EarlyStopping
+	Monitor Metric
+
+	// BestEpoch is the 1-based epoch with the best value of Monitor, and
+	// BestValue that value. BestEpoch is 0 when no epoch had a value that
+	// was not NaN.
+	BestEpoch int
+	BestValue float64
+
+	// RestoredBestWeights is set when the weights of BestEpoch were copied
+	// back into the model
+	RestoredBestWeights bool
+}
+
+// monitor follows the watched metric across the epochs of a Fit
+type monitor[T Float] struct {
+	config   EarlyStopping
+	maximize bool
+
+	bestValue float64
+	bestEpoch int
+	badEpochs int
+
+	// best holds the values of the model's trained tensors at bestEpoch
+	best [][]T
+}
+
+// newMonitor checks the EarlyStopping settings of the training config, nil
+// settings only track the best validation loss
+func (model *ModelOf[T]) newMonitor() (*monitor[T], error) {
+
+	m := &monitor[T]{config: EarlyStopping{Monitor: ValidationLoss}}
+
+	if stopping := model.TrainingConfig.EarlyStopping; stopping != nil {
+
+		m.config = *stopping
+		if m.config.Monitor == "" {
+			m.config.Monitor = ValidationLoss
+		}
+
+		if m.config.Patience < 0 {
+			return nil, fmt.Errorf("early stopping: patience must not be negative, got %d", m.config.Patience)
+		}
+
+		if m.config.MinDelta < 0 {
+			return nil, fmt.Errorf("early stopping: min delta must not be negative, got %v", m.config.MinDelta)
+		}
+	}
+
+	switch m.config.Monitor {
+	case ValidationLoss:
+		m.maximize = false
+	case ValidationAccuracy:
+		m.maximize = true
+	default:
+		return nil, fmt.Errorf("early stopping: unknown metric %q", m.config.Monitor)
+	}
+
+	switch m.config.Mode {
+	case AutoMode:
+	case MinMode:
+		m.maximize = false
+	case MaxMode:
+		m.maximize = true
+	default:
+		return nil, fmt.Errorf("early stopping: unknown mode %q", m.config.Mode)
+	}
+
+	return m, nil
+}
+
+// value returns the watched metric for an epoch that ended with validationLoss
+func (m *monitor[T]) value(model *ModelOf[T], validation dataset.DatasetOf[T], validationLoss float64) (float64, error) {
+
+	if m.config.Monitor == ValidationAccuracy {
+		_, accuracy, err := model.evaluate(validation)
+		return accuracy, err
+	}
+
+	return validationLoss, nil
+}
+
+// observe records the metric of an epoch and returns true when training
+// should stop
+func (m *monitor[T]) observe(model *ModelOf[T], epoch int, value float64) bool {
+
+	if !math.IsNaN(value) && (m.bestEpoch == 0 || m.improves(value)) {
+
+		m.bestValue = value
+		m.bestEpoch = epoch
+		m.badEpochs = 0
+
+		if m.config.RestoreBestWeights {
+			m.best = copyTensors(model.trainedTensors(), m.best)
+		}
+
+		return false
+	}
+
+	m.badEpochs++
+
+	return model.TrainingConfig.EarlyStopping != nil && m.badEpochs >= m.config.Patience
+}
+
+// improves reports whether value beats the best value by more than MinDelta
+func (m *monitor[T]) improves(value float64) bool {
+	if m.maximize {
+		return value > m.bestValue+m.config.MinDelta
+	}
+	return value < m.bestValue-m.config.MinDelta
+}
+
+// restore copies the tensors saved at the best epoch back into the model,
+// and returns whether they differed from the last epoch's
+func (m *monitor[T]) restore(model *ModelOf[T], lastEpoch int) bool {
+
+	if m.best == nil || m.bestEpoch == lastEpoch {
+		return false
+	}
+
+	for i, t := range model.trainedTensors() {
+		copy(t.Data, m.best[i])
+	}
+
+	return true
+}
+
+// trainedTensors returns every tensor training changes: the parameters and
+// the normalization running statistics
+func (model *ModelOf[T]) trainedTensors() []*vectors.Tensor[T] {
+
+	nn := &model.NeuralNetwork
+
+	if nn.Network != nil {
+		return slices.Concat(nn.Network.Params(), nn.networkState())
+	}
+
+	values := &nn.WeightsAndBiases
+
+	var tensors []*vectors.Tensor[T]
+
+	for _, group := range [][]*vectors.Tensor[T]{values.Weights, values.Biases, values.Gammas, values.Betas, values.RunningMeans, values.RunningVariances} {
+		for _, t := range group {
+			if t != nil {
+				tensors = append(tensors, t)
+			}
+		}
+	}
+
+	return tensors
+}
+
+// copyTensors copies the data of tensors into dst, reusing its slices
+func copyTensors[T Float](tensors []*vectors.Tensor[T], dst [][]T) [][]T {
+
+	if len(dst) != len(tensors) {
+		dst = make([][]T, len(tensors))
+	}
+
+	for i, t := range tensors {
+		dst[i] = append(dst[i][:0], t.Data...)
+	}
+
+	return dst
+}
@@ -0,0 +1,193 @@
+package neuralnetwork_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/dataset"
+	"github.com/ThakurMayank5/gonn/layers"
+	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
+)
+
+// diverging returns a training set whose class is the argmax of the first 4
+// of 8 features, and a validation set with a quarter of the samples
+// labelled with the next class: the validation metrics improve while the
+// model learns the rule, then worsen as it grows confident on the mislabelled
+// quarter
+func diverging() (dataset.Dataset, dataset.Dataset) {
+
+	rng := rand.New(rand.NewSource(1))
+
+	data := func(n, mislabelled int) dataset.Dataset {
+
+		x := make([][]float64, n)
+		y := make([][]float64, n)
+
+		for i := range x {
+
+			x[i] = make([]float64, 8)
+			for k := range x[i] {
+				x[i][k] = rng.NormFloat64()
+			}
+
+			class := 0
+			for k := 1; k < 4; k++ {
+				if x[i][k] > x[i][class] {
+					class = k
+				}
+			}
+
+			y[i] = make([]float64, 4)
+			if i < mislabelled {
+				class = (class + 1) % 4
+			}
+			y[i][class] = 1
+		}
+
+		return dataset.Dataset{Inputs: x, Outputs: y, NumSamples: n, NumFeatures: 8, NumOutputs: 4}
+	}
+
+	return data(200, 0), data(100, 25)
+}
+
+func TestEarlyStopping(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		model    func(t testing.TB, config nn.TrainingConfig) *nn.Model
+		stopping nn.EarlyStopping
+	}{
+		{
+			name: "validation loss, batch normalization",
+			model: func(t testing.TB, config nn.TrainingConfig) *nn.Model {
+				return newTestModel(t,
+					[]nn.Layer{{Neurons: 8, ActivationFunction: activation.ReLU, Normalization: nn.BatchNorm}},
+					nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+					config)
+			},
+			stopping: nn.EarlyStopping{Patience: 3, RestoreBestWeights: true},
+		},
+		{
+			name: "validation accuracy, network",
+			model: func(t testing.TB, config nn.TrainingConfig) *nn.Model {
+				return newNetworkModel(t, layers.NewSequential[float64](
+					&layers.Dense[float64]{Neurons: 8, ActivationFunction: activation.Tanh},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				), config)
+			},
+			stopping: nn.EarlyStopping{Monitor: nn.ValidationAccuracy, Patience: 2, MinDelta: 1, RestoreBestWeights: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			training, validation := diverging()
+
+			model := tt.model(t, nn.TrainingConfig{
+				Epochs:        100,
+				BatchSize:     16,
+				LearningRate:  0.01,
+				Optimizer:     nn.Adam,
+				EarlyStopping: &tt.stopping,
+				Seed:          1,
+			})
+
+			result, err := model.Fit(training, validation)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.StopReason != nn.StoppedEarly || result.Epochs >= 100 {
+				t.Fatalf("training ended with %q after %d epochs, want an early stop", result.StopReason, result.Epochs)
+			}
+
+			if result.Epochs != result.BestEpoch+tt.stopping.Patience {
+				t.Errorf("stopped at epoch %d with the best at epoch %d, want %d epochs later", result.Epochs, result.BestEpoch, tt.stopping.Patience)
+			}
+
+			if !result.RestoredBestWeights {
+				t.Fatal("the best weights were not restored")
+			}
+
+			// The restored model scores the best epoch's value again
+			var value float64
+			if tt.stopping.Monitor == nn.ValidationAccuracy {
+				value, err = model.Evaluate(validation)
+			} else {
+				value, err = model.ForwardPassBatch(validation.Inputs, validation.Outputs)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if math.Abs(value-result.BestValue) > 1e-12 {
+				t.Errorf("restored model scores %v, want the best value %v", value, result.BestValue)
+			}
+		})
+	}
+}
+
+func TestFitWithoutEarlyStopping(t *testing.T) {
+
+	training, validation := diverging()
+
+	model := newTestModel(t, nil, nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax}, nn.TrainingConfig{
+		Epochs:       5,
+		BatchSize:    16,
+		LearningRate: 0.01,
+		Optimizer:    nn.Adam,
+		Seed:         1,
+	})
+
+	result, err := model.Fit(training, validation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.StopReason != nn.Completed || result.Epochs != 5 {
+		t.Errorf("training ended with %q after %d epochs, want %q after 5", result.StopReason, result.Epochs, nn.Completed)
+	}
+
+	if result.Monitor != nn.ValidationLoss || result.BestEpoch < 1 || result.BestEpoch > 5 {
+		t.Errorf("best %s at epoch %d, want the validation loss at an epoch in [1, 5]", result.Monitor, result.BestEpoch)
+	}
+
+	if result.RestoredBestWeights {
+		t.Errorf("weights were restored without early stopping")
+	}
+}
+
+func TestEarlyStoppingConfig(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		stopping nn.EarlyStopping
+	}{
+		{"negative patience", nn.EarlyStopping{Patience: -1}},
+		{"negative min delta", nn.EarlyStopping{MinDelta: -0.1}},
+		{"unknown metric", nn.EarlyStopping{Monitor: "val_f1"}},
+		{"unknown mode", nn.EarlyStopping{Mode: "lowest"}},
+	}
+
+	training, validation := diverging()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			model := newTestModel(t, nil, nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax}, nn.TrainingConfig{
+				Epochs:        1,
+				BatchSize:     16,
+				LearningRate:  0.01,
+				Optimizer:     nn.Adam,
+				EarlyStopping: &tt.stopping,
+			})
+
+			if _, err := model.Fit(training, validation); err == nil {
+				t.Errorf("Fit accepted %+v", tt.stopping)
+			}
+		})
+	}
+}
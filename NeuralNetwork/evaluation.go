@@ -4,45 +4,59 @@ import (
 	"fmt"
 
 	"github.com/ThakurMayank5/gonn/dataset"
-	"github.com/ThakurMayank5/gonn/losses"
+	"github.com/ThakurMayank5/gonn/vectors"
 )
 
-func (model *Model) Evaluate(dataset dataset.Dataset) (float64, error) {
+// Evaluate prints the average loss and the accuracy of the model on dataset
+// and returns the accuracy as a percentage (0-100)
+func (model *ModelOf[T]) Evaluate(dataset dataset.DatasetOf[T]) (float64, error) {
+
+	avgLoss, accuracyPercentage, err := model.evaluate(dataset)
+	if err != nil {
+		return 0.0, err
+	}
+
+	fmt.Printf("  Loss: %.4f | Accuracy: %.2f%%\n", avgLoss, accuracyPercentage)
+
+	return accuracyPercentage, nil
+}
+
+// evaluate returns the average loss and the accuracy percentage of the model on dataset
+func (model *ModelOf[T]) evaluate(dataset dataset.DatasetOf[T]) (float64, float64, error) {
 
 	// Dataset validation
 	if len(dataset.Inputs) == 0 || len(dataset.Outputs) == 0 {
-		return 0.0, fmt.Errorf("dataset is empty")
+		return 0.0, 0.0, fmt.Errorf("dataset is empty")
 	}
 
 	if len(dataset.Inputs) != len(dataset.Outputs) {
-		return 0.0, fmt.Errorf("number of inputs and outputs must be the same")
+		return 0.0, 0.0, fmt.Errorf("number of inputs and outputs must be the same")
 	}
 
 	if len(dataset.Inputs[0]) != model.NeuralNetwork.InputLayer.Neurons {
-		return 0.0, fmt.Errorf("input data does not match the number of neurons in the input layer")
+		return 0.0, 0.0, fmt.Errorf("input data does not match the number of neurons in the input layer")
+	}
+
+	lossFunction, err := model.lossFunction()
+	if err != nil {
+		return 0.0, 0.0, err
 	}
 
 	correctPredictions := 0.0
 	totalLoss := 0.0
 
-	for i := range dataset.Inputs {
-		input := dataset.Inputs[i]
-		output, err := model.NeuralNetwork.Predict(input)
-		if err != nil {
-			fmt.Printf("Error predicting output for input %v: %v\n", input, err)
-			return 0.0, err
-		}
+	// Losses work in float64, float32 samples are converted into these rows
+	var prediction, target []float64
 
-		// Compute loss (use Cross-Entropy for Softmax, MSE for others)
-		var loss float64
-		if model.NeuralNetwork.OutputLayer.ActivationFunction == "softmax" {
-			loss, err = losses.CategoricalCrossEntropy(output, dataset.Outputs[i])
-		} else {
-			loss, err = losses.MeanSquaredError(dataset.Outputs[i], output)
-		}
+	// The samples go through the network in batches
+	err = model.NeuralNetwork.predictEach(dataset.Inputs, func(i int, output []T) error {
+
+		prediction = vectors.AsFloat64(output, prediction)
+		target = vectors.AsFloat64(dataset.Outputs[i], target)
+
+		loss, err := lossFunction.Value(prediction, target)
 		if err != nil {
-			fmt.Printf("Error computing loss for input %v: %v\n", input, err)
-			return 0.0, err
+			return fmt.Errorf("computing loss for sample %d: %v", i, err)
 		}
 
 		totalLoss += loss
@@ -58,13 +72,17 @@ func (model *Model) Evaluate(dataset dataset.Dataset) (float64, error) {
 			}
 		}
 
-		// Find target class (argmax of one-hot)
-		maxTargetValue := dataset.Outputs[i][0]
+		// Find target class (argmax of one-hot, or the index itself for sparse targets)
 		targetClass := 0
-		for j := 1; j < len(dataset.Outputs[i]); j++ {
-			if dataset.Outputs[i][j] > maxTargetValue {
-				maxTargetValue = dataset.Outputs[i][j]
-				targetClass = j
+		if len(dataset.Outputs[i]) == 1 && len(output) > 1 {
+			targetClass = int(dataset.Outputs[i][0])
+		} else {
+			maxTargetValue := dataset.Outputs[i][0]
+			for j := 1; j < len(dataset.Outputs[i]); j++ {
+				if dataset.Outputs[i][j] > maxTargetValue {
+					maxTargetValue = dataset.Outputs[i][j]
+					targetClass = j
+				}
 			}
 		}
 
@@ -72,13 +90,17 @@ func (model *Model) Evaluate(dataset dataset.Dataset) (float64, error) {
 		if predictedClass == targetClass {
 			correctPredictions++
 		}
+
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Error evaluating the dataset: %v\n", err)
+		return 0.0, 0.0, err
 	}
 
 	// Calculate accuracy as percentage (0-100)
 	accuracyPercentage := (correctPredictions / float64(len(dataset.Inputs))) * 100.0
 	avgLoss := totalLoss / float64(len(dataset.Inputs))
 
-	fmt.Printf("  Loss: %.4f | Accuracy: %.2f%%\n", avgLoss, accuracyPercentage)
-
-	return accuracyPercentage, nil
+	return avgLoss, accuracyPercentage, nil
 }
@@ -0,0 +1,308 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/losses"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// gradCheckSeed seeds the dropout masks of every pass of GradCheck
+const gradCheckSeed = 1
+
+// GradCheckResult compares the analytic and numerical gradients of one trainable layer
+type GradCheckResult struct {
+
+	// Layer is the index of the trainable layer, the output layer is last.
+	// For a Network it is the index of the layer in the Sequential or of the
+	// node in the Graph (0 for any other Network), layers without parameters
+	// are not reported.
+	Layer int
+
+	// RelativeError is |analytic - numerical| / (|analytic| + |numerical|),
+	// with the L2 norms taken over every parameter of the layer: weights,
+	// biases, and gamma and beta of a normalized layer. It is 0 when both
+	// gradients are zero.
+	RelativeError float64
+
+	// MaxAbsoluteError is the largest difference for a single parameter
+	MaxAbsoluteError float64
+}
+
+// GradCheck compares the gradients BackpropagateBatch computes for a
+// mini-batch, those of the average loss plus the L1 / L2 penalties, with
+// central finite differences (f(θ+ε) - f(θ-ε)) / 2ε of the same objective,
+// one parameter at a time. Parameters and batch norm running statistics are
+// left as they were and no optimizer step is taken.
+//
+// Every pass reseeds the model's random source with the same seed, so the
+// dropout masks and the objective are one deterministic function, and the
+// batch is not split across Workers. The source is then reseeded from a
+// value drawn from it before the check.
+// Finite differences lose most of their digits in float32, so check float64
+// models; an epsilon around 1e-5 suits them. Relative errors below 1e-6 are
+// typical, a handful of parameters near a kink (ReLU at 0, the hinge margin)
+// can raise a layer's error.
+func (model *ModelOf[T]) GradCheck(batchInputs [][]T, batchTargets [][]T, epsilon float64) ([]GradCheckResult, error) {
+
+	nn := &model.NeuralNetwork
+	params := &nn.WeightsAndBiases
+
+	if nn.Network == nil && len(params.Weights) == 0 {
+		return nil, fmt.Errorf("model weights are not initialized")
+	}
+
+	if nn.Network != nil && len(nn.Network.Params()) == 0 {
+		return nil, fmt.Errorf("network has no parameters, it may not be built")
+	}
+
+	if len(batchInputs) == 0 || len(batchInputs) != len(batchTargets) {
+		return nil, fmt.Errorf("gradcheck needs a non-empty batch with one target per input, got %d inputs and %d targets", len(batchInputs), len(batchTargets))
+	}
+
+	if epsilon <= 0 {
+		return nil, fmt.Errorf("gradcheck epsilon must be positive, got %v", epsilon)
+	}
+
+	loss, err := model.lossFunction()
+	if err != nil {
+		return nil, err
+	}
+
+	// Layers of a Network keep the source they were built with, so it is
+	// reseeded in place rather than replaced
+	rng := model.random()
+	defer rng.Seed(rng.Int63())
+
+	// Restore the sharding once done
+	defer func(workers int) {
+		model.TrainingConfig.Workers = workers
+	}(model.TrainingConfig.Workers)
+
+	model.TrainingConfig.Workers = 1
+
+	// Training passes update the batch norm running statistics, every pass
+	// starts from the ones the model had
+	statistics := append(append([]*vectors.Tensor[T](nil), params.RunningMeans...), params.RunningVariances...)
+	if nn.Network != nil {
+		statistics = nn.networkState()
+	}
+	saved := make([][]T, len(statistics))
+	for i, t := range statistics {
+		if t != nil {
+			saved[i] = append([]T(nil), t.Data...)
+		}
+	}
+
+	restore := func() {
+		for i, t := range statistics {
+			if t != nil {
+				copy(t.Data, saved[i])
+			}
+		}
+	}
+
+	defer restore()
+
+	// Analytic gradients, from the same path as BackpropagateBatch
+	rng.Seed(gradCheckSeed)
+
+	groups, err := model.gradCheckGroups(batchInputs, batchTargets)
+	if err != nil {
+		return nil, err
+	}
+
+	restore()
+
+	cache := &forwardCache[T]{}
+
+	// at returns the objective with element k of values set to x
+	at := func(values *vectors.Tensor[T], k int, x T) (float64, error) {
+
+		v := values.Data[k]
+		values.Data[k] = x
+
+		defer func() {
+			values.Data[k] = v
+			restore()
+		}()
+
+		return model.objective(cache, batchInputs, batchTargets, loss)
+	}
+
+	results := make([]GradCheckResult, len(groups))
+
+	for g, group := range groups {
+
+		var diff, analyticNorm, numericalNorm, maxAbs float64
+
+		for p, values := range group.values {
+
+			analytic := group.grads[p].Data
+
+			for k, v := range values.Data {
+
+				// The step actually taken, which differs from epsilon when T rounds it
+				plus, minus := v+T(epsilon), v-T(epsilon)
+
+				lossPlus, err := at(values, k, plus)
+				if err != nil {
+					return nil, err
+				}
+
+				lossMinus, err := at(values, k, minus)
+				if err != nil {
+					return nil, err
+				}
+
+				numerical := (lossPlus - lossMinus) / (float64(plus) - float64(minus))
+				d := float64(analytic[k]) - numerical
+
+				diff += d * d
+				analyticNorm += float64(analytic[k]) * float64(analytic[k])
+				numericalNorm += numerical * numerical
+				maxAbs = math.Max(maxAbs, math.Abs(d))
+			}
+		}
+
+		results[g] = GradCheckResult{Layer: group.layer, MaxAbsoluteError: maxAbs}
+
+		if denominator := math.Sqrt(analyticNorm) + math.Sqrt(numericalNorm); denominator > 0 {
+			results[g].RelativeError = math.Sqrt(diff) / denominator
+		}
+	}
+
+	return results, nil
+}
+
+// gradCheckGroup holds the parameters of one layer, which are checked
+// together, and their analytic gradients
+type gradCheckGroup[T Float] struct {
+	layer         int
+	values, grads []*vectors.Tensor[T]
+}
+
+// gradCheckGroups computes the gradients of a mini-batch the way
+// BackpropagateBatch does and returns them grouped by layer
+func (model *ModelOf[T]) gradCheckGroups(batchInputs [][]T, batchTargets [][]T) ([]gradCheckGroup[T], error) {
+
+	nn := &model.NeuralNetwork
+
+	ws, err := model.gradients(batchInputs, batchTargets)
+	if err != nil {
+		return nil, err
+	}
+
+	if ws.dense != nil {
+
+		groups := make([]gradCheckGroup[T], len(nn.WeightsAndBiases.Weights))
+		for l := range groups {
+			groups[l] = gradCheckGroup[T]{l, layerTensors(&nn.WeightsAndBiases, l), layerTensors(&ws.dense.grads, l)}
+		}
+
+		return groups, nil
+	}
+
+	var groups []gradCheckGroup[T]
+
+	// The parameters of a graph are grouped by node, in the order of Params
+	if graph, ok := nn.Network.(*layers.Graph[T]); ok {
+
+		params, grads := graph.Params(), graph.Grads()
+		next := 0
+
+		for i, node := range graph.Topology().Nodes {
+
+			first := next
+			for values := 0; values < node.Params; next++ {
+				values += params[next].Len()
+			}
+
+			if next > first {
+				groups = append(groups, gradCheckGroup[T]{i, params[first:next], grads[first:next]})
+			}
+		}
+
+		return groups, nil
+	}
+
+	stages := []layers.Layer[T]{nn.Network}
+	if sequential, ok := nn.Network.(*layers.Sequential[T]); ok {
+		stages = sequential.Layers
+	}
+
+	for i, layer := range stages {
+		if params := layer.Params(); len(params) > 0 {
+			groups = append(groups, gradCheckGroup[T]{i, params, layer.Grads()})
+		}
+	}
+
+	return groups, nil
+}
+
+// objective returns the average loss of a mini-batch plus the L1 / L2
+// penalties, from a training forward pass whose dropout masks are drawn from
+// the model's random source seeded with gradCheckSeed. It updates the batch
+// norm running statistics.
+func (model *ModelOf[T]) objective(cache *forwardCache[T], batchInputs [][]T, batchTargets [][]T, loss losses.Loss) (float64, error) {
+
+	nn := &model.NeuralNetwork
+
+	rng := model.random()
+	rng.Seed(gradCheckSeed)
+
+	net, err := model.network(model.workspace(0), rng)
+	if err != nil {
+		return 0, err
+	}
+
+	if cache.input, err = nn.networkInput(cache.input, batchInputs); err != nil {
+		return 0, err
+	}
+
+	out, err := nn.networkForward(net, cache.input, true)
+	if err != nil {
+		return 0, err
+	}
+
+	output, err := nn.output(&cache.tape, out, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	predictions := output.Value.Rows()
+
+	var prediction, target []float64
+
+	total := 0.0
+
+	for i := range predictions {
+
+		prediction = vectors.AsFloat64(predictions[i], prediction)
+		target = vectors.AsFloat64(batchTargets[i], target)
+
+		value, err := loss.Value(prediction, target)
+		if err != nil {
+			return 0, err
+		}
+
+		total += value
+	}
+
+	return total/float64(len(batchInputs)) + nn.regularizationPenalty(), nil
+}
+
+// layerTensors returns the trainable tensors of layer l: the weights, the
+// biases, then gamma and beta for a normalized layer
+func layerTensors[T Float](params *ModelWeightsAndBiasesOf[T], l int) []*vectors.Tensor[T] {
+
+	tensors := []*vectors.Tensor[T]{params.Weights[l], params.Biases[l]}
+
+	if l < len(params.Gammas) && params.Gammas[l] != nil {
+		tensors = append(tensors, params.Gammas[l], params.Betas[l])
+	}
+
+	return tensors
+}
@@ -0,0 +1,308 @@
+package neuralnetwork_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+const (
+	gradCheckEpsilon   = 1e-5
+	gradCheckTolerance = 1e-5
+)
+
+var gradCheckActivations = []activation.ActivationFunction{
+	activation.ReLU, activation.LeakyReLU, activation.LeakyReLUWithSlope(0.2), activation.ELU,
+	activation.SELU, activation.GELU, activation.Swish, activation.SiLU, activation.Softplus,
+	activation.HardSigmoid, activation.Linear, activation.Sigmoid, activation.Tanh, activation.Softmax,
+}
+
+var gradCheckLosses = []nn.LossFunction{
+	nn.MeanSquaredError, nn.MeanAbsoluteError, nn.Huber, nn.LogCosh, nn.Hinge,
+	nn.KLDivergence, nn.BinaryCrossEntropy, nn.CategoricalCrossEntropy, nn.SparseCategoricalCrossEntropy,
+}
+
+var gradCheckInitializers = []nn.Initialization{
+	nn.XavierUniformInitializer, nn.XavierNormalInitializer,
+	nn.KaimingUniformInitializer, nn.KaimingNormalInitializer,
+	"", // the default
+}
+
+// outputActivation returns the activation of the output layer for a loss:
+// the activation under test when its outputs are valid for the loss, a
+// softmax or sigmoid for losses that need probabilities otherwise
+func outputActivation(loss nn.LossFunction, act activation.ActivationFunction) activation.ActivationFunction {
+	switch loss {
+	case nn.CategoricalCrossEntropy, nn.SparseCategoricalCrossEntropy, nn.KLDivergence:
+		if act != activation.Sigmoid {
+			return activation.Softmax
+		}
+	case nn.BinaryCrossEntropy:
+		if act != activation.Softmax {
+			return activation.Sigmoid
+		}
+	}
+	return act
+}
+
+// targetsFor converts one-hot targets to the form loss expects
+func targetsFor(loss nn.LossFunction, oneHot [][]float64) [][]float64 {
+
+	if loss != nn.SparseCategoricalCrossEntropy {
+		return oneHot
+	}
+
+	sparse := make([][]float64, len(oneHot))
+	for i, row := range oneHot {
+		sparse[i] = []float64{float64(slices.Index(row, 1))}
+	}
+
+	return sparse
+}
+
+func checkGradients(t *testing.T, model *nn.Model, x, y [][]float64) {
+	t.Helper()
+
+	// Biases start at zero, which puts the pre-activations of samples whose
+	// hidden ReLUs are all off exactly on a kink. Random biases move them off it.
+	rng := rand.New(rand.NewSource(2))
+	for _, b := range model.NeuralNetwork.WeightsAndBiases.Biases {
+		for k := range b.Data {
+			b.Data[k] = 0.1 * rng.NormFloat64()
+		}
+	}
+
+	results, err := model.GradCheck(x, y, gradCheckEpsilon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != len(model.NeuralNetwork.Layers)+1 {
+		t.Fatalf("got %d results, want one per trainable layer (%d)", len(results), len(model.NeuralNetwork.Layers)+1)
+	}
+
+	for _, r := range results {
+		if r.RelativeError > gradCheckTolerance {
+			t.Errorf("layer %d: relative error %.3g (max absolute error %.3g), want at most %g", r.Layer, r.RelativeError, r.MaxAbsoluteError, gradCheckTolerance)
+		}
+	}
+}
+
+// checkNetworkGradients runs GradCheck on a model and fails for every
+// layer whose gradients differ from the finite differences
+func checkNetworkGradients(t *testing.T, model *nn.Model, x, y [][]float64) {
+	t.Helper()
+
+	results, err := model.GradCheck(x, y, gradCheckEpsilon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) == 0 {
+		t.Fatal("no layer was checked")
+	}
+
+	for _, r := range results {
+		if r.RelativeError > gradCheckTolerance {
+			t.Errorf("layer %d: relative error %.3g (max absolute error %.3g), want at most %g", r.Layer, r.RelativeError, r.MaxAbsoluteError, gradCheckTolerance)
+		}
+	}
+}
+
+func TestGradCheckCombinations(t *testing.T) {
+
+	for _, loss := range gradCheckLosses {
+		for _, act := range gradCheckActivations {
+			for _, init := range gradCheckInitializers {
+
+				output := outputActivation(loss, act)
+
+				name := fmt.Sprintf("%s/%s->%s/%s", loss, act, output, init)
+				if init == "" {
+					name += "default"
+				}
+
+				t.Run(name, func(t *testing.T) {
+
+					model := newTestModel(t,
+						[]nn.Layer{{Neurons: 6, ActivationFunction: act, Initialization: init}},
+						nn.OutputLayer{Neurons: 4, ActivationFunction: output, Initialization: init},
+						nn.TrainingConfig{LossFunction: loss, Seed: 1},
+					)
+
+					x, y := testBatch(5, 8, 4)
+
+					checkGradients(t, model, x, targetsFor(loss, y))
+				})
+			}
+		}
+	}
+}
+
+func TestGradCheckLayerOptions(t *testing.T) {
+
+	regularization := nn.Regularization{L1: 1e-3, L2: 1e-2, RegularizeBiases: true}
+
+	tests := []struct {
+		name   string
+		hidden []nn.Layer
+		output nn.OutputLayer
+		config nn.TrainingConfig
+	}{
+		{
+			name:   "no hidden layers",
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+		},
+		{
+			name: "batch norm",
+			hidden: []nn.Layer{
+				{Neurons: 6, ActivationFunction: activation.Tanh, Normalization: nn.BatchNorm},
+				{Neurons: 5, ActivationFunction: activation.Sigmoid, Normalization: nn.BatchNorm},
+			},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+		},
+		{
+			name: "layer norm",
+			hidden: []nn.Layer{
+				{Neurons: 6, ActivationFunction: activation.GELU, Normalization: nn.LayerNorm},
+				{Neurons: 5, ActivationFunction: activation.Tanh, Normalization: nn.LayerNorm},
+			},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Linear},
+			config: nn.TrainingConfig{LossFunction: nn.MeanSquaredError},
+		},
+		{
+			name: "dropout",
+			hidden: []nn.Layer{
+				{Neurons: 6, ActivationFunction: activation.Tanh, Dropout: 0.3},
+				{Neurons: 5, ActivationFunction: activation.Softplus, Dropout: 0.5},
+			},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+		},
+		{
+			name: "l1 and l2 penalties",
+			hidden: []nn.Layer{
+				{Neurons: 6, ActivationFunction: activation.Tanh, Regularization: regularization},
+			},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Sigmoid, Regularization: regularization},
+			config: nn.TrainingConfig{LossFunction: nn.BinaryCrossEntropy},
+		},
+		{
+			name: "everything",
+			hidden: []nn.Layer{
+				{Neurons: 6, ActivationFunction: activation.Swish, Dropout: 0.2, Normalization: nn.LayerNorm, Regularization: regularization},
+				{Neurons: 5, ActivationFunction: activation.ELU, Normalization: nn.LayerNorm},
+			},
+			output: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax, Regularization: regularization},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Seeded weights keep the pre-activations clear of kinks on every run
+			tt.config.Seed = 1
+
+			model := newTestModel(t, tt.hidden, tt.output, tt.config)
+			x, y := testBatch(6, 8, 4)
+
+			checkGradients(t, model, x, y)
+		})
+	}
+}
+
+// GradCheck runs on a single shard, so the gradients of a sharded batch are
+// compared with the unsharded ones instead
+func TestShardedGradients(t *testing.T) {
+
+	regularization := nn.Regularization{L1: 1e-3, L2: 1e-2}
+
+	hidden := []nn.Layer{
+		{Neurons: 6, ActivationFunction: activation.Swish, Normalization: nn.LayerNorm, Regularization: regularization},
+		{Neurons: 5, ActivationFunction: activation.ELU},
+	}
+	output := nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax, Regularization: regularization}
+
+	// With plain SGD at a learning rate of 1 the step is minus the gradient
+	config := nn.TrainingConfig{LearningRate: 1, Optimizer: nn.SGD}
+
+	single := newTestModel(t, hidden, output, config)
+
+	config.Workers = 3
+	sharded := newTestModel(t, hidden, output, config)
+
+	want, got := &single.NeuralNetwork.WeightsAndBiases, &sharded.NeuralNetwork.WeightsAndBiases
+
+	// Both start from the same parameters
+	for _, group := range [][2][]*vectors.Tensor[float64]{{want.Weights, got.Weights}, {want.Biases, got.Biases}, {want.Gammas, got.Gammas}, {want.Betas, got.Betas}} {
+		for l, values := range group[0] {
+			if values != nil {
+				copy(group[1][l].Data, values.Data)
+			}
+		}
+	}
+
+	x, y := testBatch(7, 8, 4)
+
+	if err := single.BackpropagateBatch(x, y); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sharded.BackpropagateBatch(x, y); err != nil {
+		t.Fatal(err)
+	}
+
+	for l := range want.Weights {
+		for _, pair := range [][2]*vectors.Tensor[float64]{{want.Weights[l], got.Weights[l]}, {want.Biases[l], got.Biases[l]}, {want.Gammas[l], got.Gammas[l]}, {want.Betas[l], got.Betas[l]}} {
+
+			if pair[0] == nil {
+				continue
+			}
+
+			for k := range pair[0].Data {
+				if d := math.Abs(pair[0].Data[k] - pair[1].Data[k]); d > 1e-12 {
+					t.Fatalf("layer %d: sharded step differs by %g from the unsharded one", l+1, d)
+				}
+			}
+		}
+	}
+}
+
+func TestGradCheckLeavesModelUnchanged(t *testing.T) {
+
+	model := newTestModel(t,
+		[]nn.Layer{{Neurons: 6, ActivationFunction: activation.Tanh, Normalization: nn.BatchNorm, Dropout: 0.2}},
+		nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+		nn.TrainingConfig{Workers: 2},
+	)
+
+	x, y := testBatch(6, 8, 4)
+
+	params := &model.NeuralNetwork.WeightsAndBiases
+
+	before := [][]float64{params.Weights[0].Clone().Data, params.Gammas[0].Clone().Data, params.RunningMeans[0].Clone().Data, params.RunningVariances[0].Clone().Data}
+	after := [][]float64{params.Weights[0].Data, params.Gammas[0].Data, params.RunningMeans[0].Data, params.RunningVariances[0].Data}
+
+	if _, err := model.GradCheck(x, y, gradCheckEpsilon); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range before {
+		if !slices.Equal(before[i], after[i]) {
+			t.Errorf("GradCheck changed the model's parameters or running statistics")
+		}
+	}
+
+	if model.TrainingConfig.Workers != 2 {
+		t.Errorf("GradCheck changed Workers to %d", model.TrainingConfig.Workers)
+	}
+
+	if _, err := model.GradCheck(x, y, 0); err == nil {
+		t.Errorf("GradCheck accepted a zero epsilon")
+	}
+}
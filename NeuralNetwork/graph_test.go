@@ -0,0 +1,275 @@
+package neuralnetwork_test
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/dataset"
+	"github.com/ThakurMayank5/gonn/layers"
+	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
+)
+
+// residualGraph returns a graph of 8 inputs with a residual block and a
+// softmax output of 4 classes
+func residualGraph() *layers.Graph[float64] {
+
+	g := layers.NewGraph[float64]()
+
+	x := g.Input(8)
+	h := g.Apply(&layers.Dense[float64]{Neurons: 8, ActivationFunction: activation.Tanh}, x)
+	h = g.Apply(&layers.Dense[float64]{Neurons: 8}, h)
+	h = g.Merge(&layers.Add[float64]{}, x, h)
+	h = g.Apply(&layers.LayerNorm[float64]{}, h)
+	g.Output(g.Apply(&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax}, h))
+
+	return g
+}
+
+// twoHeadGraph returns a graph reading 3 numeric features and 5 token IDs
+// below 7, with a softmax head of 3 classes and a linear head of 1 value
+func twoHeadGraph() *layers.Graph[float64] {
+
+	g := layers.NewGraph[float64]()
+
+	numeric := g.Input(3)
+	tokens := g.Input(5)
+
+	n := g.Apply(&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Tanh}, numeric)
+
+	s := g.Apply(&layers.Embedding[float64]{Vocabulary: 7, Dimensions: 4, MaskZero: true}, tokens)
+	s = g.Apply(&layers.TransformerEncoder[float64]{Heads: 2, FeedForward: 6, ActivationFunction: activation.Tanh}, s)
+	s = g.Apply(&layers.GlobalAveragePooling1D[float64]{}, s)
+
+	h := g.Merge(&layers.Concatenate[float64]{}, n, s)
+	h = g.Apply(&layers.Dense[float64]{Neurons: 6, ActivationFunction: activation.Tanh}, h)
+
+	g.Output(
+		g.Apply(&layers.Dense[float64]{Neurons: 3, ActivationFunction: activation.Softmax}, h),
+		g.Apply(&layers.Dense[float64]{Neurons: 1}, h),
+	)
+
+	return g
+}
+
+// twoHeadBatch returns samples for twoHeadGraph with targets of a class and a value
+func twoHeadBatch(batchSize int, seed int64) ([][]float64, [][]float64) {
+
+	rng := rand.New(rand.NewSource(seed))
+	x := make([][]float64, batchSize)
+	y := make([][]float64, batchSize)
+
+	for i := range x {
+
+		x[i] = make([]float64, 8)
+		for k := range 3 {
+			x[i][k] = rng.NormFloat64()
+		}
+		for k := 3; k < 8; k++ {
+			x[i][k] = float64(rng.Intn(7))
+		}
+
+		// The class is the sign pattern of the first two features, the value mixes a feature and a token count
+		class := 0.0
+		if x[i][0] > 0 {
+			class++
+		}
+		if x[i][1] > 0 {
+			class++
+		}
+
+		ones := 0.0
+		for _, id := range x[i][3:] {
+			if id == 1 {
+				ones++
+			}
+		}
+
+		y[i] = []float64{class, x[i][2] + ones}
+	}
+
+	return x, y
+}
+
+func TestGraphGradCheck(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		graph  func() *layers.Graph[float64]
+		config nn.TrainingConfig
+		batch  func() ([][]float64, [][]float64)
+	}{
+		{
+			name:  "residual block",
+			graph: residualGraph,
+			batch: func() ([][]float64, [][]float64) { return testBatch(4, 8, 4) },
+		},
+		{
+			name: "two inputs concatenated, a branch reused",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				a := g.Apply(&layers.Dense[float64]{Neurons: 3, ActivationFunction: activation.Sigmoid}, g.Input(5))
+				b := g.Apply(&layers.Dense[float64]{Neurons: 2, ActivationFunction: activation.Tanh}, g.Input(3))
+				h := g.Merge(&layers.Concatenate[float64]{}, a, b, a)
+				g.Output(g.Apply(&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax}, h))
+				return g
+			},
+			batch: func() ([][]float64, [][]float64) { return testBatch(4, 8, 4) },
+		},
+		{
+			name:   "masked tokens and features into two heads",
+			graph:  twoHeadGraph,
+			config: nn.TrainingConfig{OutputLosses: []nn.LossFunction{nn.SparseCategoricalCrossEntropy, nn.Huber}, LossWeights: []float64{1, 0.5}},
+			batch:  func() ([][]float64, [][]float64) { return twoHeadBatch(6, 1) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tt.config.Seed = 1
+			model := newNetworkModel(t, tt.graph(), tt.config)
+
+			x, y := tt.batch()
+
+			checkNetworkGradients(t, model, x, y)
+		})
+	}
+}
+
+func TestGraphMatchesSequential(t *testing.T) {
+
+	config := nn.TrainingConfig{LearningRate: 0.1, Optimizer: nn.Adam, Seed: 1}
+
+	chain := func() []layers.Layer[float64] {
+		return []layers.Layer[float64]{
+			&layers.Dense[float64]{Neurons: 6, ActivationFunction: activation.ReLU},
+			&layers.Dropout[float64]{Rate: 0.2},
+			&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+		}
+	}
+
+	g := layers.NewGraph[float64]()
+	node := g.Input(8)
+	for _, layer := range chain() {
+		node = g.Apply(layer, node)
+	}
+	g.Output(node)
+
+	// Both draw their initial weights and dropout masks from the same seed
+	sequential := newNetworkModel(t, layers.NewSequential(chain()...), config)
+	graph := newNetworkModel(t, g, config)
+
+	x, y := testBatch(5, 8, 4)
+
+	for step := 0; step < 3; step++ {
+		if err := sequential.BackpropagateBatch(x, y); err != nil {
+			t.Fatal(err)
+		}
+		if err := graph.BackpropagateBatch(x, y); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := sequential.NeuralNetwork.Predict(x[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := graph.NeuralNetwork.Predict(x[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for j := range want {
+		if d := math.Abs(got[j] - want[j]); d > 1e-12 {
+			t.Errorf("prediction %d is %v, want %v", j, got[j], want[j])
+		}
+	}
+}
+
+func TestGraphFit(t *testing.T) {
+
+	data := func(n int, seed int64) dataset.Dataset {
+		x, y := twoHeadBatch(n, seed)
+		return dataset.Dataset{Inputs: x, Outputs: y, NumSamples: n, NumFeatures: 8, NumOutputs: 2}
+	}
+
+	training, validation := data(400, 1), data(100, 2)
+
+	config := nn.TrainingConfig{
+		Epochs:       10,
+		BatchSize:    16,
+		LearningRate: 0.01,
+		Optimizer:    nn.Adam,
+		OutputLosses: []nn.LossFunction{nn.SparseCategoricalCrossEntropy, nn.MeanSquaredError},
+		Seed:         1,
+	}
+
+	model := newNetworkModel(t, twoHeadGraph(), config)
+
+	before, err := model.ForwardPassBatch(validation.Inputs, validation.Outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := model.Fit(training, validation); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := model.ForwardPassBatch(validation.Inputs, validation.Outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after > before/5 {
+		t.Errorf("validation loss went from %g to %g, want at least a fivefold drop", before, after)
+	}
+
+	path := filepath.Join(t.TempDir(), "model.gob")
+	if err := model.SaveWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &nn.Model{NeuralNetwork: nn.NeuralNetwork{InputLayer: nn.InputLayer{Neurons: 8}, Network: twoHeadGraph()}}
+	if err := loaded.LoadWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := model.NeuralNetwork.Predict(validation.Inputs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loaded.NeuralNetwork.Predict(validation.Inputs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("loaded model predicts %v, want %v", got, want)
+	}
+
+	// The same layers wired differently are refused: features after the tokens
+	rewired := layers.NewGraph[float64]()
+	numeric := rewired.Input(3)
+	tokens := rewired.Input(5)
+	n := rewired.Apply(&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Tanh}, numeric)
+	s := rewired.Apply(&layers.Embedding[float64]{Vocabulary: 7, Dimensions: 4, MaskZero: true}, tokens)
+	s = rewired.Apply(&layers.TransformerEncoder[float64]{Heads: 2, FeedForward: 6, ActivationFunction: activation.Tanh}, s)
+	s = rewired.Apply(&layers.GlobalAveragePooling1D[float64]{}, s)
+	h := rewired.Merge(&layers.Concatenate[float64]{}, s, n)
+	h = rewired.Apply(&layers.Dense[float64]{Neurons: 6, ActivationFunction: activation.Tanh}, h)
+	rewired.Output(
+		rewired.Apply(&layers.Dense[float64]{Neurons: 3, ActivationFunction: activation.Softmax}, h),
+		rewired.Apply(&layers.Dense[float64]{Neurons: 1}, h),
+	)
+
+	other := &nn.Model{NeuralNetwork: nn.NeuralNetwork{InputLayer: nn.InputLayer{Neurons: 8}, Network: rewired}}
+	if err := other.LoadWeights(path); err == nil {
+		t.Errorf("a graph with other edges loaded the saved graph's weights")
+	}
+}
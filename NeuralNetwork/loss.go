@@ -0,0 +1,102 @@
+package neuralnetwork
+
+import (
+	"fmt"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/losses"
+)
+
+// lossFunction resolves TrainingConfig.LossFunction. When it is left empty the
+// loss follows the output layer, or the last activation of a Network:
+// categorical cross-entropy for softmax, MSE otherwise. A Graph network with
+// several outputs, OutputLosses or LossWeights gets one loss per output.
+func (model *ModelOf[T]) lossFunction() (losses.Loss, error) {
+
+	config := &model.TrainingConfig
+	network := model.NeuralNetwork.Network
+
+	graph, ok := network.(*layers.Graph[T])
+
+	if ok && (len(graph.OutputActivations()) > 1 || config.OutputLosses != nil || config.LossWeights != nil) {
+		return model.outputLosses(graph)
+	}
+
+	if config.OutputLosses != nil || config.LossWeights != nil {
+		return nil, fmt.Errorf("output losses and loss weights need a Graph network")
+	}
+
+	output := model.NeuralNetwork.OutputLayer.ActivationFunction
+	if network != nil {
+		output = layers.OutputActivation(network)
+	}
+
+	return resolveLoss(config.LossFunction, output)
+}
+
+// resolveLoss returns the loss named name, or the default one for outputs
+// with the given activation when name is empty
+func resolveLoss(name LossFunction, output activation.ActivationFunction) (losses.Loss, error) {
+
+	if name == "" {
+		if output == activation.Softmax {
+			name = CategoricalCrossEntropy
+		} else {
+			name = MeanSquaredError
+		}
+	}
+
+	return losses.Get(string(name))
+}
+
+// outputLosses combines the losses of the outputs of graph
+func (model *ModelOf[T]) outputLosses(graph *layers.Graph[T]) (losses.Loss, error) {
+
+	config := &model.TrainingConfig
+	topology := graph.Topology()
+	activations := graph.OutputActivations()
+
+	if len(topology.Nodes) == 0 {
+		return nil, fmt.Errorf("graph is not built")
+	}
+
+	if len(config.OutputLosses) > len(activations) {
+		return nil, fmt.Errorf("%d output losses for %d outputs", len(config.OutputLosses), len(activations))
+	}
+
+	if config.LossWeights != nil && len(config.LossWeights) != len(activations) {
+		return nil, fmt.Errorf("%d loss weights for %d outputs", len(config.LossWeights), len(activations))
+	}
+
+	combined := losses.Combined{Weights: config.LossWeights}
+
+	for i, o := range topology.Outputs {
+
+		name := config.LossFunction
+		if i < len(config.OutputLosses) && config.OutputLosses[i] != "" {
+			name = config.OutputLosses[i]
+		}
+
+		loss, err := resolveLoss(name, activations[i])
+		if err != nil {
+			return nil, fmt.Errorf("output %d: %v", i+1, err)
+		}
+
+		width := 1
+		for _, d := range topology.Nodes[o].Shape {
+			width *= d
+		}
+
+		targets := width
+		if _, ok := loss.(losses.SparseCategoricalCrossEntropyLoss); ok {
+			targets = 1
+		}
+
+		combined.Losses = append(combined.Losses, loss)
+		combined.Predictions = append(combined.Predictions, width)
+		combined.Targets = append(combined.Targets, targets)
+	}
+
+	return combined, nil
+}
@@ -0,0 +1,268 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/autodiff"
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// buildNetwork sizes and initializes the parameters of nn.Network, drawing
+// from the model's random source
+func (model *ModelOf[T]) buildNetwork() error {
+
+	nn := &model.NeuralNetwork
+
+	// The layers own their parameters, the dense layer tensors are unused
+	nn.WeightsAndBiases = ModelWeightsAndBiasesOf[T]{}
+
+	input := nn.inputShape()
+
+	size := 1
+	for _, d := range input {
+		size *= d
+	}
+
+	if nn.InputLayer.Neurons == 0 {
+		nn.InputLayer.Neurons = size
+	}
+
+	if size != nn.InputLayer.Neurons || size <= 0 {
+		return fmt.Errorf("input shape %v does not hold the %d input neurons", input, nn.InputLayer.Neurons)
+	}
+
+	if err := nn.Network.Build(input, model.random()); err != nil {
+		return err
+	}
+
+	output, err := nn.Network.OutputShape(input)
+	if err != nil {
+		return err
+	}
+
+	if len(output) != 1 {
+		return fmt.Errorf("network output samples must be flat vectors, got shape %v", output)
+	}
+
+	return nil
+}
+
+// inputShape returns the shape of one input sample of the network, flat for
+// Layers and OutputLayer
+func (nn *NeuralNetworkOf[T]) inputShape() []int {
+	if nn.Network != nil && len(nn.InputLayer.Shape) > 0 {
+		return nn.InputLayer.Shape
+	}
+	return []int{nn.InputLayer.Neurons}
+}
+
+// checkNetwork returns an error when nn.Network is combined with settings
+// only Layers and OutputLayer support, which would otherwise be ignored
+func (nn *NeuralNetworkOf[T]) checkNetwork(workers int) error {
+
+	if nn.Network == nil {
+		return nil
+	}
+
+	if workers > 1 {
+		return fmt.Errorf("a network trains on a single goroutine, got %d workers", workers)
+	}
+
+	if len(nn.Layers) > 0 || nn.OutputLayer != (OutputLayer{}) {
+		return fmt.Errorf("a network replaces the hidden and output layers, their regularization and constraints are not supported with a network")
+	}
+
+	return nil
+}
+
+// network returns the network shard ws trains: nn.Network, or the shard's
+// denseNetwork, whose dropout draws from rng
+func (model *ModelOf[T]) network(ws *workspace[T], rng *rand.Rand) (layers.Layer[T], error) {
+
+	nn := &model.NeuralNetwork
+
+	if nn.Network != nil {
+		ws.dense = nil
+		return nn.Network, nil
+	}
+
+	d, err := nn.dense(ws.dense, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.dense = d
+
+	return d.sequential, nil
+}
+
+// predictor returns the denseNetwork Predict runs inputs through
+func (nn *NeuralNetworkOf[T]) predictor() (*denseNetwork[T], error) {
+
+	d, err := nn.dense(nn.inference, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	nn.inference = d
+
+	return d, nil
+}
+
+// networkInput copies a batch of samples into the [batch, input shape...]
+// tensor x, reusing its memory when possible
+func (nn *NeuralNetworkOf[T]) networkInput(x *vectors.Tensor[T], batchInputs [][]T) (*vectors.Tensor[T], error) {
+
+	inputs := nn.InputLayer.Neurons
+
+	// Flat samples, the common case, are sized without building a shape
+	if shape := nn.inputShape(); len(shape) == 1 {
+		x = vectors.Reuse(x, len(batchInputs), inputs)
+	} else {
+		x = vectors.Reuse(x, append([]int{len(batchInputs)}, shape...)...)
+	}
+
+	for i, row := range batchInputs {
+		if len(row) != inputs {
+			return nil, fmt.Errorf("input has %d values but the input layer has %d neurons", len(row), inputs)
+		}
+		copy(x.Data[i*inputs:(i+1)*inputs], row)
+	}
+
+	return x, nil
+}
+
+// networkForward runs a batch through net on the backend of nn and returns
+// its [batch, outputs] output, owned by the network
+func (nn *NeuralNetworkOf[T]) networkForward(net layers.Layer[T], x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if l, ok := net.(layers.BackendSetter[T]); ok {
+		l.SetBackend(nn.Backend)
+	}
+
+	out, err := net.Forward(x, training)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Dims() != 2 {
+		return nil, fmt.Errorf("network output has shape %v, want [batch, outputs]", out.Shape)
+	}
+
+	return out, nil
+}
+
+// output records out, the output of the network, on tape followed by the
+// activation of OutputLayer, which a denseNetwork leaves to the model. A
+// Network applies its own. When grad is set, out is recorded as trainable
+// and grad receives its gradient from the backward pass of the tape.
+func (nn *NeuralNetworkOf[T]) output(tape *autodiff.Tape[T], out, grad *vectors.Tensor[T]) (*autodiff.Node[T], error) {
+
+	tape.Backend = nn.Backend
+	tape.Reset()
+
+	var prediction *autodiff.Node[T]
+
+	if grad == nil {
+		prediction = tape.Constant(out)
+	} else {
+		var err error
+		if prediction, err = tape.Param(out, grad); err != nil {
+			return nil, err
+		}
+	}
+
+	if name := nn.OutputLayer.ActivationFunction; nn.Network == nil && name != "" {
+		return tape.Activate(prediction, name)
+	}
+
+	return prediction, nil
+}
+
+// networkParameters appends the data of every parameter of net to params and
+// of the matching gradient to grads, in the order expected by Optimizer.Step
+func networkParameters[T Float](net layers.Layer[T], params, grads [][]T) ([][]T, [][]T) {
+
+	for _, p := range net.Params() {
+		params = append(params, p.Data)
+	}
+
+	for _, g := range net.Grads() {
+		grads = append(grads, g.Data)
+	}
+
+	return params, grads
+}
+
+// decayMask appends to mask whether weight decay applies to every parameter
+// of net, in the order of networkParameters: to the weights, the parameters
+// of two dimensions or more, and not to biases or normalization parameters
+func decayMask[T Float](net layers.Layer[T], mask []bool) []bool {
+	for _, p := range net.Params() {
+		mask = append(mask, p.Dims() >= 2)
+	}
+	return mask
+}
+
+// networkRows returns the rows of the parameters of net that received
+// gradient, in the order of networkParameters, nil when every parameter is dense
+func networkRows[T Float](net layers.Layer[T]) []layers.Rows {
+	if sparse, ok := net.(layers.Sparse); ok {
+		return sparse.SparseRows()
+	}
+	return nil
+}
+
+// networkState returns the state of nn.Network, nil when it keeps none
+func (nn *NeuralNetworkOf[T]) networkState() []*vectors.Tensor[T] {
+	if stateful, ok := nn.Network.(layers.Stateful[T]); ok {
+		return stateful.State()
+	}
+	return nil
+}
+
+// networkSummary prints the layers of nn.Network, one line per layer of a
+// Sequential network or node of a Graph
+func (nn *NeuralNetworkOf[T]) networkSummary() {
+
+	if graph, ok := nn.Network.(*layers.Graph[T]); ok {
+
+		topology := graph.Topology()
+
+		for i, node := range topology.Nodes {
+			fmt.Printf("Node %d %s Inputs: %v Output Shape: %v Parameters: %d\n", i, node.Layer, node.Inputs, node.Shape, node.Params)
+		}
+
+		fmt.Println("Outputs:", topology.Outputs)
+		return
+	}
+
+	stages := []layers.Layer[T]{nn.Network}
+	if sequential, ok := nn.Network.(*layers.Sequential[T]); ok {
+		stages = sequential.Layers
+	}
+
+	shape := nn.inputShape()
+
+	fmt.Println("Input Shape:", shape)
+
+	for i, layer := range stages {
+
+		next, err := layer.OutputShape(shape)
+		if err != nil {
+			fmt.Println("Layer", i+1, "Error:", err)
+			return
+		}
+
+		parameters := 0
+		for _, p := range layer.Params() {
+			parameters += p.Len()
+		}
+
+		fmt.Printf("Layer %d %T Output Shape: %v Parameters: %d\n", i+1, layer, next, parameters)
+
+		shape = next
+	}
+}
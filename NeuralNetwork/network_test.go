@@ -0,0 +1,881 @@
+package neuralnetwork_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/backend"
+	"github.com/ThakurMayank5/gonn/dataloader"
+	"github.com/ThakurMayank5/gonn/dataset"
+	"github.com/ThakurMayank5/gonn/layers"
+	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+func newNetworkModel(t testing.TB, network layers.Layer[float64], config nn.TrainingConfig) *nn.Model {
+	t.Helper()
+
+	model := &nn.Model{
+		NeuralNetwork: nn.NeuralNetwork{
+			InputLayer: nn.InputLayer{Neurons: 8},
+			Network:    network,
+		},
+		TrainingConfig: config,
+	}
+
+	if err := model.InitializeWeights(); err != nil {
+		t.Fatal(err)
+	}
+
+	return model
+}
+
+func TestNetworkGradCheck(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		network func() layers.Layer[float64]
+		loss    nn.LossFunction
+	}{
+		{
+			name: "dense layers",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.Dense[float64]{Neurons: 6, ActivationFunction: activation.Tanh},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+		},
+		{
+			name: "normalization, dropout and a separate activation",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.Dense[float64]{Neurons: 6, Initialization: layers.KaimingNormal},
+					&layers.BatchNorm[float64]{},
+					&layers.Activation[float64]{Function: activation.GELU},
+					&layers.Dropout[float64]{Rate: 0.3},
+					&layers.Dense[float64]{Neurons: 5, ActivationFunction: activation.Sigmoid},
+					&layers.LayerNorm[float64]{},
+					&layers.Dense[float64]{Neurons: 4},
+				)
+			},
+			loss: nn.MeanSquaredError,
+		},
+		{
+			name: "nested sequential",
+			network: func() layers.Layer[float64] {
+				block := layers.NewSequential[float64](
+					&layers.Dense[float64]{Neurons: 6, ActivationFunction: activation.Swish},
+					&layers.LayerNorm[float64]{},
+				)
+				return layers.NewSequential[float64](block, &layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax})
+			},
+			loss: nn.SparseCategoricalCrossEntropy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			model := newNetworkModel(t, tt.network(), nn.TrainingConfig{LossFunction: tt.loss, Seed: 1})
+
+			x, y := testBatch(6, 8, 4)
+
+			checkNetworkGradients(t, model, x, targetsFor(tt.loss, y))
+		})
+	}
+}
+
+func TestConvNetworkGradCheck(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		network func() layers.Layer[float64]
+	}{
+		{
+			name: "conv, max pool, flatten",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.Conv2D[float64]{Filters: 3, Kernel: [2]int{3, 3}, Padding: [2]int{1, 1}, ActivationFunction: activation.Tanh, Initialization: layers.KaimingNormal},
+					&layers.MaxPool2D[float64]{Kernel: [2]int{2, 2}},
+					&layers.Flatten[float64]{},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+		},
+		{
+			name: "strided conv, overlapping avg pool, global average pooling",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.Conv2D[float64]{Filters: 4, Kernel: [2]int{2, 3}, Stride: [2]int{1, 2}, Padding: [2]int{1, 1}, ActivationFunction: activation.Swish},
+					&layers.AvgPool2D[float64]{Kernel: [2]int{2, 2}, Stride: [2]int{1, 1}, Padding: [2]int{1, 0}},
+					&layers.Conv2D[float64]{Filters: 4, Kernel: [2]int{1, 1}},
+					&layers.MaxPool2D[float64]{Kernel: [2]int{3, 2}, Stride: [2]int{2, 1}, Padding: [2]int{1, 1}},
+					&layers.GlobalAveragePooling2D[float64]{},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			model := &nn.Model{
+				NeuralNetwork: nn.NeuralNetwork{
+					InputLayer: nn.InputLayer{Shape: []int{6, 5, 2}},
+					Network:    tt.network(),
+				},
+				TrainingConfig: nn.TrainingConfig{Seed: 1},
+			}
+
+			if err := model.InitializeWeights(); err != nil {
+				t.Fatal(err)
+			}
+
+			if model.NeuralNetwork.InputLayer.Neurons != 60 {
+				t.Fatalf("input neurons set to %d, want 60", model.NeuralNetwork.InputLayer.Neurons)
+			}
+
+			x, y := testBatch(3, 60, 4)
+
+			checkNetworkGradients(t, model, x, y)
+		})
+	}
+}
+
+func TestNetworkMatchesDenseLayers(t *testing.T) {
+
+	config := nn.TrainingConfig{LearningRate: 0.1, Optimizer: nn.Adam}
+
+	classic := newTestModel(t,
+		[]nn.Layer{{Neurons: 6, ActivationFunction: activation.ReLU}},
+		nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+		config,
+	)
+
+	hidden := &layers.Dense[float64]{Neurons: 6, ActivationFunction: activation.ReLU}
+	output := &layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax}
+
+	network := newNetworkModel(t, layers.NewSequential[float64](hidden, output), config)
+
+	params := &classic.NeuralNetwork.WeightsAndBiases
+	for l, dense := range []*layers.Dense[float64]{hidden, output} {
+		copy(dense.Weights().Data, params.Weights[l].Data)
+		copy(dense.Biases().Data, params.Biases[l].Data)
+	}
+
+	x, y := testBatch(5, 8, 4)
+
+	for step := 0; step < 3; step++ {
+		if err := classic.BackpropagateBatch(x, y); err != nil {
+			t.Fatal(err)
+		}
+		if err := network.BackpropagateBatch(x, y); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for l, dense := range []*layers.Dense[float64]{hidden, output} {
+		for k, w := range dense.Weights().Data {
+			if d := math.Abs(w - params.Weights[l].Data[k]); d > 1e-12 {
+				t.Fatalf("layer %d weight %d differs by %g after training", l+1, k, d)
+			}
+		}
+	}
+
+	want, err := classic.NeuralNetwork.Predict(x[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := network.NeuralNetwork.Predict(x[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for j := range want {
+		if d := math.Abs(got[j] - want[j]); d > 1e-12 {
+			t.Errorf("prediction %d is %v, want %v", j, got[j], want[j])
+		}
+	}
+}
+
+func TestNetworkUnsupportedOptions(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		config nn.TrainingConfig
+		layers func(network *nn.NeuralNetwork)
+	}{
+		{
+			name:   "workers",
+			config: nn.TrainingConfig{Workers: 2},
+		},
+		{
+			name: "hidden layer regularization",
+			layers: func(network *nn.NeuralNetwork) {
+				network.AddLayer(nn.Layer{Neurons: 6, Regularization: nn.Regularization{L2: 0.01}})
+			},
+		},
+		{
+			name: "output layer constraint",
+			layers: func(network *nn.NeuralNetwork) {
+				network.OutputLayer.Regularization.Constraint = nn.MaxNorm{Value: 1}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tt.config.LearningRate = 0.1
+			tt.config.Optimizer = nn.SGD
+
+			model := newNetworkModel(t, layers.NewSequential[float64](
+				&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+			), tt.config)
+
+			if tt.layers != nil {
+				tt.layers(&model.NeuralNetwork)
+			}
+
+			x, y := testBatch(4, 8, 4)
+
+			if err := model.BackpropagateBatch(x, y); err == nil {
+				t.Errorf("BackpropagateBatch ignored an option a network does not support")
+			}
+		})
+	}
+}
+
+// countingBackend counts the matrix products it runs
+type countingBackend struct {
+	backend.CPU[float64]
+	gemms int
+}
+
+func (b *countingBackend) Gemm(transA, transB bool, alpha float64, x, y *vectors.Tensor[float64], beta float64, c *vectors.Tensor[float64]) error {
+	b.gemms++
+	return b.CPU.Gemm(transA, transB, alpha, x, y, beta, c)
+}
+
+func TestNetworkBackend(t *testing.T) {
+
+	config := nn.TrainingConfig{LearningRate: 0.1, Optimizer: nn.Adam}
+
+	models := map[string]*nn.Model{
+		"dense layers": newTestModel(t,
+			[]nn.Layer{{Neurons: 6, ActivationFunction: activation.ReLU, Normalization: nn.LayerNorm}},
+			nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+			config,
+		),
+		"network": newNetworkModel(t, layers.NewSequential[float64](
+			&layers.Dense[float64]{Neurons: 6, ActivationFunction: activation.ReLU},
+			&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+		), config),
+	}
+
+	x, y := testBatch(5, 8, 4)
+
+	for name, model := range models {
+		t.Run(name, func(t *testing.T) {
+
+			be := &countingBackend{}
+			model.NeuralNetwork.Backend = be
+
+			if err := model.BackpropagateBatch(x, y); err != nil {
+				t.Fatal(err)
+			}
+
+			// Two layers, one product forward and two backward each, less
+			// at most the input gradient of the first layer
+			if be.gemms < 5 {
+				t.Errorf("training step ran %d matrix products on the backend, want at least 5", be.gemms)
+			}
+
+			be.gemms = 0
+
+			if _, err := model.NeuralNetwork.Predict(x[0]); err != nil {
+				t.Fatal(err)
+			}
+
+			if be.gemms != 2 {
+				t.Errorf("prediction ran %d matrix products on the backend, want 2", be.gemms)
+			}
+		})
+	}
+}
+
+func TestNetworkSaveLoad(t *testing.T) {
+
+	network := func() layers.Layer[float64] {
+		return layers.NewSequential[float64](
+			&layers.Dense[float64]{Neurons: 6, ActivationFunction: activation.ReLU},
+			&layers.BatchNorm[float64]{},
+			&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+		)
+	}
+
+	model := newNetworkModel(t, network(), nn.TrainingConfig{LearningRate: 0.01, Optimizer: nn.Adam, Seed: 1})
+
+	x, y := testBatch(6, 8, 4)
+
+	if err := model.BackpropagateBatch(x, y); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "model.gob")
+	if err := model.SaveWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The loaded network is built from the file's shapes, with its own random start
+	loaded := &nn.Model{NeuralNetwork: nn.NeuralNetwork{InputLayer: nn.InputLayer{Neurons: 8}, Network: network()}}
+	if err := loaded.LoadWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := model.NeuralNetwork.Predict(x[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loaded.NeuralNetwork.Predict(x[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("loaded model predicts %v, want %v", got, want)
+	}
+
+	classic := &nn.Model{NeuralNetwork: nn.NeuralNetwork{
+		InputLayer:  nn.InputLayer{Neurons: 8},
+		Layers:      []nn.Layer{{Neurons: 6, ActivationFunction: activation.ReLU}},
+		OutputLayer: nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+	}}
+	if err := classic.LoadWeights(path); err == nil {
+		t.Errorf("a model without a Network loaded a Network's weights")
+	}
+}
+
+func newSequenceModel(t testing.TB, network layers.Layer[float64], config nn.TrainingConfig) *nn.Model {
+	t.Helper()
+
+	model := &nn.Model{
+		NeuralNetwork: nn.NeuralNetwork{
+			InputLayer: nn.InputLayer{Shape: []int{5, 3}},
+			Network:    network,
+		},
+		TrainingConfig: config,
+	}
+
+	if err := model.InitializeWeights(); err != nil {
+		t.Fatal(err)
+	}
+
+	return model
+}
+
+func TestRecurrentNetworkGradCheck(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		network func() layers.Layer[float64]
+	}{
+		{
+			name: "stacked simple rnn",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.SimpleRNN[float64]{Units: 4, ReturnSequences: true},
+					&layers.SimpleRNN[float64]{Units: 3, ActivationFunction: activation.Sigmoid},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+		},
+		{
+			name: "lstm",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.LSTM[float64]{Units: 4},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+		},
+		{
+			name: "gru sequences, lstm, flatten",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.GRU[float64]{Units: 3, ReturnSequences: true},
+					&layers.LSTM[float64]{Units: 2, ReturnSequences: true},
+					&layers.Flatten[float64]{},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			model := newSequenceModel(t, tt.network(), nn.TrainingConfig{Seed: 1})
+
+			x, y := testBatch(4, 15, 4)
+
+			checkNetworkGradients(t, model, x, y)
+		})
+	}
+}
+
+func TestRecurrentFit(t *testing.T) {
+
+	// Predict the next value of a noisy sine wave from the 5 steps before it
+	rng := rand.New(rand.NewSource(1))
+	series := make([][]float64, 200)
+	for i := range series {
+		v := math.Sin(float64(i) / 4)
+		series[i] = []float64{v, math.Cos(float64(i) / 4), v + 0.05*rng.NormFloat64()}
+	}
+
+	data, err := dataset.Windows(series, 5, 1, []int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	training, validation, err := dataset.SplitWithoutShuffle(data, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network := func() layers.Layer[float64] {
+		return layers.NewSequential[float64](
+			&layers.GRU[float64]{Units: 8, Truncation: 3},
+			&layers.Dense[float64]{Neurons: 1},
+		)
+	}
+
+	model := newSequenceModel(t, network(), nn.TrainingConfig{
+		Epochs:       15,
+		BatchSize:    16,
+		LearningRate: 0.01,
+		Optimizer:    nn.Adam,
+		LossFunction: nn.MeanSquaredError,
+		ClipNorm:     1,
+		Seed:         1,
+	})
+
+	before, err := model.ForwardPassBatch(validation.Inputs, validation.Outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := model.Fit(training, validation); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := model.ForwardPassBatch(validation.Inputs, validation.Outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after > before/10 {
+		t.Errorf("validation loss went from %g to %g, want at least a tenfold drop", before, after)
+	}
+
+	path := filepath.Join(t.TempDir(), "model.gob")
+	if err := model.SaveWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &nn.Model{NeuralNetwork: nn.NeuralNetwork{InputLayer: nn.InputLayer{Shape: []int{5, 3}}, Network: network()}}
+	if err := loaded.LoadWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := model.NeuralNetwork.Predict(validation.Inputs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loaded.NeuralNetwork.Predict(validation.Inputs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("loaded model predicts %v, want %v", got, want)
+	}
+
+	// A dataset of sequences of another length is refused
+	short, err := dataset.Windows(series, 4, 1, []int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Fit(short, validation); err == nil {
+		t.Errorf("Fit accepted [4, 3] sequences for a [5, 3] input shape")
+	}
+}
+
+// idBatch returns a batch of samples of length IDs below vocabulary, with one-hot targets
+func idBatch(batchSize, length, vocabulary, outputs int) ([][]float64, [][]float64) {
+
+	x, y := testBatch(batchSize, length, outputs)
+
+	rng := rand.New(rand.NewSource(2))
+	for _, row := range x {
+		for k := range row {
+			row[k] = float64(rng.Intn(vocabulary))
+		}
+	}
+
+	return x, y
+}
+
+func TestEmbeddingGradCheck(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		network func() layers.Layer[float64]
+		x       [][]float64
+	}{
+		{
+			name: "token embedding into a gru",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.Embedding[float64]{Vocabulary: 7, Dimensions: 3},
+					&layers.GRU[float64]{Units: 3},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+		},
+		{
+			name: "categorical features among numeric ones",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.FeatureEmbedding[float64]{Embeddings: map[int]*layers.Embedding[float64]{
+						0: {Vocabulary: 7, Dimensions: 2},
+						3: {Vocabulary: 7, Dimensions: 3},
+					}},
+					&layers.Dense[float64]{Neurons: 5, ActivationFunction: activation.Tanh},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			model := &nn.Model{
+				NeuralNetwork: nn.NeuralNetwork{
+					InputLayer: nn.InputLayer{Neurons: 5},
+					Network:    tt.network(),
+				},
+				TrainingConfig: nn.TrainingConfig{Seed: 1},
+			}
+
+			if err := model.InitializeWeights(); err != nil {
+				t.Fatal(err)
+			}
+
+			// IDs repeat within the batch, so rows collect several contributions
+			x, y := idBatch(6, 5, 7, 4)
+
+			checkNetworkGradients(t, model, x, y)
+		})
+	}
+}
+
+func TestEmbeddingSparseUpdate(t *testing.T) {
+
+	embedding := &layers.Embedding[float64]{Vocabulary: 50, Dimensions: 4}
+	network := layers.NewSequential[float64](
+		embedding,
+		&layers.Flatten[float64]{},
+		&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+	)
+
+	model := &nn.Model{
+		NeuralNetwork:  nn.NeuralNetwork{InputLayer: nn.InputLayer{Neurons: 2}, Network: network},
+		TrainingConfig: nn.TrainingConfig{LearningRate: 0.1, Optimizer: nn.AdamW, WeightDecay: 0.1, Seed: 1},
+	}
+
+	if err := model.InitializeWeights(); err != nil {
+		t.Fatal(err)
+	}
+
+	table := embedding.Table()
+
+	_, y := testBatch(2, 2, 4)
+	first := [][]float64{{1, 2}, {2, 3}}
+	second := [][]float64{{10, 11}, {11, 10}}
+
+	if err := model.BackpropagateBatch(first, y); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := slices.Clone(table.Data)
+
+	if err := model.BackpropagateBatch(second, y); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dense Adam would keep moving rows 1 to 3 on their momentum and decay every row
+	for id := range 50 {
+		changed := !slices.Equal(table.Row(id), snapshot[id*4:(id+1)*4])
+		if want := id == 10 || id == 11; changed != want {
+			t.Errorf("row %d changed: %v, want %v", id, changed, want)
+		}
+	}
+}
+
+func TestCategoricalCSV(t *testing.T) {
+
+	// The class is given by the colour; size is noise
+	colours := []string{"red", "green", "blue", "amber", "violet", "teal"}
+
+	rng := rand.New(rand.NewSource(1))
+
+	csv := "colour,size,class\n"
+	for i := 0; i < 300; i++ {
+		c := rng.Intn(len(colours))
+		csv += fmt.Sprintf("%s,%.3f,%d\n", colours[c], 100+10*rng.NormFloat64(), c%3)
+	}
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := dataset.CSVConfig{
+		HasHeader:          true,
+		InputColumns:       []int{0, 1},
+		CategoricalColumns: []int{0},
+		HasLabelColumn:     true,
+		LabelColumn:        2,
+		Scaling:            dataset.ZScoreStandardize,
+	}
+
+	data, err := dataloader.FromCSV(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := slices.Sorted(slices.Values(colours))
+	if !slices.Equal(data.Categories[0], want) {
+		t.Fatalf("categories are %v, want %v", data.Categories[0], want)
+	}
+
+	for _, row := range data.Inputs {
+		if id := row[0]; id < 1 || id > float64(len(colours)) || id != math.Trunc(id) {
+			t.Fatalf("colour feature is %v, want an ID in [1, %d]", id, len(colours))
+		}
+	}
+
+	network := layers.NewSequential[float64](
+		&layers.FeatureEmbedding[float64]{Embeddings: map[int]*layers.Embedding[float64]{
+			0: {Vocabulary: data.Vocabulary(0), Dimensions: 3},
+		}},
+		&layers.Dense[float64]{Neurons: 8, ActivationFunction: activation.ReLU},
+		&layers.Dense[float64]{Neurons: 3, ActivationFunction: activation.Softmax},
+	)
+
+	model := &nn.Model{
+		NeuralNetwork: nn.NeuralNetwork{InputLayer: nn.InputLayer{Neurons: 2}, Network: network},
+		TrainingConfig: nn.TrainingConfig{
+			Epochs: 20, BatchSize: 16, LearningRate: 0.05, Optimizer: nn.Adam, Seed: 1,
+		},
+	}
+
+	if err := model.InitializeWeights(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := model.Fit(data, data); err != nil {
+		t.Fatal(err)
+	}
+
+	correct := 0
+	for i, input := range data.Inputs {
+		output, err := model.NeuralNetwork.Predict(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Index(output, slices.Max(output)) == slices.Index(data.Outputs[i], 1) {
+			correct++
+		}
+	}
+
+	if correct != len(data.Inputs) {
+		t.Errorf("%d of %d samples classified correctly, want all", correct, len(data.Inputs))
+	}
+
+	// A test file numbered with the training categories maps new ones to 0
+	if err := os.WriteFile(path, []byte("colour,size,class\nblue,100,2\ncyan,90,0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Categories = map[int][]string{0: data.Categories[0]}
+	test, err := dataloader.FromCSV(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := []float64{test.Inputs[0][0], test.Inputs[1][0]}; !slices.Equal(got, []float64{2, 0}) {
+		t.Errorf("test colours have IDs %v, want [2 0]", got)
+	}
+}
+
+func TestAttentionGradCheck(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		network func() layers.Layer[float64]
+
+		// ids feeds batches of 5 IDs below 7, with 0 as padding, instead of [5, 3] sequences
+		ids bool
+	}{
+		{
+			name: "multi-head attention over padded tokens",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.Embedding[float64]{Vocabulary: 7, Dimensions: 4, MaskZero: true},
+					&layers.PositionalEncoding[float64]{},
+					&layers.MultiHeadAttention[float64]{Heads: 2},
+					&layers.GlobalAveragePooling1D[float64]{},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+			ids: true,
+		},
+		{
+			name: "transformer encoder over padded tokens",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.Embedding[float64]{Vocabulary: 7, Dimensions: 4, MaskZero: true},
+					&layers.PositionalEncoding[float64]{},
+					&layers.TransformerEncoder[float64]{Heads: 2, FeedForward: 6, ActivationFunction: activation.Tanh},
+					&layers.GlobalAveragePooling1D[float64]{},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+			ids: true,
+		},
+		{
+			name: "stacked encoders over sequences",
+			network: func() layers.Layer[float64] {
+				return layers.NewSequential[float64](
+					&layers.TransformerEncoder[float64]{Heads: 3, FeedForward: 4, ActivationFunction: activation.Sigmoid},
+					&layers.TransformerEncoder[float64]{Heads: 1, ActivationFunction: activation.Tanh},
+					&layers.Flatten[float64]{},
+					&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Softmax},
+				)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var model *nn.Model
+			var x, y [][]float64
+
+			if tt.ids {
+				model = &nn.Model{
+					NeuralNetwork:  nn.NeuralNetwork{InputLayer: nn.InputLayer{Neurons: 5}, Network: tt.network()},
+					TrainingConfig: nn.TrainingConfig{Seed: 1},
+				}
+				if err := model.InitializeWeights(); err != nil {
+					t.Fatal(err)
+				}
+				x, y = idBatch(6, 5, 7, 4)
+			} else {
+				model = newSequenceModel(t, tt.network(), nn.TrainingConfig{Seed: 1})
+				x, y = testBatch(4, 15, 4)
+			}
+
+			checkNetworkGradients(t, model, x, y)
+		})
+	}
+}
+
+func TestTransformerFit(t *testing.T) {
+
+	// Tell whether a padded sentence of up to 8 tokens mentions token 1
+	rng := rand.New(rand.NewSource(1))
+
+	sentences := func(n int) dataset.Dataset {
+
+		data := dataset.Dataset{NumSamples: n, NumFeatures: 8, NumOutputs: 2}
+
+		for i := range n {
+
+			mentions := i%2 == 0
+			length := 2 + rng.Intn(7)
+
+			ids := make([]float64, 8)
+			for k := range length {
+				ids[k] = float64(2 + rng.Intn(10))
+			}
+			if mentions {
+				ids[rng.Intn(length)] = 1
+			}
+
+			label := []float64{1, 0}
+			if mentions {
+				label = []float64{0, 1}
+			}
+
+			data.Inputs = append(data.Inputs, ids)
+			data.Outputs = append(data.Outputs, label)
+		}
+
+		return data
+	}
+
+	training, validation := sentences(400), sentences(100)
+
+	network := layers.NewSequential[float64](
+		&layers.Embedding[float64]{Vocabulary: 12, Dimensions: 8, MaskZero: true},
+		&layers.PositionalEncoding[float64]{},
+		&layers.TransformerEncoder[float64]{Heads: 2, FeedForward: 16},
+		&layers.GlobalAveragePooling1D[float64]{},
+		&layers.Dense[float64]{Neurons: 2, ActivationFunction: activation.Softmax},
+	)
+
+	model := &nn.Model{
+		NeuralNetwork: nn.NeuralNetwork{InputLayer: nn.InputLayer{Neurons: 8}, Network: network},
+		TrainingConfig: nn.TrainingConfig{
+			Epochs:       10,
+			BatchSize:    16,
+			LearningRate: 0.01,
+			Optimizer:    nn.Adam,
+			LossFunction: nn.CategoricalCrossEntropy,
+			Seed:         1,
+		},
+	}
+
+	if err := model.InitializeWeights(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := model.Fit(training, validation); err != nil {
+		t.Fatal(err)
+	}
+
+	accuracy, err := model.Evaluate(validation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if accuracy < 95 {
+		t.Errorf("validation accuracy %.2f%%, want at least 95%%", accuracy)
+	}
+}
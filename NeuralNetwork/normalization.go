@@ -0,0 +1,58 @@
+package neuralnetwork
+
+import "github.com/ThakurMayank5/gonn/vectors"
+
+// Normalization selects the normalization applied to a hidden layer's
+// pre-activations, before the activation function
+type Normalization string
+
+const (
+	NoNormalization Normalization = ""
+	BatchNorm       Normalization = "batch_norm"
+	LayerNorm       Normalization = "layer_norm"
+)
+
+const (
+	normEpsilon = 1e-5
+
+	// batchNormMomentum is the weight of the current batch in the running statistics
+	batchNormMomentum = 0.1
+)
+
+// normalization returns the normalization of trainable layer l (the output layer is never normalized)
+func (nn *NeuralNetworkOf[T]) normalization(l int) Normalization {
+	if l >= len(nn.Layers) {
+		return NoNormalization
+	}
+	return nn.Layers[l].Normalization
+}
+
+// allocateNormalization creates gamma = 1, beta = 0 and the running statistics
+// for every normalized hidden layer
+func allocateNormalization[T Float](nn *NeuralNetworkOf[T]) {
+
+	trainable := len(nn.Layers) + 1
+
+	params := &nn.WeightsAndBiases
+	params.Gammas = make([]*vectors.Tensor[T], trainable)
+	params.Betas = make([]*vectors.Tensor[T], trainable)
+	params.RunningMeans = make([]*vectors.Tensor[T], trainable)
+	params.RunningVariances = make([]*vectors.Tensor[T], trainable)
+
+	for l, layer := range nn.Layers {
+
+		if layer.Normalization == NoNormalization {
+			continue
+		}
+
+		params.Gammas[l] = vectors.NewTensor[T](layer.Neurons)
+		params.Gammas[l].Fill(1.0)
+		params.Betas[l] = vectors.NewTensor[T](layer.Neurons)
+
+		if layer.Normalization == BatchNorm {
+			params.RunningMeans[l] = vectors.NewTensor[T](layer.Neurons)
+			params.RunningVariances[l] = vectors.NewTensor[T](layer.Neurons)
+			params.RunningVariances[l].Fill(1.0)
+		}
+	}
+}
@@ -0,0 +1,562 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ThakurMayank5/gonn/layers"
+)
+
+// OptimizerName selects one of the built-in optimizers
+type OptimizerName string
+
+const (
+	SGD      OptimizerName = "sgd"
+	Momentum OptimizerName = "momentum"
+	Nesterov OptimizerName = "nesterov"
+	RMSprop  OptimizerName = "rmsprop"
+	Adagrad  OptimizerName = "adagrad"
+	Adam     OptimizerName = "adam"
+	AdamW    OptimizerName = "adamw"
+)
+
+// Default hyperparameters, used when the TrainingConfig leaves them at zero
+const (
+	defaultMomentum = 0.9
+	defaultRho      = 0.9
+	defaultBeta1    = 0.9
+	defaultBeta2    = 0.999
+	defaultEpsilon  = 1e-8
+)
+
+// Optimizer updates model parameters from their gradients.
+//
+// Step is called once per mini-batch with every trainable parameter of the
+// model flattened into params, and the matching batch averaged gradients in
+// grads. A parameter keeps its index across calls, so implementations can
+// key per-parameter state on it.
+type OptimizerOf[T Float] interface {
+	Step(params, grads [][]T) error
+}
+
+// Optimizer updates the parameters of a float64 model
+type Optimizer = OptimizerOf[float64]
+
+// SparseOptimizerOf is implemented by optimizers that can update only some
+// rows of a parameter, the ones a Sparse layer such as an Embedding wrote
+// gradient to. rows[i] lists the rows of params[i], the zero Rows (or a nil
+// rows) stands for the whole parameter. Rows left out keep their values and
+// their optimizer state, so moments and weight decay only apply to a row on
+// the steps that look it up (lazy updates).
+type SparseOptimizerOf[T Float] interface {
+	OptimizerOf[T]
+	StepSparse(params, grads [][]T, rows []layers.Rows) error
+}
+
+// SparseOptimizer is a SparseOptimizerOf for float64 models
+type SparseOptimizer = SparseOptimizerOf[float64]
+
+// WeightDecayer is implemented by optimizers that apply weight decay. Before
+// every step the model sets one flag per parameter, indexed like Step's
+// params, that is true for the weights: matrices, kernels and embedding
+// tables. Biases and normalization scales and shifts are not decayed. Without
+// a mask every parameter is decayed.
+type WeightDecayer interface {
+	SetDecayMask(decay []bool)
+}
+
+// StatefulOptimizerOf is implemented by optimizers whose internal state
+// (moments, velocities, step counters) should be saved with the weights so
+// training can resume exactly where it stopped.
+type StatefulOptimizerOf[T Float] interface {
+	OptimizerOf[T]
+	State() OptimizerStateOf[T]
+	SetState(state OptimizerStateOf[T]) error
+}
+
+// StatefulOptimizer is a StatefulOptimizerOf for float64 models
+type StatefulOptimizer = StatefulOptimizerOf[float64]
+
+// OptimizerStateOf is the serializable state of a StatefulOptimizerOf.
+// Slots holds named per-parameter buffers, each indexed like Step's params.
+type OptimizerStateOf[T Float] struct {
+	Name  OptimizerName
+	Step  int
+	Slots map[string][][]T
+}
+
+// OptimizerState is the optimizer state of a float64 model
+type OptimizerState = OptimizerStateOf[float64]
+
+// NewOptimizer builds the built-in optimizer selected by config.Optimizer.
+// Hyperparameters are kept in float64 and converted to T on every step.
+func NewOptimizer[T Float](config TrainingConfigOf[T]) (OptimizerOf[T], error) {
+
+	lr := config.LearningRate
+	epsilon := orDefault(config.Epsilon, defaultEpsilon)
+
+	switch config.Optimizer {
+	case "", SGD:
+		return &momentumOptimizer[T]{name: SGD, learningRate: lr, momentum: config.Momentum}, nil
+	case Momentum:
+		return &momentumOptimizer[T]{name: Momentum, learningRate: lr, momentum: orDefault(config.Momentum, defaultMomentum)}, nil
+	case Nesterov:
+		return &momentumOptimizer[T]{name: Nesterov, learningRate: lr, momentum: orDefault(config.Momentum, defaultMomentum), nesterov: true}, nil
+	case RMSprop:
+		return &rmspropOptimizer[T]{learningRate: lr, rho: orDefault(config.Rho, defaultRho), epsilon: epsilon}, nil
+	case Adagrad:
+		return &adagradOptimizer[T]{learningRate: lr, epsilon: epsilon}, nil
+	case Adam, AdamW:
+		return &adamOptimizer[T]{
+			name:         config.Optimizer,
+			learningRate: lr,
+			beta1:        orDefault(config.Beta1, defaultBeta1),
+			beta2:        orDefault(config.Beta2, defaultBeta2),
+			epsilon:      epsilon,
+			weightDecay:  config.WeightDecay,
+			decoupled:    config.Optimizer == AdamW,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown optimizer: %q", config.Optimizer)
+	}
+}
+
+// getOptimizer returns the optimizer used by BackpropagateBatch, building it
+// on first use. A CustomOptimizer takes precedence over the configured name.
+func (model *ModelOf[T]) getOptimizer() (OptimizerOf[T], error) {
+
+	if model.optimizer != nil {
+		return model.optimizer, nil
+	}
+
+	optimizer := model.TrainingConfig.CustomOptimizer
+
+	if optimizer == nil {
+		var err error
+		optimizer, err = NewOptimizer(model.TrainingConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Resume from a state restored by LoadWeights
+	if model.loadedOptimizerState != nil {
+		stateful, ok := optimizer.(StatefulOptimizerOf[T])
+		if !ok {
+			return nil, fmt.Errorf("optimizer state was loaded but the optimizer does not accept state")
+		}
+		if err := stateful.SetState(*model.loadedOptimizerState); err != nil {
+			return nil, err
+		}
+		model.loadedOptimizerState = nil
+	}
+
+	model.optimizer = optimizer
+
+	return optimizer, nil
+}
+
+// momentumOptimizer implements SGD with optional classical or Nesterov momentum
+type momentumOptimizer[T Float] struct {
+	name         OptimizerName
+	learningRate float64
+	momentum     float64
+	nesterov     bool
+
+	step     int
+	velocity [][]T
+}
+
+func (o *momentumOptimizer[T]) Step(params, grads [][]T) error {
+	return o.StepSparse(params, grads, nil)
+}
+
+func (o *momentumOptimizer[T]) StepSparse(params, grads [][]T, rows []layers.Rows) error {
+
+	if err := checkStepShapes(params, grads, rows); err != nil {
+		return err
+	}
+
+	lr, momentum := T(o.learningRate), T(o.momentum)
+
+	// Plain SGD keeps no state
+	if o.momentum == 0 {
+		o.step++
+		for i := range params {
+			spans := spansOf(rows, i, len(params[i]))
+			for r := range spans.Len() {
+				lo, hi := spans.Span(r)
+				for k := lo; k < hi; k++ {
+					params[i][k] -= lr * grads[i][k]
+				}
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if o.velocity, err = ensureSlot("velocity", o.velocity, params); err != nil {
+		return err
+	}
+
+	o.step++
+
+	for i := range params {
+		v := o.velocity[i]
+		spans := spansOf(rows, i, len(params[i]))
+		for r := range spans.Len() {
+			lo, hi := spans.Span(r)
+			for k := lo; k < hi; k++ {
+				v[k] = momentum*v[k] - lr*grads[i][k]
+
+				if o.nesterov {
+					params[i][k] += momentum*v[k] - lr*grads[i][k]
+				} else {
+					params[i][k] += v[k]
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o *momentumOptimizer[T]) State() OptimizerStateOf[T] {
+	return OptimizerStateOf[T]{Name: o.name, Step: o.step, Slots: map[string][][]T{"velocity": o.velocity}}
+}
+
+func (o *momentumOptimizer[T]) SetState(state OptimizerStateOf[T]) error {
+	if err := checkStateName(o.name, state); err != nil {
+		return err
+	}
+	o.step = state.Step
+	o.velocity = state.Slots["velocity"]
+	return nil
+}
+
+// rmspropOptimizer scales the step by a running average of squared gradients
+type rmspropOptimizer[T Float] struct {
+	learningRate float64
+	rho          float64
+	epsilon      float64
+
+	step    int
+	squared [][]T
+}
+
+func (o *rmspropOptimizer[T]) Step(params, grads [][]T) error {
+	return o.StepSparse(params, grads, nil)
+}
+
+func (o *rmspropOptimizer[T]) StepSparse(params, grads [][]T, rows []layers.Rows) error {
+
+	if err := checkStepShapes(params, grads, rows); err != nil {
+		return err
+	}
+
+	var err error
+	if o.squared, err = ensureSlot("squared", o.squared, params); err != nil {
+		return err
+	}
+
+	o.step++
+
+	lr, rho, epsilon := T(o.learningRate), T(o.rho), T(o.epsilon)
+
+	for i := range params {
+		s := o.squared[i]
+		spans := spansOf(rows, i, len(params[i]))
+		for r := range spans.Len() {
+			lo, hi := spans.Span(r)
+			for k := lo; k < hi; k++ {
+				g := grads[i][k]
+				s[k] = rho*s[k] + (1.0-rho)*g*g
+				params[i][k] -= lr * g / (T(math.Sqrt(float64(s[k]))) + epsilon)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o *rmspropOptimizer[T]) State() OptimizerStateOf[T] {
+	return OptimizerStateOf[T]{Name: RMSprop, Step: o.step, Slots: map[string][][]T{"squared": o.squared}}
+}
+
+func (o *rmspropOptimizer[T]) SetState(state OptimizerStateOf[T]) error {
+	if err := checkStateName(RMSprop, state); err != nil {
+		return err
+	}
+	o.step = state.Step
+	o.squared = state.Slots["squared"]
+	return nil
+}
+
+// adagradOptimizer scales the step by the sum of all past squared gradients
+type adagradOptimizer[T Float] struct {
+	learningRate float64
+	epsilon      float64
+
+	step        int
+	accumulated [][]T
+}
+
+func (o *adagradOptimizer[T]) Step(params, grads [][]T) error {
+	return o.StepSparse(params, grads, nil)
+}
+
+func (o *adagradOptimizer[T]) StepSparse(params, grads [][]T, rows []layers.Rows) error {
+
+	if err := checkStepShapes(params, grads, rows); err != nil {
+		return err
+	}
+
+	var err error
+	if o.accumulated, err = ensureSlot("accumulated", o.accumulated, params); err != nil {
+		return err
+	}
+
+	o.step++
+
+	lr, epsilon := T(o.learningRate), T(o.epsilon)
+
+	for i := range params {
+		acc := o.accumulated[i]
+		spans := spansOf(rows, i, len(params[i]))
+		for r := range spans.Len() {
+			lo, hi := spans.Span(r)
+			for k := lo; k < hi; k++ {
+				g := grads[i][k]
+				acc[k] += g * g
+				params[i][k] -= lr * g / (T(math.Sqrt(float64(acc[k]))) + epsilon)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o *adagradOptimizer[T]) State() OptimizerStateOf[T] {
+	return OptimizerStateOf[T]{Name: Adagrad, Step: o.step, Slots: map[string][][]T{"accumulated": o.accumulated}}
+}
+
+func (o *adagradOptimizer[T]) SetState(state OptimizerStateOf[T]) error {
+	if err := checkStateName(Adagrad, state); err != nil {
+		return err
+	}
+	o.step = state.Step
+	o.accumulated = state.Slots["accumulated"]
+	return nil
+}
+
+// adamOptimizer implements Adam, and AdamW when decoupled is set
+type adamOptimizer[T Float] struct {
+	name         OptimizerName
+	learningRate float64
+	beta1        float64
+	beta2        float64
+	epsilon      float64
+	weightDecay  float64
+	decoupled    bool
+
+	// decay tells which parameters weight decay applies to, nil for all
+	decay []bool
+
+	step         int
+	firstMoment  [][]T
+	secondMoment [][]T
+}
+
+func (o *adamOptimizer[T]) SetDecayMask(decay []bool) {
+	o.decay = decay
+}
+
+func (o *adamOptimizer[T]) Step(params, grads [][]T) error {
+	return o.StepSparse(params, grads, nil)
+}
+
+func (o *adamOptimizer[T]) StepSparse(params, grads [][]T, rows []layers.Rows) error {
+
+	if err := checkStepShapes(params, grads, rows); err != nil {
+		return err
+	}
+
+	if o.decay != nil && len(o.decay) != len(params) {
+		return fmt.Errorf("decay mask has %d entries for %d parameters", len(o.decay), len(params))
+	}
+
+	var err error
+	if o.firstMoment, err = ensureSlot("first_moment", o.firstMoment, params); err != nil {
+		return err
+	}
+	if o.secondMoment, err = ensureSlot("second_moment", o.secondMoment, params); err != nil {
+		return err
+	}
+
+	o.step++
+
+	// Bias correction terms for the moment estimates
+	correction1 := T(1.0 - math.Pow(o.beta1, float64(o.step)))
+	correction2 := T(1.0 - math.Pow(o.beta2, float64(o.step)))
+
+	lr, beta1, beta2 := T(o.learningRate), T(o.beta1), T(o.beta2)
+	epsilon, weightDecay := T(o.epsilon), T(o.weightDecay)
+
+	for i := range params {
+		m := o.firstMoment[i]
+		v := o.secondMoment[i]
+
+		decay := weightDecay
+		if o.decay != nil && !o.decay[i] {
+			decay = 0
+		}
+
+		spans := spansOf(rows, i, len(params[i]))
+		for r := range spans.Len() {
+			lo, hi := spans.Span(r)
+			for k := lo; k < hi; k++ {
+				g := grads[i][k]
+
+				// Adam folds L2 weight decay into the gradient
+				if !o.decoupled {
+					g += decay * params[i][k]
+				}
+
+				m[k] = beta1*m[k] + (1.0-beta1)*g
+				v[k] = beta2*v[k] + (1.0-beta2)*g*g
+
+				mHat := m[k] / correction1
+				vHat := v[k] / correction2
+
+				// AdamW decouples weight decay from the adaptive update
+				if o.decoupled {
+					params[i][k] -= lr * decay * params[i][k]
+				}
+
+				params[i][k] -= lr * mHat / (T(math.Sqrt(float64(vHat))) + epsilon)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o *adamOptimizer[T]) State() OptimizerStateOf[T] {
+	return OptimizerStateOf[T]{
+		Name: o.name,
+		Step: o.step,
+		Slots: map[string][][]T{
+			"first_moment":  o.firstMoment,
+			"second_moment": o.secondMoment,
+		},
+	}
+}
+
+func (o *adamOptimizer[T]) SetState(state OptimizerStateOf[T]) error {
+	if err := checkStateName(o.name, state); err != nil {
+		return err
+	}
+	o.step = state.Step
+	o.firstMoment = state.Slots["first_moment"]
+	o.secondMoment = state.Slots["second_moment"]
+	return nil
+}
+
+func checkStepShapes[T Float](params, grads [][]T, rows []layers.Rows) error {
+	if len(params) != len(grads) {
+		return fmt.Errorf("optimizer got %d parameters but %d gradients", len(params), len(grads))
+	}
+	if rows != nil && len(rows) != len(params) {
+		return fmt.Errorf("optimizer got %d parameters but rows for %d", len(params), len(rows))
+	}
+	for i := range params {
+		if len(params[i]) != len(grads[i]) {
+			return fmt.Errorf("parameter %d has %d values but its gradient has %d", i, len(params[i]), len(grads[i]))
+		}
+		if rows == nil || rows[i].Dense() {
+			continue
+		}
+		for _, r := range rows[i].Indices {
+			if r < 0 || (r+1)*rows[i].Width > len(params[i]) {
+				return fmt.Errorf("parameter %d has no row %d of %d values", i, r, rows[i].Width)
+			}
+		}
+	}
+	return nil
+}
+
+// stepSpans are the ranges of a parameter's values a step updates: the whole
+// parameter, or each of the listed rows of a sparse one
+type stepSpans struct {
+	rows layers.Rows
+	size int
+}
+
+// spansOf returns the spans of parameter i, of size values, for the rows given to StepSparse
+func spansOf(rows []layers.Rows, i, size int) stepSpans {
+	if rows == nil {
+		return stepSpans{size: size}
+	}
+	return stepSpans{rows: rows[i], size: size}
+}
+
+// Len returns the number of spans
+func (s stepSpans) Len() int {
+	if s.rows.Dense() {
+		return 1
+	}
+	return len(s.rows.Indices)
+}
+
+// Span returns the bounds of span r
+func (s stepSpans) Span(r int) (int, int) {
+	if s.rows.Dense() {
+		return 0, s.size
+	}
+	lo := s.rows.Indices[r] * s.rows.Width
+	return lo, lo + s.rows.Width
+}
+
+func checkStateName[T Float](name OptimizerName, state OptimizerStateOf[T]) error {
+	if state.Name != name {
+		return fmt.Errorf("cannot restore %q optimizer state into a %q optimizer", state.Name, name)
+	}
+	return nil
+}
+
+// ensureSlot returns slot, or a zeroed buffer laid out like params when it
+// is nil. A slot that does not match the layout of params, such as one
+// restored from another model, is an error.
+func ensureSlot[T Float](name string, slot [][]T, params [][]T) ([][]T, error) {
+
+	if slot == nil {
+		return zerosLike2D(params), nil
+	}
+
+	if len(slot) != len(params) {
+		return slot, fmt.Errorf("optimizer state %q has %d buffers for %d parameters", name, len(slot), len(params))
+	}
+
+	for i := range params {
+		if len(slot[i]) != len(params[i]) {
+			return slot, fmt.Errorf("optimizer state %q has %d values for parameter %d of %d values", name, len(slot[i]), i, len(params[i]))
+		}
+	}
+
+	return slot, nil
+}
+
+func orDefault(value, fallback float64) float64 {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
+
+func zerosLike2D[T Float](x [][]T) [][]T {
+	out := make([][]T, len(x))
+	for i := range x {
+		out[i] = make([]T, len(x[i]))
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package neuralnetwork_test
+
+import (
+	"math"
+	"testing"
+
+	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
+)
+
+func TestAdamSteps(t *testing.T) {
+
+	// Two steps from params {1, -2} and {0.5} with a learning rate of 0.1 and
+	// the default betas and epsilon. After bias correction the first step
+	// moves every value by lr·g/(|g|+ε), e.g. 1 - 0.1·0.1/(0.1+1e-8) for Adam.
+	// AdamW first shrinks the value by lr·decay, 1 - 0.1·0.5·1 = 0.95.
+	grads := [][][]float64{
+		{{0.1, -0.2}, {0.3}},
+		{{0.3, 0.1}, {-0.1}},
+	}
+
+	tests := []struct {
+		name   string
+		config nn.TrainingConfig
+		mask   []bool
+		want   [][][]float64 // params after each step
+	}{
+		{
+			name:   "adam",
+			config: nn.TrainingConfig{Optimizer: nn.Adam},
+			want: [][][]float64{
+				{{0.900000009999999, -1.9000000049999997}, {0.4000000033333332}},
+				{{0.8082219022055899, -1.8733663027186758}, {0.35997814792808075}},
+			},
+		},
+		{
+			name:   "adam with l2 decay",
+			config: nn.TrainingConfig{Optimizer: nn.Adam, WeightDecay: 0.5},
+			want: [][][]float64{
+				{{0.9000000016666666, -1.9000000008333333}, {0.4000000018181818}},
+				{{0.8000358995445791, -1.8023040219999573}, {0.3207580724947825}},
+			},
+		},
+		{
+			name:   "adamw",
+			config: nn.TrainingConfig{Optimizer: nn.AdamW, WeightDecay: 0.5},
+			want: [][][]float64{
+				{{0.8500000099999989, -1.8000000049999996}, {0.37500000333333317}},
+				{{0.7157219017055899, -1.6833663024686756}, {0.31622814776141406}},
+			},
+		},
+		{
+			name:   "adamw without decay on the second parameter",
+			config: nn.TrainingConfig{Optimizer: nn.AdamW, WeightDecay: 0.5},
+			mask:   []bool{true, false},
+			want: [][][]float64{
+				{{0.8500000099999989, -1.8000000049999996}, {0.4000000033333332}},
+				{{0.7157219017055899, -1.6833663024686756}, {0.35997814792808075}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tt.config.LearningRate = 0.1
+
+			optimizer, err := nn.NewOptimizer(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.mask != nil {
+				optimizer.(nn.WeightDecayer).SetDecayMask(tt.mask)
+			}
+
+			params := [][]float64{{1, -2}, {0.5}}
+
+			for step, want := range tt.want {
+
+				if err := optimizer.Step(params, grads[step]); err != nil {
+					t.Fatal(err)
+				}
+
+				for i := range want {
+					for k := range want[i] {
+						if d := math.Abs(params[i][k] - want[i][k]); d > 1e-12 {
+							t.Errorf("step %d: param %d[%d] is %v, want %v", step+1, i, k, params[i][k], want[i][k])
+						}
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package neuralnetwork
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/ThakurMayank5/gonn/backend"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// workers returns the number of shards a mini-batch of batchSize samples is split into
+func (model *ModelOf[T]) workers(batchSize int) int {
+
+	workers := model.TrainingConfig.Workers
+
+	// Batch norm statistics are taken over the whole mini-batch, so it cannot be sharded
+	for _, layer := range model.NeuralNetwork.Layers {
+		if layer.Normalization == BatchNorm {
+			return 1
+		}
+	}
+
+	return max(1, min(workers, batchSize))
+}
+
+// batchGradients computes the gradients of the loss averaged over a
+// mini-batch and returns the workspace whose network holds them in its Grads.
+// With TrainingConfig.Workers > 1 the batch is split into contiguous shards
+// whose gradients are computed concurrently, each by its own copy of the
+// network, and summed in shard order, so the result does not depend on
+// goroutine scheduling. The gradients are overwritten by the next call.
+func (model *ModelOf[T]) batchGradients(batchInputs [][]T, batchTargets [][]T) (*workspace[T], error) {
+
+	batchSize := len(batchInputs)
+	scale := 1 / T(batchSize)
+
+	rng := model.random()
+	workers := model.workers(batchSize)
+
+	if workers == 1 {
+		ws := model.workspace(0)
+		if err := model.backward(ws, batchInputs, batchTargets, scale, rng); err != nil {
+			return nil, err
+		}
+		return ws, nil
+	}
+
+	// Each shard draws its dropout masks from its own source, seeded in
+	// shard order before any goroutine starts
+	shards := make([]*workspace[T], workers)
+	for s := range shards {
+		shards[s] = model.workspace(s)
+		if shards[s].rng == nil {
+			shards[s].rng = rand.New(rand.NewSource(rng.Int63()))
+		} else {
+			shards[s].rng.Seed(rng.Int63())
+		}
+	}
+
+	errs := make([]error, workers)
+
+	var wg sync.WaitGroup
+
+	for s := 0; s < workers; s++ {
+
+		start := s * batchSize / workers
+		end := (s + 1) * batchSize / workers
+
+		wg.Add(1)
+		go func(s, start, end int) {
+			defer wg.Done()
+			errs[s] = model.backward(shards[s], batchInputs[start:end], batchTargets[start:end], scale, shards[s].rng)
+		}(s, start, end)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Reduce in shard order
+	be := model.NeuralNetwork.backend()
+	for s := 1; s < workers; s++ {
+		if err := shards[0].dense.grads.add(be, &shards[s].dense.grads); err != nil {
+			return nil, err
+		}
+	}
+
+	return shards[0], nil
+}
+
+// add adds other to params, tensor by tensor. Nil tensors are skipped.
+func (params *ModelWeightsAndBiasesOf[T]) add(be backend.Backend[T], other *ModelWeightsAndBiasesOf[T]) error {
+
+	groups := [][2][]*vectors.Tensor[T]{
+		{params.Weights, other.Weights},
+		{params.Biases, other.Biases},
+		{params.Gammas, other.Gammas},
+		{params.Betas, other.Betas},
+	}
+
+	for _, group := range groups {
+		for l, t := range group[0] {
+			if t == nil || l >= len(group[1]) || group[1][l] == nil {
+				continue
+			}
+			if err := be.Add(t.Data, group[1][l].Data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -3,58 +3,99 @@ package neuralnetwork
 import (
 	"fmt"
 
-	"github.com/ThakurMayank5/gonn/activation"
-	vectors "github.com/ThakurMayank5/gonn/vectors"
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
 )
 
-func (nn *NeuralNetwork) Predict(input []float64) ([]float64, error) {
+// Predict runs inference on a single sample. Dropout is disabled and batch
+// normalization uses its running statistics. The layers keep the values of
+// the pass, so a network must not predict on several goroutines at once.
+func (nn *NeuralNetworkOf[T]) Predict(input []T) ([]T, error) {
 
-	weights := nn.WeightsAndBiases.Weights
-	biases := nn.WeightsAndBiases.Biases
+	if len(input) != nn.InputLayer.Neurons {
+		return nil, fmt.Errorf("input has %d values but the input layer has %d neurons", len(input), nn.InputLayer.Neurons)
+	}
 
-	x := input
+	net := nn.Network
 
-	// Iterate through each layer
-	for i := 0; i < len(weights); i++ {
-		{
+	if net == nil {
+		d, err := nn.predictor()
+		if err != nil {
+			return nil, err
+		}
+		net = layers.Layer[T](d.sequential)
+	}
 
-			// Default to ReLU for hidden layers
-			activationFunction := activation.ReLU
+	x, err := vectors.FromSlice(input, append([]int{1}, nn.inputShape()...)...)
+	if err != nil {
+		return nil, err
+	}
 
-			if i == len(weights)-1 {
-				activationFunction = nn.OutputLayer.ActivationFunction
-			} else {
-				activationFunction = nn.Layers[i].ActivationFunction
-			}
+	out, err := nn.networkForward(net, x, false)
+	if err != nil {
+		return nil, err
+	}
 
-			newX := make([]float64, len(biases[i])) // Initialized elements as 0
+	var cache forwardCache[T]
 
-			// Iterate through each neuron in the current layer
-			for j := range biases[i] {
-				currWeights := weights[i][j]
-				dotProduct, err := vectors.DotProduct(x, currWeights)
+	prediction, err := nn.output(&cache.tape, out, nil)
+	if err != nil {
+		return nil, err
+	}
 
-				if err != nil {
-					return nil, fmt.Errorf("error computing dot product: %v", err)
-				}
+	return append([]T(nil), prediction.Value.Data...), nil
+}
 
-				if activationFunction != activation.Softmax {
-					activationFunctionToUse := activation.GetActivationFunction(activationFunction)
-					newX[j] = activationFunctionToUse(dotProduct + biases[i][j])
-				} else {
-					newX[j] = dotProduct + biases[i][j] // Store pre-activation for softmax
-				}
+// predictionBatch is the number of samples predictEach runs through the
+// network at once
+const predictionBatch = 256
 
-			}
+// predictEach runs inputs through the network in batches and calls visit
+// with the index and the prediction of every sample, in order. A prediction
+// is only valid during its call.
+func (nn *NeuralNetworkOf[T]) predictEach(inputs [][]T, visit func(i int, prediction []T) error) error {
 
-			// Apply softmax if needed
-			if i == len(weights)-1 && activationFunction == activation.Softmax {
-				newX = activation.SoftmaxFunc(newX)
-			}
+	net := nn.Network
+
+	if net == nil {
+		d, err := nn.predictor()
+		if err != nil {
+			return err
+		}
+		net = layers.Layer[T](d.sequential)
+	}
+
+	var cache forwardCache[T]
+
+	for start := 0; start < len(inputs); start += predictionBatch {
+
+		end := min(start+predictionBatch, len(inputs))
+
+		x, err := nn.networkInput(cache.input, inputs[start:end])
+		if err != nil {
+			return err
+		}
+		cache.input = x
 
-			x = newX
+		out, err := nn.networkForward(net, x, false)
+		if err != nil {
+			return err
+		}
+
+		prediction, err := nn.output(&cache.tape, out, nil)
+		if err != nil {
+			return err
+		}
 
+		outputs := prediction.Value.Shape[1]
+
+		for i := start; i < end; i++ {
+			row := prediction.Value.Data[(i-start)*outputs : (i-start+1)*outputs]
+			if err := visit(i, row); err != nil {
+				return err
+			}
 		}
 	}
-	return x, nil
+
+	return nil
 }
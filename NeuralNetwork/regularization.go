@@ -0,0 +1,171 @@
+package neuralnetwork
+
+import (
+	"math"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Regularization holds the weight penalties and constraint of a trainable layer
+type Regularization struct {
+
+	// L1 and L2 add L1*sum(|w|) + L2*sum(w^2) to the loss
+	L1 float64
+	L2 float64
+
+	// RegularizeBiases applies L1 and L2 to the biases as well.
+	// Biases are excluded by default.
+	RegularizeBiases bool
+
+	// Constraint, if set, is enforced on each neuron's incoming weights after every update
+	Constraint Constraint
+}
+
+// Constraint projects the incoming weights of one neuron back into an allowed set
+type Constraint interface {
+	Apply(weights []float64)
+}
+
+// MaxNorm rescales a neuron's incoming weights whenever their L2 norm exceeds Value
+type MaxNorm struct {
+	Value float64
+}
+
+func (c MaxNorm) Apply(weights []float64) {
+	norm := l2Norm(weights)
+	if norm > c.Value {
+		scale := c.Value / norm
+		for k := range weights {
+			weights[k] *= scale
+		}
+	}
+}
+
+// UnitNorm rescales a neuron's incoming weights to an L2 norm of 1
+type UnitNorm struct{}
+
+func (UnitNorm) Apply(weights []float64) {
+	norm := l2Norm(weights)
+	if norm > 0 {
+		for k := range weights {
+			weights[k] /= norm
+		}
+	}
+}
+
+func l2Norm(x []float64) float64 {
+	sum := 0.0
+	for _, v := range x {
+		sum += v * v
+	}
+	return math.Sqrt(sum)
+}
+
+// regularization returns the regularization settings of trainable layer l
+func (nn *NeuralNetworkOf[T]) regularization(l int) Regularization {
+	if l >= len(nn.Layers) {
+		return nn.OutputLayer.Regularization
+	}
+	return nn.Layers[l].Regularization
+}
+
+// regularizationPenalty returns the L1 and L2 penalty of the whole network
+func (nn *NeuralNetworkOf[T]) regularizationPenalty() float64 {
+
+	penalty := 0.0
+
+	for l := range nn.WeightsAndBiases.Weights {
+
+		reg := nn.regularization(l)
+		if reg.L1 == 0 && reg.L2 == 0 {
+			continue
+		}
+
+		penalty += regularizationPenaltyOf(reg, nn.WeightsAndBiases.Weights[l].Data)
+
+		if reg.RegularizeBiases {
+			penalty += regularizationPenaltyOf(reg, nn.WeightsAndBiases.Biases[l].Data)
+		}
+	}
+
+	return penalty
+}
+
+// regularizationPenaltyOf returns the L1 and L2 penalty of values
+func regularizationPenaltyOf[T Float](reg Regularization, values []T) float64 {
+	penalty := 0.0
+	for _, v := range values {
+		w := float64(v)
+		penalty += reg.L1*math.Abs(w) + reg.L2*w*w
+	}
+	return penalty
+}
+
+// addRegularizationGradient adds the derivative of the penalty of values to grad
+func addRegularizationGradient[T Float](reg Regularization, values, grad []T) {
+	l1, l2 := T(reg.L1), T(reg.L2)
+	for k, v := range values {
+		var sign T
+		if v > 0 {
+			sign = 1.0
+		} else if v < 0 {
+			sign = -1.0
+		}
+		grad[k] += l1*sign + 2.0*l2*v
+	}
+}
+
+// addRegularizationGradients adds the penalty gradients of the current
+// parameters to the batch gradients of every regularized layer
+func (nn *NeuralNetworkOf[T]) addRegularizationGradients(grads *ModelWeightsAndBiasesOf[T]) {
+
+	for l := range nn.WeightsAndBiases.Weights {
+
+		reg := nn.regularization(l)
+		if reg.L1 == 0 && reg.L2 == 0 {
+			continue
+		}
+
+		addRegularizationGradient(reg, nn.WeightsAndBiases.Weights[l].Data, grads.Weights[l].Data)
+
+		if reg.RegularizeBiases {
+			addRegularizationGradient(reg, nn.WeightsAndBiases.Biases[l].Data, grads.Biases[l].Data)
+		}
+	}
+}
+
+// applyConstraints enforces each layer's weight constraint on weights.
+// Constraints work on float64 rows; other element types are converted
+// through scratch, which is returned grown to the widest row.
+func (nn *NeuralNetworkOf[T]) applyConstraints(weights []*vectors.Tensor[T], scratch []float64) []float64 {
+
+	for l := range weights {
+
+		constraint := nn.regularization(l).Constraint
+		if constraint == nil {
+			continue
+		}
+
+		for j := 0; j < weights[l].Shape[0]; j++ {
+			scratch = applyConstraint(constraint, weights[l].Row(j), scratch)
+		}
+	}
+
+	return scratch
+}
+
+// applyConstraint applies c to one row of weights, in place for float64 rows
+func applyConstraint[T Float](c Constraint, row []T, scratch []float64) []float64 {
+
+	if values, ok := any(&row).(*[]float64); ok {
+		c.Apply(*values)
+		return scratch
+	}
+
+	scratch = resize(scratch, len(row))
+	values := vectors.AsFloat64(row, scratch)
+	c.Apply(values)
+	vectors.Convert(row, values)
+
+	return scratch
+}
@@ -0,0 +1,222 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math"
+)
+
+// LearningRateSchedule decides the learning rate used for each epoch of Fit.
+// Epochs are counted from 1, as in Fit's progress output, and baseRate is
+// TrainingConfig.LearningRate.
+type LearningRateSchedule interface {
+	LearningRate(epoch int, baseRate float64) float64
+}
+
+// PlateauObserver is implemented by schedules that react to the validation
+// loss. Fit reports the loss after every epoch.
+type PlateauObserver interface {
+	ObserveValidationLoss(loss float64)
+}
+
+// LearningRateSetter is implemented by optimizers whose learning rate can be
+// changed between epochs. All built-in optimizers implement it.
+type LearningRateSetter interface {
+	SetLearningRate(rate float64)
+}
+
+func (o *momentumOptimizer[T]) SetLearningRate(rate float64) { o.learningRate = rate }
+func (o *rmspropOptimizer[T]) SetLearningRate(rate float64)  { o.learningRate = rate }
+func (o *adagradOptimizer[T]) SetLearningRate(rate float64)  { o.learningRate = rate }
+func (o *adamOptimizer[T]) SetLearningRate(rate float64)     { o.learningRate = rate }
+
+// applyLearningRate sets the scheduled rate for epoch on the optimizer and returns it
+func (model *ModelOf[T]) applyLearningRate(optimizer OptimizerOf[T], epoch int) (float64, error) {
+
+	rate := model.TrainingConfig.LearningRate
+
+	schedule := model.TrainingConfig.LearningRateSchedule
+	if schedule == nil {
+		return rate, nil
+	}
+
+	rate = schedule.LearningRate(epoch, rate)
+
+	setter, ok := optimizer.(LearningRateSetter)
+	if !ok {
+		return 0, fmt.Errorf("a learning rate schedule is configured but the optimizer does not implement SetLearningRate")
+	}
+	setter.SetLearningRate(rate)
+
+	return rate, nil
+}
+
+// StepDecay multiplies the rate by Gamma every StepSize epochs. A zero Gamma
+// uses 0.1 and a zero StepSize decays every epoch.
+type StepDecay struct {
+	StepSize int
+	Gamma    float64
+}
+
+func (s StepDecay) LearningRate(epoch int, baseRate float64) float64 {
+	stepSize := s.StepSize
+	if stepSize < 1 {
+		stepSize = 1
+	}
+	return baseRate * math.Pow(orDefault(s.Gamma, 0.1), float64((epoch-1)/stepSize))
+}
+
+// ExponentialDecay multiplies the rate by Gamma every epoch. A zero Gamma
+// uses 0.9.
+type ExponentialDecay struct {
+	Gamma float64
+}
+
+func (s ExponentialDecay) LearningRate(epoch int, baseRate float64) float64 {
+	return baseRate * math.Pow(orDefault(s.Gamma, 0.9), float64(epoch-1))
+}
+
+// CosineAnnealingWarmRestarts anneals the rate from the base rate down to
+// MinRate along a cosine curve over Period epochs, then restarts. Each new
+// period is PeriodMultiplier times longer than the previous one.
+type CosineAnnealingWarmRestarts struct {
+	Period           int
+	PeriodMultiplier int
+	MinRate          float64
+}
+
+func (s CosineAnnealingWarmRestarts) LearningRate(epoch int, baseRate float64) float64 {
+
+	period := s.Period
+	if period < 1 {
+		period = 1
+	}
+	multiplier := s.PeriodMultiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	// Find the position inside the current period
+	t := epoch - 1
+	for t >= period {
+		t -= period
+		period *= multiplier
+	}
+
+	cosine := (1.0 + math.Cos(math.Pi*float64(t)/float64(period))) / 2.0
+
+	return s.MinRate + (baseRate-s.MinRate)*cosine
+}
+
+// LinearWarmup ramps the rate linearly up to the base rate over WarmupEpochs,
+// then hands over to Next. Next sees epochs counted from the end of warmup.
+// A nil Next keeps the base rate.
+type LinearWarmup struct {
+	WarmupEpochs int
+	Next         LearningRateSchedule
+}
+
+func (s LinearWarmup) LearningRate(epoch int, baseRate float64) float64 {
+
+	if epoch <= s.WarmupEpochs {
+		return baseRate * float64(epoch) / float64(s.WarmupEpochs)
+	}
+
+	if s.Next == nil {
+		return baseRate
+	}
+
+	return s.Next.LearningRate(epoch-s.WarmupEpochs, baseRate)
+}
+
+func (s LinearWarmup) ObserveValidationLoss(loss float64) {
+	if observer, ok := s.Next.(PlateauObserver); ok {
+		observer.ObserveValidationLoss(loss)
+	}
+}
+
+// OneCycle implements the one-cycle policy: the rate rises from
+// MaxRate/DivFactor to MaxRate during the first PercentStart of TotalEpochs,
+// then anneals down to MaxRate/(DivFactor*FinalDivFactor). A zero MaxRate
+// uses the base rate as the peak.
+type OneCycle struct {
+	TotalEpochs    int
+	MaxRate        float64
+	PercentStart   float64
+	DivFactor      float64
+	FinalDivFactor float64
+}
+
+func (s OneCycle) LearningRate(epoch int, baseRate float64) float64 {
+
+	maxRate := orDefault(s.MaxRate, baseRate)
+	percentStart := orDefault(s.PercentStart, 0.3)
+	initialRate := maxRate / orDefault(s.DivFactor, 25.0)
+	finalRate := initialRate / orDefault(s.FinalDivFactor, 1e4)
+
+	total := s.TotalEpochs
+	if total < 2 {
+		return maxRate
+	}
+
+	// Progress through the cycle in [0, 1]
+	progress := float64(epoch-1) / float64(total-1)
+	if progress > 1 {
+		progress = 1
+	}
+
+	if progress <= percentStart {
+		return cosineInterpolate(initialRate, maxRate, progress/percentStart)
+	}
+
+	return cosineInterpolate(maxRate, finalRate, (progress-percentStart)/(1.0-percentStart))
+}
+
+// cosineInterpolate moves from start to end along half a cosine as t goes from 0 to 1
+func cosineInterpolate(start, end, t float64) float64 {
+	return end + (start-end)*(1.0+math.Cos(math.Pi*t))/2.0
+}
+
+// ReduceOnPlateau multiplies the rate by Factor once the validation loss has
+// not improved by more than MinDelta for Patience epochs. The rate never drops
+// below MinRate. Use a pointer, the schedule keeps state between epochs.
+type ReduceOnPlateau struct {
+	Factor   float64
+	Patience int
+	MinDelta float64
+	MinRate  float64
+
+	scale     float64
+	bestLoss  float64
+	badEpochs int
+	observed  bool
+}
+
+func (s *ReduceOnPlateau) LearningRate(epoch int, baseRate float64) float64 {
+
+	if s.scale == 0 {
+		s.scale = 1.0
+	}
+
+	return math.Max(baseRate*s.scale, s.MinRate)
+}
+
+func (s *ReduceOnPlateau) ObserveValidationLoss(loss float64) {
+
+	if s.scale == 0 {
+		s.scale = 1.0
+	}
+
+	if !s.observed || loss < s.bestLoss-s.MinDelta {
+		s.bestLoss = loss
+		s.badEpochs = 0
+		s.observed = true
+		return
+	}
+
+	s.badEpochs++
+
+	if s.badEpochs >= s.Patience {
+		s.scale *= orDefault(s.Factor, 0.1)
+		s.badEpochs = 0
+	}
+}
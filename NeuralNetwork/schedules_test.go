@@ -0,0 +1,123 @@
+package neuralnetwork_test
+
+import (
+	"math"
+	"testing"
+
+	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
+)
+
+func TestLearningRateSchedules(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		schedule nn.LearningRateSchedule
+		want     []float64 // the rate of epochs 1, 2, ... with a base rate of 1
+	}{
+		{
+			name:     "step decay",
+			schedule: nn.StepDecay{StepSize: 2, Gamma: 0.5},
+			want:     []float64{1, 1, 0.5, 0.5, 0.25},
+		},
+		{
+			name:     "step decay defaults",
+			schedule: nn.StepDecay{},
+			want:     []float64{1, 0.1, 0.01},
+		},
+		{
+			name:     "exponential decay",
+			schedule: nn.ExponentialDecay{Gamma: 0.5},
+			want:     []float64{1, 0.5, 0.25, 0.125},
+		},
+		{
+			name:     "exponential decay defaults",
+			schedule: nn.ExponentialDecay{},
+			want:     []float64{1, 0.9, 0.81},
+		},
+		{
+			name:     "cosine annealing with warm restarts",
+			schedule: nn.CosineAnnealingWarmRestarts{Period: 2, PeriodMultiplier: 2, MinRate: 0.2},
+			want:     []float64{1, 0.6, 1, 0.2 + 0.8*(1+math.Sqrt2/2)/2, 0.6, 0.2 + 0.8*(1-math.Sqrt2/2)/2, 1},
+		},
+		{
+			name:     "cosine annealing defaults",
+			schedule: nn.CosineAnnealingWarmRestarts{},
+			want:     []float64{1, 1, 1},
+		},
+		{
+			name:     "linear warmup",
+			schedule: nn.LinearWarmup{WarmupEpochs: 4},
+			want:     []float64{0.25, 0.5, 0.75, 1, 1},
+		},
+		{
+			name:     "linear warmup into step decay",
+			schedule: nn.LinearWarmup{WarmupEpochs: 2, Next: nn.StepDecay{StepSize: 1, Gamma: 0.5}},
+			want:     []float64{0.5, 1, 1, 0.5, 0.25},
+		},
+		{
+			name:     "one cycle",
+			schedule: nn.OneCycle{TotalEpochs: 5, MaxRate: 2, PercentStart: 0.25, DivFactor: 10, FinalDivFactor: 10},
+			want:     []float64{0.2, 2, 0.02 + 1.98*0.75, 0.02 + 1.98*0.25, 0.02, 0.02},
+		},
+		{
+			name:     "one cycle defaults",
+			schedule: nn.OneCycle{},
+			want:     []float64{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.schedule.LearningRate(i+1, 1); math.Abs(got-want) > 1e-12 {
+					t.Errorf("epoch %d: rate %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		schedule *nn.ReduceOnPlateau
+		losses   []float64
+		want     []float64 // the rate after observing each loss, with a base rate of 1
+	}{
+		{
+			name:     "patience",
+			schedule: &nn.ReduceOnPlateau{Factor: 0.5, Patience: 2},
+			losses:   []float64{1, 1, 1, 0.5, 0.6, 0.6},
+			want:     []float64{1, 1, 0.5, 0.5, 0.5, 0.25},
+		},
+		{
+			name:     "min delta and min rate",
+			schedule: &nn.ReduceOnPlateau{Factor: 0.5, Patience: 1, MinDelta: 0.1, MinRate: 0.3},
+			losses:   []float64{1, 0.95, 0.8, 0.75, 0.75},
+			want:     []float64{1, 0.5, 0.5, 0.3, 0.3},
+		},
+		{
+			name:     "defaults",
+			schedule: &nn.ReduceOnPlateau{},
+			losses:   []float64{1, 1, 1},
+			want:     []float64{1, 0.1, 0.01},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if got := tt.schedule.LearningRate(1, 1); got != 1 {
+				t.Fatalf("rate %v before any loss, want 1", got)
+			}
+
+			for i, loss := range tt.losses {
+				tt.schedule.ObserveValidationLoss(loss)
+				if got := tt.schedule.LearningRate(i+2, 1); math.Abs(got-tt.want[i]) > 1e-12 {
+					t.Errorf("after loss %d: rate %v, want %v", i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -7,25 +7,68 @@ import (
 	"time"
 )
 
-func (model *Model) Fit(training dataset.Dataset, validation dataset.Dataset) error {
+// random returns the model's random source, seeded from TrainingConfig.Seed
+// or from the clock when no seed is set
+func (model *ModelOf[T]) random() *rand.Rand {
+
+	if model.rng == nil {
+		seed := model.TrainingConfig.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		model.rng = rand.New(rand.NewSource(seed))
+	}
+
+	return model.rng
+}
 
-	// initialize a random seed for further use
-	rand.Seed(time.Now().UnixNano())
+// Fit trains the model on training for TrainingConfig.Epochs epochs, or
+// fewer with EarlyStopping, and reports how training ended
+func (model *ModelOf[T]) Fit(training dataset.DatasetOf[T], validation dataset.DatasetOf[T]) (FitResult, error) {
+
+	// initialize the random source used for shuffling and dropout
+	rng := model.random()
 
 	// Dataset validation
 
 	if len(training.Inputs) == 0 || len(training.Outputs) == 0 {
-		return fmt.Errorf("training dataset is empty")
+		return FitResult{}, fmt.Errorf("training dataset is empty")
 	}
 
 	if len(training.Inputs) != len(training.Outputs) {
-		return fmt.Errorf("number of inputs and outputs must be the same")
+		return FitResult{}, fmt.Errorf("number of inputs and outputs must be the same")
 	}
 
 	if len(training.Inputs[0]) != model.NeuralNetwork.InputLayer.Neurons {
-		return fmt.Errorf("input data does not match the number of neurons in the input layer")
+		return FitResult{}, fmt.Errorf("input data does not match the number of neurons in the input layer")
+	}
+
+	if training.Timesteps > 0 {
+		shape := model.NeuralNetwork.inputShape()
+		if len(shape) != 2 || shape[0] != training.Timesteps || shape[1] != training.NumFeatures {
+			return FitResult{}, fmt.Errorf("dataset holds [%d, %d] sequences but the input shape is %v", training.Timesteps, training.NumFeatures, shape)
+		}
+	}
+
+	for i, layer := range model.NeuralNetwork.Layers {
+		if layer.Dropout < 0 || layer.Dropout >= 1 {
+			return FitResult{}, fmt.Errorf("layer %d: dropout rate must be in [0, 1), got %v", i+1, layer.Dropout)
+		}
+	}
+
+	// Resolve the optimizer up front, it keeps its state across batches and epochs
+	optimizer, err := model.getOptimizer()
+	if err != nil {
+		return FitResult{}, err
+	}
+
+	monitor, err := model.newMonitor()
+	if err != nil {
+		return FitResult{}, err
 	}
 
+	result := FitResult{StopReason: Completed, Monitor: monitor.config.Monitor}
+
 	total_samples := len(training.Inputs)
 
 	epochs := model.TrainingConfig.Epochs
@@ -37,10 +80,15 @@ func (model *Model) Fit(training dataset.Dataset, validation dataset.Dataset) er
 
 	for epoch := 1; epoch <= epochs; epoch++ {
 
-		fmt.Printf("Epoch %d/%d\n", epoch, epochs)
+		learningRate, err := model.applyLearningRate(optimizer, epoch)
+		if err != nil {
+			return FitResult{}, err
+		}
+
+		fmt.Printf("Epoch %d/%d - Learning Rate: %.6g\n", epoch, epochs, learningRate)
 
 		// Shuffle the training data at the beginning of each epoch
-		shuffledIndices := rand.Perm(total_samples)
+		shuffledIndices := rng.Perm(total_samples)
 
 		for batch := 0; batch < batchesPerEpoch; batch++ {
 
@@ -51,8 +99,8 @@ func (model *Model) Fit(training dataset.Dataset, validation dataset.Dataset) er
 			}
 
 			// Create mini-batch
-			batchInputs := make([][]float64, end-start)
-			batchTargets := make([][]float64, end-start)
+			batchInputs := make([][]T, end-start)
+			batchTargets := make([][]T, end-start)
 
 			for i, idx := range shuffledIndices[start:end] {
 				batchInputs[i] = training.Inputs[idx]
@@ -62,7 +110,7 @@ func (model *Model) Fit(training dataset.Dataset, validation dataset.Dataset) er
 			// Backward Propagation with weight/bias updates for the entire batch
 			err := model.BackpropagateBatch(batchInputs, batchTargets)
 			if err != nil {
-				return err
+				return FitResult{}, err
 			}
 
 			// Show progress bar
@@ -73,13 +121,38 @@ func (model *Model) Fit(training dataset.Dataset, validation dataset.Dataset) er
 
 		validationLoss, err := model.ForwardPassBatch(validation.Inputs, validation.Outputs)
 		if err != nil {
-			return err
+			return FitResult{}, err
 		}
 		fmt.Printf("\nValidation Loss: %.4f\n", validationLoss)
 
+		if observer, ok := model.TrainingConfig.LearningRateSchedule.(PlateauObserver); ok {
+			observer.ObserveValidationLoss(validationLoss)
+		}
+
+		result.Epochs = epoch
+
+		value, err := monitor.value(model, validation, validationLoss)
+		if err != nil {
+			return FitResult{}, err
+		}
+
+		if monitor.observe(model, epoch, value) {
+			fmt.Printf("Early stopping: %s has not improved for %d epochs, best %.4f at epoch %d\n", monitor.config.Monitor, monitor.badEpochs, monitor.bestValue, monitor.bestEpoch)
+			result.StopReason = StoppedEarly
+			break
+		}
+
+	}
+
+	result.BestEpoch = monitor.bestEpoch
+	result.BestValue = monitor.bestValue
+	result.RestoredBestWeights = monitor.restore(model, result.Epochs)
+
+	if result.RestoredBestWeights {
+		fmt.Printf("Restored the weights of epoch %d\n", result.BestEpoch)
 	}
 
-	return nil
+	return result, nil
 
 }
 
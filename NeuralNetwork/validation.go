@@ -1,38 +1,40 @@
 package neuralnetwork
 
-import "github.com/ThakurMayank5/gonn/losses"
+import "github.com/ThakurMayank5/gonn/vectors"
 
-func (model *Model) ForwardPassBatch(batchInputs [][]float64, batchTargets [][]float64) (float64, error) {
+func (model *ModelOf[T]) ForwardPassBatch(batchInputs [][]T, batchTargets [][]T) (float64, error) {
+
+	lossFunction, err := model.lossFunction()
+	if err != nil {
+		return 0, err
+	}
 
 	batchLoss := 0.0
 
-	// iteration over mini-batch
-	for i := range batchInputs {
-		input := batchInputs[i]
-		target := batchTargets[i]
+	// Losses work in float64, float32 samples are converted into these rows
+	var prediction, targetValues []float64
 
-		output, err := model.NeuralNetwork.Predict(input)
-		if err != nil {
-			return 0, err
-		}
+	// The samples go through the network in batches
+	err = model.NeuralNetwork.predictEach(batchInputs, func(i int, output []T) error {
 
-		loss := 0.0
+		prediction = vectors.AsFloat64(output, prediction)
+		targetValues = vectors.AsFloat64(batchTargets[i], targetValues)
 
-		// Compute loss (use Cross-Entropy for Softmax, MSE for others)
-		if model.NeuralNetwork.OutputLayer.ActivationFunction == "softmax" {
-			loss, err = losses.CategoricalCrossEntropy(output, target)
-		} else {
-			loss, err = losses.MeanSquaredError(target, output)
-		}
+		loss, err := lossFunction.Value(prediction, targetValues)
 		if err != nil {
-			return 0, err
+			return err
 		}
 
 		batchLoss += loss
 
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	loss := batchLoss / float64(len(batchInputs))
+	// The L1 / L2 penalty is added once, on top of the average sample loss
+	loss := batchLoss/float64(len(batchInputs)) + model.NeuralNetwork.regularizationPenalty()
 
 	return loss, nil
 }
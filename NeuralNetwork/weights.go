@@ -2,15 +2,51 @@ package neuralnetwork
 
 import (
 	"encoding/gob"
+	"fmt"
 	"os"
+	"slices"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
 )
 
+// ModelParameters is the on-disk form of a model. Parameters are stored as
+// nested slices, so files written before tensors were introduced still load.
+// Values are always stored as float64, which holds float32 values exactly.
 type ModelParameters struct {
+
+	// DType is the precision of the saved model, "float32" or "float64".
+	// Files written before it was recorded are float64.
+	DType string
+
 	Weights [][][]float64
 	Biases  [][]float64
+
+	// Normalization parameters and running statistics, nil for layers without normalization
+	Gammas           [][]float64
+	Betas            [][]float64
+	RunningMeans     [][]float64
+	RunningVariances [][]float64
+
+	// Params and State hold the parameters and state of a Network, in the
+	// order its Params and State report them, nil for other models
+	Params [][]float64
+	State  [][]float64
+
+	// Topology records the nodes and edges of a Graph network, nil for
+	// other models. Loading checks it against the graph of the model.
+	Topology *layers.Topology
+
+	// Activations of the hidden layers followed by the output layer. Custom
+	// activations must be registered before the file is loaded.
+	Activations []activation.ActivationFunction
+
+	// Optimizer is the optimizer state at save time, nil if the optimizer keeps none
+	Optimizer *OptimizerState
 }
 
-func (model *Model) SaveWeights(path string) error {
+func (model *ModelOf[T]) SaveWeights(path string) error {
 
 	file, err := os.Create(path)
 	if err != nil {
@@ -20,15 +56,59 @@ func (model *Model) SaveWeights(path string) error {
 
 	encoder := gob.NewEncoder(file)
 
+	values := &model.NeuralNetwork.WeightsAndBiases
+
+	weights := make([][][]float64, len(values.Weights))
+	for l, rows := range values.WeightRows() {
+		weights[l] = float64Rows(rows)
+	}
+
 	params := ModelParameters{
-		Weights: model.NeuralNetwork.WeightsAndBiases.Weights,
-		Biases:  model.NeuralNetwork.WeightsAndBiases.Biases,
+		DType: model.DType(),
+
+		Weights: weights,
+		Biases:  float64Rows(values.BiasValues()),
+
+		Gammas:           tensorValues(values.Gammas),
+		Betas:            tensorValues(values.Betas),
+		RunningMeans:     tensorValues(values.RunningMeans),
+		RunningVariances: tensorValues(values.RunningVariances),
+	}
+
+	if network := model.NeuralNetwork.Network; network != nil {
+		params.Params = tensorValues(network.Params())
+		params.State = tensorValues(model.NeuralNetwork.networkState())
+		if graph, ok := network.(*layers.Graph[T]); ok {
+			topology := graph.Topology()
+			params.Topology = &topology
+		}
+	} else {
+		for _, layer := range model.NeuralNetwork.Layers {
+			params.Activations = append(params.Activations, layer.ActivationFunction)
+		}
+		params.Activations = append(params.Activations, model.NeuralNetwork.OutputLayer.ActivationFunction)
+	}
+
+	// A state loaded but not yet handed to an optimizer is saved as it was
+	state := model.loadedOptimizerState
+	if stateful, ok := model.optimizer.(StatefulOptimizerOf[T]); ok {
+		saved := stateful.State()
+		state = &saved
+	}
+
+	if state != nil {
+		params.Optimizer = &OptimizerState{Name: state.Name, Step: state.Step, Slots: make(map[string][][]float64, len(state.Slots))}
+		for name, slot := range state.Slots {
+			params.Optimizer.Slots[name] = float64Rows(slot)
+		}
 	}
 
 	return encoder.Encode(params)
 }
 
-func (model *Model) LoadWeights(path string) error {
+// LoadWeights restores a model written by SaveWeights. The file must have
+// been saved by a model of the same precision.
+func (model *ModelOf[T]) LoadWeights(path string) error {
 
 	file, err := os.Open(path)
 	if err != nil {
@@ -45,8 +125,310 @@ func (model *Model) LoadWeights(path string) error {
 		return err
 	}
 
-	model.NeuralNetwork.WeightsAndBiases.Weights = params.Weights
-	model.NeuralNetwork.WeightsAndBiases.Biases = params.Biases
+	dtype := params.DType
+	if dtype == "" {
+		dtype = "float64"
+	}
+
+	if dtype != model.DType() {
+		return fmt.Errorf("saved model is %s but the model is %s", dtype, model.DType())
+	}
+
+	if model.NeuralNetwork.Network != nil {
+		if err := model.restoreNetwork(&params); err != nil {
+			return err
+		}
+	} else if len(params.Params) > 0 || params.Topology != nil {
+		return fmt.Errorf("saved model was built from a Network but the model has none")
+	} else if err := model.NeuralNetwork.checkDenseParameters(&params); err != nil {
+		return err
+	}
+
+	if err := model.NeuralNetwork.restoreActivations(params.Activations); err != nil {
+		return err
+	}
+
+	weights := make([]*vectors.Tensor[T], len(params.Weights))
+	for l, rows := range params.Weights {
+		weights[l], err = vectors.FromRows(valueRows[T](rows))
+		if err != nil {
+			return fmt.Errorf("invalid weights for layer %d: %v", l+1, err)
+		}
+	}
+
+	values := &model.NeuralNetwork.WeightsAndBiases
+
+	values.Weights = weights
+	values.Biases = valueTensors[T](params.Biases)
+
+	values.Gammas = valueTensors[T](params.Gammas)
+	values.Betas = valueTensors[T](params.Betas)
+	values.RunningMeans = valueTensors[T](params.RunningMeans)
+	values.RunningVariances = valueTensors[T](params.RunningVariances)
+
+	// The optimizer is rebuilt from the restored state on the next training step
+	model.optimizer = nil
+	model.loadedOptimizerState = nil
+
+	if params.Optimizer != nil {
+		state := &OptimizerStateOf[T]{Name: params.Optimizer.Name, Step: params.Optimizer.Step, Slots: make(map[string][][]T, len(params.Optimizer.Slots))}
+		for name, slot := range params.Optimizer.Slots {
+			state.Slots[name] = valueRows[T](slot)
+		}
+		model.loadedOptimizerState = state
+	}
 
 	return nil
 }
+
+// restoreNetwork copies saved parameters and state into the tensors of
+// Network, building it first when it does not have them yet
+func (model *ModelOf[T]) restoreNetwork(params *ModelParameters) error {
+
+	nn := &model.NeuralNetwork
+
+	if len(params.Weights) > 0 {
+		return fmt.Errorf("saved model has dense layers but the model uses a Network")
+	}
+
+	if len(nn.Network.Params()) != len(params.Params) || len(nn.networkState()) != len(params.State) {
+		if err := model.buildNetwork(); err != nil {
+			return err
+		}
+	}
+
+	if params.Topology != nil {
+
+		graph, ok := nn.Network.(*layers.Graph[T])
+		if !ok {
+			return fmt.Errorf("saved model is a graph but the network is a %T", nn.Network)
+		}
+
+		if err := checkTopology(*params.Topology, graph.Topology()); err != nil {
+			return err
+		}
+	}
+
+	groups := []struct {
+		name    string
+		tensors []*vectors.Tensor[T]
+		saved   [][]float64
+	}{
+		{"parameter", nn.Network.Params(), params.Params},
+		{"state tensor", nn.networkState(), params.State},
+	}
+
+	for _, group := range groups {
+
+		if len(group.tensors) != len(group.saved) {
+			return fmt.Errorf("saved model has %d %ss but the network has %d", len(group.saved), group.name, len(group.tensors))
+		}
+
+		for i, t := range group.tensors {
+			if t.Len() != len(group.saved[i]) {
+				return fmt.Errorf("%s %d has %d saved values but the network's has %d", group.name, i+1, len(group.saved[i]), t.Len())
+			}
+			vectors.Convert(t.Data, group.saved[i])
+		}
+	}
+
+	return nil
+}
+
+// checkTopology returns an error describing the first difference between
+// the saved topology of a graph and the topology of the model's graph. Nodes
+// are numbered by their index, as in the Inputs and Outputs of a topology.
+func checkTopology(saved, current layers.Topology) error {
+
+	if len(saved.Nodes) != len(current.Nodes) {
+		return fmt.Errorf("saved graph has %d nodes but the network has %d", len(saved.Nodes), len(current.Nodes))
+	}
+
+	for i, s := range saved.Nodes {
+
+		c := current.Nodes[i]
+
+		if s.Layer != c.Layer || !slices.Equal(s.Inputs, c.Inputs) || !slices.Equal(s.Shape, c.Shape) || s.Params != c.Params {
+			return fmt.Errorf("saved graph node %d is %s of nodes %v with output shape %v and %d parameters, but the network's is %s of nodes %v with output shape %v and %d parameters",
+				i, s.Layer, s.Inputs, s.Shape, s.Params, c.Layer, c.Inputs, c.Shape, c.Params)
+		}
+	}
+
+	if !slices.Equal(saved.Outputs, current.Outputs) {
+		return fmt.Errorf("saved graph outputs nodes %v but the network outputs nodes %v", saved.Outputs, current.Outputs)
+	}
+
+	return nil
+}
+
+// checkDenseParameters returns an error when the saved dense layers do not
+// have the shapes Layers and OutputLayer define, or normalization parameters
+// other than the ones their Normalization needs
+func (nn *NeuralNetworkOf[T]) checkDenseParameters(params *ModelParameters) error {
+
+	trainable := len(nn.Layers) + 1
+
+	if len(params.Weights) != trainable || len(params.Biases) != trainable {
+		return fmt.Errorf("saved model has %d hidden layers but the network defines %d", len(params.Weights)-1, len(nn.Layers))
+	}
+
+	for l := range trainable {
+
+		inputs, neurons := fanIn(nn, l), fanOut(nn, l)
+
+		if len(params.Weights[l]) != neurons || len(params.Biases[l]) != neurons {
+			return fmt.Errorf("%s: saved model has %d neurons but the network defines %d", nn.layerName(l), len(params.Weights[l]), neurons)
+		}
+
+		for _, row := range params.Weights[l] {
+			if len(row) != inputs {
+				return fmt.Errorf("%s: saved model takes %d inputs but the network gives it %d", nn.layerName(l), len(row), inputs)
+			}
+		}
+
+		norm := nn.normalization(l)
+
+		groups := []struct {
+			name  string
+			saved [][]float64
+			want  bool
+		}{
+			{"gamma", params.Gammas, norm != NoNormalization},
+			{"beta", params.Betas, norm != NoNormalization},
+			{"running mean", params.RunningMeans, norm == BatchNorm},
+			{"running variance", params.RunningVariances, norm == BatchNorm},
+		}
+
+		for _, group := range groups {
+
+			var saved []float64
+			if l < len(group.saved) {
+				saved = group.saved[l]
+			}
+
+			if group.want && len(saved) != neurons {
+				return fmt.Errorf("%s: saved model has %d %s values but the layer has %d neurons", nn.layerName(l), len(saved), group.name, neurons)
+			}
+
+			if !group.want && len(saved) > 0 {
+				return fmt.Errorf("%s: saved model has %s values the layer does not use", nn.layerName(l), group.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// layerName names trainable layer l in errors
+func (nn *NeuralNetworkOf[T]) layerName(l int) string {
+	if l == len(nn.Layers) {
+		return "output layer"
+	}
+	return fmt.Sprintf("layer %d", l+1)
+}
+
+// restoreActivations checks that every saved activation can be resolved,
+// fills in the ones the network definition leaves empty and refuses the
+// ones it sets to another activation, which the weights were not trained for
+func (nn *NeuralNetworkOf[T]) restoreActivations(saved []activation.ActivationFunction) error {
+
+	// Files written before activations were recorded
+	if len(saved) == 0 {
+		return nil
+	}
+
+	for _, name := range saved {
+		if name != "" && !activation.IsRegistered(name) {
+			return fmt.Errorf("saved model uses activation %q, which is not registered", name)
+		}
+	}
+
+	if len(saved) != len(nn.Layers)+1 {
+		return fmt.Errorf("saved model has %d hidden layers but the network defines %d", len(saved)-1, len(nn.Layers))
+	}
+
+	configured := make([]*activation.ActivationFunction, 0, len(saved))
+	for i := range nn.Layers {
+		configured = append(configured, &nn.Layers[i].ActivationFunction)
+	}
+	configured = append(configured, &nn.OutputLayer.ActivationFunction)
+
+	for i, name := range configured {
+		if *name != "" && saved[i] != "" && *name != saved[i] {
+			return fmt.Errorf("%s: saved model uses activation %q but the network defines %q", nn.layerName(i), saved[i], *name)
+		}
+	}
+
+	for i, name := range configured {
+		if *name == "" {
+			*name = saved[i]
+		}
+	}
+
+	return nil
+}
+
+// tensorValues returns the data of each 1D tensor as float64, nil for missing tensors
+func tensorValues[T Float](tensors []*vectors.Tensor[T]) [][]float64 {
+	if tensors == nil {
+		return nil
+	}
+	values := make([][]float64, len(tensors))
+	for l, t := range tensors {
+		if t != nil {
+			values[l] = vectors.AsFloat64(t.Data, nil)
+		}
+	}
+	return values
+}
+
+// valueTensors wraps each slice in a 1D tensor, empty slices become nil tensors
+func valueTensors[T Float](values [][]float64) []*vectors.Tensor[T] {
+	if values == nil {
+		return nil
+	}
+	tensors := make([]*vectors.Tensor[T], len(values))
+	for l, v := range values {
+		if len(v) > 0 {
+			tensors[l], _ = vectors.FromSlice(valueRow[T](v), len(v))
+		}
+	}
+	return tensors
+}
+
+// float64Rows returns rows as float64, sharing their memory when T is float64
+func float64Rows[T Float](rows [][]T) [][]float64 {
+	if rows == nil {
+		return nil
+	}
+	out := make([][]float64, len(rows))
+	for i, row := range rows {
+		if row != nil {
+			out[i] = vectors.AsFloat64(row, nil)
+		}
+	}
+	return out
+}
+
+// valueRows converts saved float64 rows to T, sharing their memory when T is float64
+func valueRows[T Float](rows [][]float64) [][]T {
+	if rows == nil {
+		return nil
+	}
+	out := make([][]T, len(rows))
+	for i, row := range rows {
+		if row != nil {
+			out[i] = valueRow[T](row)
+		}
+	}
+	return out
+}
+
+func valueRow[T Float](row []float64) []T {
+	if values, ok := any(&row).(*[]T); ok {
+		return *values
+	}
+	values := make([]T, len(row))
+	vectors.Convert(values, row)
+	return values
+}
@@ -0,0 +1,354 @@
+package neuralnetwork_test
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/dataset"
+	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+func TestLoadWeightsActivations(t *testing.T) {
+
+	model := newTestModel(t,
+		[]nn.Layer{{Neurons: 6, ActivationFunction: activation.Sigmoid}},
+		nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax},
+		nn.TrainingConfig{},
+	)
+
+	path := filepath.Join(t.TempDir(), "model.gob")
+	if err := model.SaveWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	x, _ := testBatch(1, 8, 4)
+
+	want, err := model.NeuralNetwork.Predict(x[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(hidden, output activation.ActivationFunction) (*nn.Model, error) {
+		loaded := &nn.Model{NeuralNetwork: nn.NeuralNetwork{
+			InputLayer:  nn.InputLayer{Neurons: 8},
+			Layers:      []nn.Layer{{Neurons: 6, ActivationFunction: hidden}},
+			OutputLayer: nn.OutputLayer{Neurons: 4, ActivationFunction: output},
+		}}
+		return loaded, loaded.LoadWeights(path)
+	}
+
+	// Activations left empty are filled in from the file
+	loaded, err := load("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := loaded.NeuralNetwork.Layers[0].ActivationFunction; got != activation.Sigmoid {
+		t.Errorf("hidden activation is %q after loading, want %q", got, activation.Sigmoid)
+	}
+
+	got, err := loaded.NeuralNetwork.Predict(x[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("loaded model predicts %v, want %v", got, want)
+	}
+
+	// Activations set to something else are refused
+	if _, err := load(activation.ReLU, ""); err == nil {
+		t.Errorf("a sigmoid layer loaded into a relu layer")
+	}
+
+	if _, err := load(activation.Sigmoid, activation.Sigmoid); err == nil {
+		t.Errorf("a softmax output loaded into a sigmoid output")
+	}
+
+	if _, err := load(activation.Sigmoid, activation.Softmax); err != nil {
+		t.Errorf("matching activations were refused: %v", err)
+	}
+}
+
+func TestLoadWeightsShapes(t *testing.T) {
+
+	hidden := []nn.Layer{{Neurons: 6, ActivationFunction: activation.ReLU, Normalization: nn.BatchNorm}}
+	output := nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax}
+
+	model := newTestModel(t, hidden, output, nn.TrainingConfig{})
+
+	path := filepath.Join(t.TempDir(), "model.gob")
+	if err := model.SaveWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		inputs int
+		hidden []nn.Layer
+		output nn.OutputLayer
+		ok     bool
+	}{
+		{"same layers", 8, hidden, output, true},
+		{"more hidden neurons", 8, []nn.Layer{{Neurons: 10, Normalization: nn.BatchNorm}}, output, false},
+		{"more inputs", 9, hidden, output, false},
+		{"more outputs", 8, hidden, nn.OutputLayer{Neurons: 5}, false},
+		{"another hidden layer", 8, append(slices.Clone(hidden), nn.Layer{Neurons: 6}), output, false},
+		{"layer norm", 8, []nn.Layer{{Neurons: 6, Normalization: nn.LayerNorm}}, output, false},
+		{"no normalization", 8, []nn.Layer{{Neurons: 6}}, output, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			loaded := &nn.Model{NeuralNetwork: nn.NeuralNetwork{
+				InputLayer:  nn.InputLayer{Neurons: tt.inputs},
+				Layers:      tt.hidden,
+				OutputLayer: tt.output,
+			}}
+
+			err := loaded.LoadWeights(path)
+			if tt.ok && err != nil {
+				t.Errorf("loading into the same layers failed: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("weights loaded into layers of other shapes")
+			}
+		})
+	}
+}
+
+func TestFloat32Model(t *testing.T) {
+
+	hidden := []nn.Layer{{Neurons: 8, ActivationFunction: activation.Tanh, Normalization: nn.LayerNorm}}
+	output := nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax}
+	config := nn.TrainingConfig{Epochs: 5, BatchSize: 16, LearningRate: 0.01, Optimizer: nn.Adam, Seed: 1}
+
+	reference := newTestModel(t, hidden, output, config)
+
+	model := &nn.ModelOf[float32]{
+		NeuralNetwork: nn.NeuralNetworkOf[float32]{
+			InputLayer:  nn.InputLayer{Neurons: 8},
+			Layers:      hidden,
+			OutputLayer: output,
+		},
+		TrainingConfig: nn.TrainingConfigOf[float32]{Epochs: 5, BatchSize: 16, LearningRate: 0.01, Optimizer: nn.Adam, Seed: 1},
+	}
+
+	if err := model.InitializeWeights(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both start from the float64 model's parameters, rounded to float32
+	want, got := &reference.NeuralNetwork.WeightsAndBiases, &model.NeuralNetwork.WeightsAndBiases
+	for _, group := range []struct {
+		from []*vectors.Tensor[float64]
+		to   []*vectors.Tensor[float32]
+	}{{want.Weights, got.Weights}, {want.Biases, got.Biases}, {want.Gammas, got.Gammas}, {want.Betas, got.Betas}} {
+		for l, values := range group.from {
+			if values != nil {
+				vectors.Convert(group.to[l].Data, values.Data)
+			}
+		}
+	}
+
+	training64, _ := diverging()
+	training := dataset.Convert[float32](training64)
+
+	if _, err := reference.Fit(training64, training64); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := model.Fit(training, training); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same steps in single precision end close to the float64 model
+	loss64, err := reference.ForwardPassBatch(training64.Inputs, training64.Outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loss, err := model.ForwardPassBatch(training.Inputs, training.Outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(loss-loss64) > 1e-3*loss64 {
+		t.Errorf("float32 model ends with loss %g, float64 model with %g", loss, loss64)
+	}
+
+	prediction, err := model.NeuralNetwork.Predict(training.Inputs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saved weights load back into a float32 model only
+	path := filepath.Join(t.TempDir(), "model.gob")
+	if err := model.SaveWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &nn.ModelOf[float32]{NeuralNetwork: nn.NeuralNetworkOf[float32]{InputLayer: nn.InputLayer{Neurons: 8}, Layers: hidden, OutputLayer: output}}
+	if err := loaded.LoadWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.DType() != "float32" {
+		t.Errorf("loaded model is %s, want float32", loaded.DType())
+	}
+
+	restored, err := loaded.NeuralNetwork.Predict(training.Inputs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(restored, prediction) {
+		t.Errorf("loaded model predicts %v, want %v", restored, prediction)
+	}
+
+	other := &nn.Model{NeuralNetwork: nn.NeuralNetwork{InputLayer: nn.InputLayer{Neurons: 8}, Layers: hidden, OutputLayer: output}}
+	if err := other.LoadWeights(path); err == nil {
+		t.Errorf("a float32 file loaded into a float64 model")
+	}
+
+	// And the other way round
+	path64 := filepath.Join(t.TempDir(), "model64.gob")
+	if err := reference.SaveWeights(path64); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loaded.LoadWeights(path64); err == nil {
+		t.Errorf("a float64 file loaded into a float32 model")
+	}
+}
+
+func TestSaveLoadedOptimizerState(t *testing.T) {
+
+	hidden := []nn.Layer{{Neurons: 6, ActivationFunction: activation.Tanh}}
+	output := nn.OutputLayer{Neurons: 4, ActivationFunction: activation.Softmax}
+	config := nn.TrainingConfig{LearningRate: 0.01, Optimizer: nn.Adam}
+
+	model := newTestModel(t, hidden, output, config)
+	x, y := testBatch(8, 8, 4)
+
+	for range 2 {
+		if err := model.BackpropagateBatch(x, y); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+
+	// Saving a model that was loaded but not trained since keeps the state
+	path := filepath.Join(dir, "model.gob")
+	if err := model.SaveWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded *nn.Model
+	paths := []string{path}
+
+	for i := range 2 {
+
+		loaded = &nn.Model{
+			NeuralNetwork:  nn.NeuralNetwork{InputLayer: nn.InputLayer{Neurons: 8}, Layers: hidden, OutputLayer: output},
+			TrainingConfig: config,
+		}
+		if err := loaded.LoadWeights(paths[i]); err != nil {
+			t.Fatal(err)
+		}
+
+		paths = append(paths, filepath.Join(dir, fmt.Sprintf("resaved%d.gob", i)))
+		if err := loaded.SaveWeights(paths[i+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := readParameters(t, paths[0]).Optimizer
+	if want == nil || want.Step != 2 {
+		t.Fatal("saved optimizer state is not the state after 2 steps")
+	}
+
+	for _, path := range paths[1:] {
+		if got := readParameters(t, path).Optimizer; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: optimizer state differs from the one first saved", filepath.Base(path))
+		}
+	}
+
+	// The loaded model takes the next step exactly as the original does
+	if err := model.BackpropagateBatch(x, y); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.BackpropagateBatch(x, y); err != nil {
+		t.Fatal(err)
+	}
+
+	for l, w := range model.NeuralNetwork.WeightsAndBiases.Weights {
+		if !slices.Equal(loaded.NeuralNetwork.WeightsAndBiases.Weights[l].Data, w.Data) {
+			t.Errorf("layer %d: weights differ from the original model's after resuming", l+1)
+		}
+	}
+}
+
+func TestOptimizerStateShapes(t *testing.T) {
+
+	params := [][]float64{{1, 2, 3}, {4}}
+	grads := [][]float64{{0.1, 0.2, 0.3}, {0.4}}
+
+	for _, name := range []nn.OptimizerName{nn.Momentum, nn.RMSprop, nn.Adagrad, nn.Adam} {
+		t.Run(string(name), func(t *testing.T) {
+
+			optimizer, err := nn.NewOptimizer(nn.TrainingConfig{LearningRate: 0.1, Optimizer: name})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stateful := optimizer.(nn.StatefulOptimizer)
+
+			if err := optimizer.Step(params, grads); err != nil {
+				t.Fatal(err)
+			}
+
+			// Every slot loses a value of its first parameter
+			state := stateful.State()
+			for slot, rows := range state.Slots {
+				state.Slots[slot] = [][]float64{rows[0][:2], rows[1]}
+			}
+
+			if err := stateful.SetState(state); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := optimizer.Step(params, grads); err == nil {
+				t.Errorf("a step with state of the wrong shape succeeded")
+			}
+		})
+	}
+}
+
+// readParameters decodes the file written by SaveWeights at path
+func readParameters(t *testing.T, path string) nn.ModelParameters {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var params nn.ModelParameters
+	if err := gob.NewDecoder(file).Decode(&params); err != nil {
+		t.Fatal(err)
+	}
+
+	return params
+}
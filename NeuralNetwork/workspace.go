@@ -0,0 +1,36 @@
+package neuralnetwork
+
+import (
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// workspace holds the buffers of one training shard. They are sized on the
+// first step and reused by every later one, so steady-state training steps
+// do not allocate.
+type workspace[T Float] struct {
+	cache forwardCache[T]
+
+	// net is the network the shard trained last: NeuralNetwork.Network, or
+	// dense, the shard's own copy of the network of Layers and OutputLayer
+	net   layers.Layer[T]
+	dense *denseNetwork[T]
+
+	// outputGrad receives the gradient of the loss with respect to the output of net
+	outputGrad *vectors.Tensor[T]
+
+	// rng draws the dropout masks of the shard when the batch is sharded
+	rng *rand.Rand
+}
+
+// workspace returns the buffers of shard s, allocating them on first use
+func (model *ModelOf[T]) workspace(s int) *workspace[T] {
+
+	for len(model.workspaces) <= s {
+		model.workspaces = append(model.workspaces, &workspace[T]{})
+	}
+
+	return model.workspaces[s]
+}
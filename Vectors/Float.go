@@ -0,0 +1,40 @@
+package vectors
+
+// Float is the set of element types tensors and models can be built on
+type Float interface {
+	~float32 | ~float64
+}
+
+// DType returns the name of T, "float32" or "float64"
+func DType[T Float]() string {
+	var zero T
+	if _, ok := any(zero).(float32); ok {
+		return "float32"
+	}
+	return "float64"
+}
+
+// AsFloat64 returns x as a []float64. When T is float64 that is x itself,
+// otherwise x is converted into scratch, or into a new slice when scratch
+// holds fewer than len(x) values.
+func AsFloat64[T Float](x []T, scratch []float64) []float64 {
+	if p, ok := any(&x).(*[]float64); ok {
+		return *p
+	}
+	if cap(scratch) < len(x) {
+		scratch = make([]float64, len(x))
+	}
+	scratch = scratch[:len(x)]
+	for i, v := range x {
+		scratch[i] = float64(v)
+	}
+	return scratch
+}
+
+// Convert copies src into dst, converting each element. len(dst) must be at least len(src).
+func Convert[T, U Float](dst []T, src []U) {
+	dst = dst[:len(src)]
+	for i, v := range src {
+		dst[i] = T(v)
+	}
+}
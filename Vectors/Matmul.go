@@ -0,0 +1,318 @@
+package vectors
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Block sizes of the matmul kernels. A block of B (blockK x blockN values)
+// stays in L2 cache while every row of A in a chunk streams over it.
+const (
+	blockK = 128
+	blockN = 256
+
+	// parallelThreshold is the number of multiply-adds below which a
+	// product is computed on the calling goroutine
+	parallelThreshold = 1 << 16
+)
+
+// MaxProcs caps the number of goroutines one Gemm call may use.
+// Zero uses runtime.GOMAXPROCS.
+var MaxProcs = 0
+
+// Gemm computes c = alpha * op(a) * op(b) + beta * c for 2D tensors, where op
+// transposes its argument when the matching trans flag is set. With beta 0,
+// c is overwritten and its previous contents are ignored.
+//
+// Rows of c are split across goroutines for large products. Each element of
+// c is always summed by a single goroutine in the same order, so the result
+// does not depend on the number of goroutines.
+func Gemm[T Float](transA, transB bool, alpha T, a, b *Tensor[T], beta T, c *Tensor[T]) error {
+
+	if a.Dims() != 2 || b.Dims() != 2 || c.Dims() != 2 {
+		return fmt.Errorf("gemm needs 2D tensors, got shapes %v, %v and %v", a.Shape, b.Shape, c.Shape)
+	}
+
+	m, k := a.Shape[0], a.Shape[1]
+	if transA {
+		m, k = k, m
+	}
+
+	kb, n := b.Shape[0], b.Shape[1]
+	if transB {
+		kb, n = n, kb
+	}
+
+	if k != kb || c.Shape[0] != m || c.Shape[1] != n {
+		return fmt.Errorf("gemm shape mismatch: op(a) is %dx%d, op(b) is %dx%d, c is %v", m, k, kb, n, c.Shape)
+	}
+
+	g := gemm[T]{
+		transA: transA, transB: transB,
+		alpha: alpha,
+		a:     a.Data, b: b.Data, c: c.Data,
+		lda: a.Shape[1], ldb: b.Shape[1],
+		k: k, n: n,
+	}
+
+	// Scale (or clear) c once, the kernels only accumulate into it
+	if beta == 0 {
+		for i := range c.Data {
+			c.Data[i] = 0
+		}
+	} else if beta != 1 {
+		for i := range c.Data {
+			c.Data[i] *= beta
+		}
+	}
+
+	if m == 0 || n == 0 || k == 0 || alpha == 0 {
+		return nil
+	}
+
+	// Small products are not worth the goroutines
+	workers := min(procs(), m, max(1, m*n*k/parallelThreshold))
+
+	if workers <= 1 {
+		g.rows(0, m)
+		return nil
+	}
+
+	g.parallel(m, workers)
+
+	return nil
+}
+
+// MatMul returns a * b for 2D tensors
+func MatMul[T Float](a, b *Tensor[T]) (*Tensor[T], error) {
+	if a.Dims() != 2 || b.Dims() != 2 {
+		return nil, fmt.Errorf("matmul needs 2D tensors, got shapes %v and %v", a.Shape, b.Shape)
+	}
+	c := NewTensor[T](a.Shape[0], b.Shape[1])
+	if err := Gemm(false, false, 1, a, b, 0, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func procs() int {
+	if MaxProcs > 0 {
+		return MaxProcs
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// gemm holds the operands of one product, with lda and ldb the row lengths of a and b as stored
+type gemm[T Float] struct {
+	transA, transB bool
+	alpha          T
+	a, b, c        []T
+	lda, ldb       int
+	k, n           int
+}
+
+// rows accumulates rows [start, end) of c
+func (g *gemm[T]) rows(start, end int) {
+	switch {
+	case !g.transB:
+		g.axpyKernel(start, end)
+	case !g.transA:
+		g.dotKernel(start, end)
+	default:
+		g.stridedKernel(start, end)
+	}
+}
+
+// parallel splits the m rows of c into one chunk per worker. It takes g by
+// value so that only this path, not the serial one, moves it to the heap.
+func (g gemm[T]) parallel(m, workers int) {
+
+	var wg sync.WaitGroup
+	chunk := (m + workers - 1) / workers
+
+	for start := 0; start < m; start += chunk {
+		end := min(start+chunk, m)
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			g.rows(start, end)
+		}(start, end)
+	}
+
+	wg.Wait()
+}
+
+// at returns op(a)[i][p]
+func (g *gemm[T]) at(i, p int) T {
+	if g.transA {
+		return g.a[p*g.lda+i]
+	}
+	return g.a[i*g.lda+p]
+}
+
+// axpyKernel handles an untransposed b: each row of c accumulates
+// op(a)[i][p] times row p of b, reading b row by row
+func (g *gemm[T]) axpyKernel(start, end int) {
+
+	for p0 := 0; p0 < g.k; p0 += blockK {
+		p1 := min(p0+blockK, g.k)
+
+		for j0 := 0; j0 < g.n; j0 += blockN {
+			j1 := min(j0+blockN, g.n)
+
+			for i := start; i < end; i++ {
+				cRow := g.c[i*g.n+j0 : i*g.n+j1]
+
+				for p := p0; p < p1; p++ {
+					aip := g.alpha * g.at(i, p)
+					if aip == 0 {
+						continue
+					}
+					axpy(aip, g.b[p*g.ldb+j0:p*g.ldb+j1], cRow)
+				}
+			}
+		}
+	}
+}
+
+// dotKernel handles a transposed b with an untransposed a: each element of c
+// accumulates the dot product of row i of a with row j of b. Tiles of 2 rows
+// of a by 4 rows of b are summed together, which keeps the 8 sums in
+// registers and uses every value loaded more than once. Every element is
+// summed in the same order whichever tile holds it, so the result does not
+// depend on how the rows are split.
+func (g *gemm[T]) dotKernel(start, end int) {
+
+	for p0 := 0; p0 < g.k; p0 += blockK {
+		p1 := min(p0+blockK, g.k)
+
+		for j0 := 0; j0 < g.n; j0 += blockN / 4 {
+			j1 := min(j0+blockN/4, g.n)
+
+			i := start
+			for ; i+2 <= end; i += 2 {
+				j := j0
+				for ; j+4 <= j1; j += 4 {
+					g.dotTile(i, j, p0, p1)
+				}
+				for ; j < j1; j++ {
+					g.dotOne(i, j, p0, p1)
+					g.dotOne(i+1, j, p0, p1)
+				}
+			}
+
+			for ; i < end; i++ {
+				for j := j0; j < j1; j++ {
+					g.dotOne(i, j, p0, p1)
+				}
+			}
+		}
+	}
+}
+
+// dotTile accumulates the 2x4 block of c at rows i, columns j, over columns
+// [p0, p1) of a and b
+func (g *gemm[T]) dotTile(i, j, p0, p1 int) {
+
+	kb := p1 - p0
+
+	a0 := g.a[i*g.lda+p0:][:kb]
+	a1 := g.a[(i+1)*g.lda+p0:][:kb]
+
+	b0 := g.b[j*g.ldb+p0:][:kb]
+	b1 := g.b[(j+1)*g.ldb+p0:][:kb]
+	b2 := g.b[(j+2)*g.ldb+p0:][:kb]
+	b3 := g.b[(j+3)*g.ldb+p0:][:kb]
+
+	var c00, c01, c02, c03 T
+	var c10, c11, c12, c13 T
+
+	for p := range kb {
+		x0, x1 := a0[p], a1[p]
+		y0, y1, y2, y3 := b0[p], b1[p], b2[p], b3[p]
+
+		c00 += x0 * y0
+		c01 += x0 * y1
+		c02 += x0 * y2
+		c03 += x0 * y3
+		c10 += x1 * y0
+		c11 += x1 * y1
+		c12 += x1 * y2
+		c13 += x1 * y3
+	}
+
+	c := g.c[i*g.n+j:][:4]
+	c[0] += g.alpha * c00
+	c[1] += g.alpha * c01
+	c[2] += g.alpha * c02
+	c[3] += g.alpha * c03
+
+	c = g.c[(i+1)*g.n+j:][:4]
+	c[0] += g.alpha * c10
+	c[1] += g.alpha * c11
+	c[2] += g.alpha * c12
+	c[3] += g.alpha * c13
+}
+
+// dotOne accumulates element i, j of c over columns [p0, p1) of a and b,
+// summing in the order of dotTile
+func (g *gemm[T]) dotOne(i, j, p0, p1 int) {
+
+	kb := p1 - p0
+
+	x := g.a[i*g.lda+p0:][:kb]
+	y := g.b[j*g.ldb+p0:][:kb]
+
+	var sum T
+	for p := range kb {
+		sum += x[p] * y[p]
+	}
+
+	g.c[i*g.n+j] += g.alpha * sum
+}
+
+// stridedKernel handles both operands transposed
+func (g *gemm[T]) stridedKernel(start, end int) {
+	for i := start; i < end; i++ {
+		for j := 0; j < g.n; j++ {
+			var sum T
+			for p := 0; p < g.k; p++ {
+				sum += g.a[p*g.lda+i] * g.b[j*g.ldb+p]
+			}
+			g.c[i*g.n+j] += g.alpha * sum
+		}
+	}
+}
+
+// axpy adds alpha * x to y, len(x) == len(y)
+func axpy[T Float](alpha T, x, y []T) {
+	y = y[:len(x)]
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		y[i] += alpha * x[i]
+		y[i+1] += alpha * x[i+1]
+		y[i+2] += alpha * x[i+2]
+		y[i+3] += alpha * x[i+3]
+	}
+	for ; i < len(x); i++ {
+		y[i] += alpha * x[i]
+	}
+}
+
+// dot returns the dot product of x and y, len(x) == len(y)
+func dot[T Float](x, y []T) T {
+	y = y[:len(x)]
+	var s0, s1, s2, s3 T
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		s0 += x[i] * y[i]
+		s1 += x[i+1] * y[i+1]
+		s2 += x[i+2] * y[i+2]
+		s3 += x[i+3] * y[i+3]
+	}
+	for ; i < len(x); i++ {
+		s0 += x[i] * y[i]
+	}
+	return (s0 + s1) + (s2 + s3)
+}
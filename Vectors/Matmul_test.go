@@ -0,0 +1,95 @@
+package vectors_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// randomTensor returns a tensor of the given shape with values drawn from rng
+func randomTensor(rng *rand.Rand, shape ...int) *vectors.Tensor[float64] {
+	t := vectors.NewTensor[float64](shape...)
+	for i := range t.Data {
+		t.Data[i] = rng.NormFloat64()
+	}
+	return t
+}
+
+// TestGemmRowsIndependent checks that every row of a product comes out the
+// same whether it is computed with the rest of the batch or on its own, so a
+// batched prediction equals the per-sample ones
+func TestGemmRowsIndependent(t *testing.T) {
+
+	defer func(procs int) { vectors.MaxProcs = procs }(vectors.MaxProcs)
+	vectors.MaxProcs = 3
+
+	rng := rand.New(rand.NewSource(1))
+
+	// Sizes off the tile and block sizes, and large enough to be split
+	for _, shape := range [][3]int{{1, 5, 3}, {7, 130, 9}, {67, 300, 35}} {
+
+		m, k, n := shape[0], shape[1], shape[2]
+
+		x := randomTensor(rng, m, k)
+		w := randomTensor(rng, n, k)
+
+		batched := vectors.NewTensor[float64](m, n)
+		if err := vectors.Gemm(false, true, 0.5, x, w, 0, batched); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range m {
+
+			row, err := vectors.FromSlice(x.Row(i), 1, k)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			single := vectors.NewTensor[float64](1, n)
+			if err := vectors.Gemm(false, true, 0.5, row, w, 0, single); err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(single.Data, batched.Row(i)) {
+				t.Fatalf("%dx%dx%d: row %d differs between the batched and the single-row product", m, k, n, i)
+			}
+		}
+	}
+}
+
+// BenchmarkGemm times the first hidden layer of the Fashion-MNIST MLP, a
+// batch of 128 samples of 784 inputs through 256 neurons, as one batched
+// product and as the dot product of every sample with every neuron's weights
+func BenchmarkGemm(b *testing.B) {
+
+	const batch, inputs, neurons = 128, 784, 256
+
+	rng := rand.New(rand.NewSource(1))
+	x := randomTensor(rng, batch, inputs)
+	w := randomTensor(rng, neurons, inputs)
+	out := vectors.NewTensor[float64](batch, neurons)
+
+	b.Run("batched", func(b *testing.B) {
+		for b.Loop() {
+			if err := vectors.Gemm(false, true, 1, x, w, 0, out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("per-sample", func(b *testing.B) {
+		for b.Loop() {
+			for i := range batch {
+				for j := range neurons {
+					v, err := vectors.DotProduct(x.Row(i), w.Row(j))
+					if err != nil {
+						b.Fatal(err)
+					}
+					out.Data[i*neurons+j] = v
+				}
+			}
+		}
+	})
+}
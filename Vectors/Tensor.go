@@ -0,0 +1,180 @@
+package vectors
+
+import "fmt"
+
+// Tensor is a dense n-dimensional array backed by a single flat slice.
+// Element (i0, i1, ...) lives at Data[i0*Strides[0] + i1*Strides[1] + ...].
+// Tensors created by this package are row-major and contiguous.
+type Tensor[T Float] struct {
+	Shape   []int
+	Strides []int
+	Data    []T
+}
+
+// NewTensor allocates a zeroed, contiguous tensor of the given shape
+func NewTensor[T Float](shape ...int) *Tensor[T] {
+	return &Tensor[T]{
+		Shape:   append([]int(nil), shape...),
+		Strides: contiguousStrides(shape),
+		Data:    make([]T, numElements(shape)),
+	}
+}
+
+// FromSlice wraps data in a tensor of the given shape without copying
+func FromSlice[T Float](data []T, shape ...int) (*Tensor[T], error) {
+	if len(data) != numElements(shape) {
+		return nil, fmt.Errorf("cannot view %d values as shape %v", len(data), shape)
+	}
+	return &Tensor[T]{
+		Shape:   append([]int(nil), shape...),
+		Strides: contiguousStrides(shape),
+		Data:    data,
+	}, nil
+}
+
+// FromRows copies equally sized rows into a new 2D tensor
+func FromRows[T Float](rows [][]T) (*Tensor[T], error) {
+
+	cols := 0
+	if len(rows) > 0 {
+		cols = len(rows[0])
+	}
+
+	t := NewTensor[T](len(rows), cols)
+
+	for i, row := range rows {
+		if len(row) != cols {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", i, len(row), cols)
+		}
+		copy(t.Data[i*cols:], row)
+	}
+
+	return t, nil
+}
+
+// Reuse returns t resized to shape, keeping its backing array when it is large
+// enough and allocating a new tensor otherwise. A nil t always allocates.
+// Element values are not preserved, callers must overwrite them.
+func Reuse[T Float](t *Tensor[T], shape ...int) *Tensor[T] {
+
+	n := numElements(shape)
+
+	if t == nil || len(t.Shape) != len(shape) || cap(t.Data) < n {
+		return NewTensor[T](shape...)
+	}
+
+	t.Data = t.Data[:n]
+	copy(t.Shape, shape)
+
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		t.Strides[i] = stride
+		stride *= shape[i]
+	}
+
+	return t
+}
+
+func contiguousStrides(shape []int) []int {
+	strides := make([]int, len(shape))
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = stride
+		stride *= shape[i]
+	}
+	return strides
+}
+
+func numElements(shape []int) int {
+	n := 1
+	for _, d := range shape {
+		n *= d
+	}
+	return n
+}
+
+// Len returns the number of elements
+func (t *Tensor[T]) Len() int {
+	return numElements(t.Shape)
+}
+
+// Dims returns the number of dimensions
+func (t *Tensor[T]) Dims() int {
+	return len(t.Shape)
+}
+
+// Offset returns the position of an element in Data
+func (t *Tensor[T]) Offset(index ...int) int {
+	offset := 0
+	for i, idx := range index {
+		offset += idx * t.Strides[i]
+	}
+	return offset
+}
+
+// At returns the element at index
+func (t *Tensor[T]) At(index ...int) T {
+	return t.Data[t.Offset(index...)]
+}
+
+// Set stores value at index
+func (t *Tensor[T]) Set(value T, index ...int) {
+	t.Data[t.Offset(index...)] = value
+}
+
+// Row returns row i of a 2D tensor as a slice sharing the tensor's memory
+func (t *Tensor[T]) Row(i int) []T {
+	start := i * t.Strides[0]
+	return t.Data[start : start+t.Shape[1] : start+t.Shape[1]]
+}
+
+// Rows returns every row of a 2D tensor as slices sharing the tensor's
+// memory, so existing code can keep reading and writing t[j][k]
+func (t *Tensor[T]) Rows() [][]T {
+	rows := make([][]T, t.Shape[0])
+	for i := range rows {
+		rows[i] = t.Row(i)
+	}
+	return rows
+}
+
+// Reshape returns a view of the same data with a new shape
+func (t *Tensor[T]) Reshape(shape ...int) (*Tensor[T], error) {
+	return FromSlice(t.Data, shape...)
+}
+
+// Clone returns a deep copy
+func (t *Tensor[T]) Clone() *Tensor[T] {
+	return &Tensor[T]{
+		Shape:   append([]int(nil), t.Shape...),
+		Strides: append([]int(nil), t.Strides...),
+		Data:    append([]T(nil), t.Data...),
+	}
+}
+
+// Zero sets every element to 0
+func (t *Tensor[T]) Zero() {
+	for i := range t.Data {
+		t.Data[i] = 0
+	}
+}
+
+// Fill sets every element to value
+func (t *Tensor[T]) Fill(value T) {
+	for i := range t.Data {
+		t.Data[i] = value
+	}
+}
+
+// SameShape reports whether t and other have identical shapes
+func (t *Tensor[T]) SameShape(other *Tensor[T]) bool {
+	if len(t.Shape) != len(other.Shape) {
+		return false
+	}
+	for i := range t.Shape {
+		if t.Shape[i] != other.Shape[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -2,11 +2,11 @@ package vectors
 
 import "fmt"
 
-func DotProduct(a, b []float64) (float64, error) {
+func DotProduct[T Float](a, b []T) (T, error) {
 	if len(a) != len(b) {
 		return 0, fmt.Errorf("vectors must be of the same length")
 	}
-	dotProduct := 0.0
+	var dotProduct T
 	for i := range a {
 		dotProduct += a[i] * b[i]
 	}
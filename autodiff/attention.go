@@ -0,0 +1,225 @@
+package autodiff
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Attention records multi-head scaled dot-product attention over sequences
+// of steps steps. q, k and v are [batch*steps, dims] matrices of queries,
+// keys and values, one row per step, and head h uses their columns
+// [h*dims/heads, (h+1)*dims/heads). For every sequence and head
+//
+//	out = softmax(q·kᵀ / sqrt(dims/heads)) · v
+//
+// so each query attends to the keys of its own sequence. The result is a
+// [batch*steps, dims] matrix with the heads side by side.
+//
+// mask, if not nil, holds a flag per row, false for the padding steps no
+// query attends to. A query whose sequence is all padding gets zeros. q, k
+// and v may be the same node.
+func (t *Tape[T]) Attention(q, k, v *Node[T], steps, heads int, mask []bool) (*Node[T], error) {
+
+	for _, x := range []*Node[T]{q, k, v} {
+		if err := checkMatrix("attention", x); err != nil {
+			return nil, err
+		}
+		if !x.Value.SameShape(q.Value) {
+			return nil, fmt.Errorf("attention: queries, keys and values have shapes %v, %v and %v", q.Value.Shape, k.Value.Shape, v.Value.Shape)
+		}
+	}
+
+	rows, dims := q.Value.Shape[0], q.Value.Shape[1]
+
+	if steps <= 0 || rows%steps != 0 {
+		return nil, fmt.Errorf("attention: %d rows do not hold sequences of %d steps", rows, steps)
+	}
+
+	if heads <= 0 || dims%heads != 0 {
+		return nil, fmt.Errorf("attention: %d columns do not split into %d heads", dims, heads)
+	}
+
+	if mask != nil && len(mask) != rows {
+		return nil, fmt.Errorf("attention: mask has %d flags for %d rows", len(mask), rows)
+	}
+
+	n := t.node(opAttention, q, k, v)
+	n.steps, n.heads = steps, heads
+	n.mask = append(n.mask, mask...)
+
+	batch, width := rows/steps, dims/heads
+	scale := T(1 / math.Sqrt(float64(width)))
+	be := t.backend()
+
+	out := n.output(rows, dims)
+
+	// The weights of every sequence and head, kept for the backward pass
+	probs := n.buffer(0, batch*heads*steps, steps)
+
+	qh, kh, vh := n.buffer(1, steps, width), n.buffer(2, steps, width), n.buffer(3, steps, width)
+	oh, p := n.buffer(4, steps, width), n.buffer(5, steps, steps)
+
+	for b := 0; b < batch; b++ {
+		for h := 0; h < heads; h++ {
+
+			first, col := b*steps, h*width
+
+			gatherHead(qh, q.Value, first, col)
+			gatherHead(kh, k.Value, first, col)
+			gatherHead(vh, v.Value, first, col)
+
+			if err := be.Gemm(false, true, scale, qh, kh, 0, p); err != nil {
+				return nil, err
+			}
+
+			maskedSoftmax(p, n.mask, first)
+
+			if err := be.Gemm(false, false, 1, p, vh, 0, oh); err != nil {
+				return nil, err
+			}
+
+			copy(probs.Data[(b*heads+h)*steps*steps:], p.Data)
+			scatterHead(out.Data, dims, oh, first, col)
+		}
+	}
+
+	return n, nil
+}
+
+func (t *Tape[T]) attentionBackward(n *Node[T]) error {
+
+	q, k, v := n.inputs[0], n.inputs[1], n.inputs[2]
+
+	rows, dims := q.Value.Shape[0], q.Value.Shape[1]
+	steps, heads := n.steps, n.heads
+	batch, width := rows/steps, dims/heads
+	scale := T(1 / math.Sqrt(float64(width)))
+	be := t.backend()
+
+	probs := n.aux[0]
+	qh, kh, vh := n.aux[1], n.aux[2], n.aux[3]
+	dout, p := n.aux[4], n.aux[5]
+	dp, dh := n.buffer(6, steps, steps), n.buffer(7, steps, width)
+
+	// Gradients of q, k and v, added to their nodes once complete since the
+	// three may be one node
+	dq, dk, dv := n.buffer(8, rows, dims), n.buffer(9, rows, dims), n.buffer(10, rows, dims)
+
+	for b := 0; b < batch; b++ {
+		for h := 0; h < heads; h++ {
+
+			first, col := b*steps, h*width
+
+			gatherHead(qh, q.Value, first, col)
+			gatherHead(kh, k.Value, first, col)
+			gatherHead(vh, v.Value, first, col)
+			gatherHead(dout, n.Grad, first, col)
+			copy(p.Data, probs.Data[(b*heads+h)*steps*steps:])
+
+			// dv = pᵀ·dout
+			if err := be.Gemm(true, false, 1, p, dout, 0, dh); err != nil {
+				return err
+			}
+			scatterHead(dv.Data, dims, dh, first, col)
+
+			// dp = dout·vᵀ, then through the softmax of every row:
+			// ds = p * (dp - sum(dp * p)), scaled like the scores
+			if err := be.Gemm(false, true, 1, dout, vh, 0, dp); err != nil {
+				return err
+			}
+
+			for i := 0; i < steps; i++ {
+				pr, dr := p.Row(i), dp.Row(i)
+				var dot T
+				for j := range pr {
+					dot += pr[j] * dr[j]
+				}
+				for j := range pr {
+					dr[j] = pr[j] * (dr[j] - dot) * scale
+				}
+			}
+
+			// dq = ds·k and dk = dsᵀ·q
+			if err := be.Gemm(false, false, 1, dp, kh, 0, dh); err != nil {
+				return err
+			}
+			scatterHead(dq.Data, dims, dh, first, col)
+
+			if err := be.Gemm(true, false, 1, dp, qh, 0, dh); err != nil {
+				return err
+			}
+			scatterHead(dk.Data, dims, dh, first, col)
+		}
+	}
+
+	if err := t.accumulate(q, dq.Data); err != nil {
+		return err
+	}
+
+	if err := t.accumulate(k, dk.Data); err != nil {
+		return err
+	}
+
+	return t.accumulate(v, dv.Data)
+}
+
+// gatherHead copies the columns [col, col+width) of the rows [first,
+// first+steps) of x into the [steps, width] matrix dst
+func gatherHead[T vectors.Float](dst, x *vectors.Tensor[T], first, col int) {
+	width := dst.Shape[1]
+	for i := 0; i < dst.Shape[0]; i++ {
+		copy(dst.Row(i), x.Row(first + i)[col:col+width])
+	}
+}
+
+// scatterHead copies the [steps, width] matrix src into the columns [col,
+// col+width) of the rows [first, first+steps) of the matrix data with dims
+// columns
+func scatterHead[T vectors.Float](data []T, dims int, src *vectors.Tensor[T], first, col int) {
+	width := src.Shape[1]
+	for i := 0; i < src.Shape[0]; i++ {
+		at := (first+i)*dims + col
+		copy(data[at:at+width], src.Row(i))
+	}
+}
+
+// maskedSoftmax applies softmax to every row of the scores p, leaving out the
+// keys whose flag in mask, from offset first, is false. Rows without any key
+// are zeroed.
+func maskedSoftmax[T vectors.Float](p *vectors.Tensor[T], mask []bool, first int) {
+
+	keep := func(j int) bool { return len(mask) == 0 || mask[first+j] }
+
+	for i := 0; i < p.Shape[0]; i++ {
+
+		row := p.Row(i)
+
+		found, largest := false, T(0)
+		for j, s := range row {
+			if keep(j) && (!found || s > largest) {
+				found, largest = true, s
+			}
+		}
+
+		if !found {
+			clear(row)
+			continue
+		}
+
+		var sum T
+		for j, s := range row {
+			if keep(j) {
+				row[j] = T(math.Exp(float64(s - largest)))
+				sum += row[j]
+			} else {
+				row[j] = 0
+			}
+		}
+
+		for j := range row {
+			row[j] /= sum
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package autodiff
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Window is a 2D window sliding over images, which are stored as
+// [batch, height, width, channels] tensors
+type Window struct {
+
+	// Kernel is the height and width of the window
+	Kernel [2]int
+
+	// Stride is the step between windows along height and width, zero means 1
+	Stride [2]int
+
+	// Padding is the number of rows and columns of zeros added on each side
+	// of the image. It must be smaller than the kernel.
+	Padding [2]int
+}
+
+// Output returns the height and width of the output of the window sliding
+// over an image of the given size
+func (w Window) Output(height, width int) (int, int, error) {
+
+	size := [2]int{height, width}
+	var out [2]int
+
+	for i := range size {
+
+		if w.Kernel[i] <= 0 || w.Stride[i] < 0 || w.Padding[i] < 0 || w.Padding[i] >= w.Kernel[i] {
+			return 0, 0, fmt.Errorf("invalid window: kernel %v, stride %v, padding %v", w.Kernel, w.Stride, w.Padding)
+		}
+
+		padded := size[i] + 2*w.Padding[i]
+		if padded < w.Kernel[i] {
+			return 0, 0, fmt.Errorf("kernel %v is larger than the padded %dx%d image", w.Kernel, height+2*w.Padding[0], width+2*w.Padding[1])
+		}
+
+		out[i] = (padded-w.Kernel[i])/w.stride(i) + 1
+	}
+
+	return out[0], out[1], nil
+}
+
+func (w Window) stride(i int) int {
+	if w.Stride[i] == 0 {
+		return 1
+	}
+	return w.Stride[i]
+}
+
+// image returns the dimensions of a [batch, height, width, channels] value
+func image[T vectors.Float](op string, x *Node[T]) (batch, height, width, channels int, err error) {
+	if x.Value.Dims() != 4 {
+		return 0, 0, 0, 0, fmt.Errorf("%s needs a [batch, height, width, channels] input, got shape %v", op, x.Value.Shape)
+	}
+	s := x.Value.Shape
+	return s[0], s[1], s[2], s[3], nil
+}
+
+// Im2Col records the patches of x under every position of the window, one
+// row per position in [batch, row, column] order, each row holding the patch
+// in [height, width, channel] order with zeros for padding. A convolution
+// is then MatMul(Im2Col(x), kernels, false, true) with the kernels flattened
+// to [filters, kernel height * kernel width * channels].
+func (t *Tape[T]) Im2Col(x *Node[T], w Window) (*Node[T], error) {
+
+	batch, height, width, channels, err := image("im2col", x)
+	if err != nil {
+		return nil, err
+	}
+
+	outHeight, outWidth, err := w.Output(height, width)
+	if err != nil {
+		return nil, fmt.Errorf("im2col: %v", err)
+	}
+
+	n := t.node(opIm2Col, x, nil, nil)
+	n.window = w
+
+	cols := n.output(batch*outHeight*outWidth, w.Kernel[0]*w.Kernel[1]*channels)
+
+	unfold(w, x.Value.Data, cols.Data, batch, height, width, channels, false)
+
+	return n, nil
+}
+
+func (t *Tape[T]) im2ColBackward(n *Node[T]) error {
+
+	x := n.inputs[0]
+	if !x.requiresGrad {
+		return nil
+	}
+
+	s := x.Value.Shape
+
+	g := n.buffer(0, s...)
+	g.Zero()
+
+	unfold(n.window, g.Data, n.Grad.Data, s[0], s[1], s[2], s[3], true)
+
+	return t.accumulate(x, g.Data)
+}
+
+// unfold copies every window patch of the image batch x into a row of cols.
+// With fold set it goes the other way, adding each row of cols back into
+// its patch of x, which is the gradient of unfolding.
+func unfold[T vectors.Float](w Window, x, cols []T, batch, height, width, channels int, fold bool) {
+
+	outHeight, outWidth, _ := w.Output(height, width)
+	kh, kw := w.Kernel[0], w.Kernel[1]
+	sh, sw := w.stride(0), w.stride(1)
+
+	rowLen := kh * kw * channels
+	row := 0
+
+	for b := 0; b < batch; b++ {
+		img := x[b*height*width*channels : (b+1)*height*width*channels]
+
+		for oy := 0; oy < outHeight; oy++ {
+			for ox := 0; ox < outWidth; ox++ {
+
+				patch := cols[row*rowLen : (row+1)*rowLen]
+				row++
+
+				for ky := 0; ky < kh; ky++ {
+
+					iy := oy*sh + ky - w.Padding[0]
+
+					for kx := 0; kx < kw; kx++ {
+
+						ix := ox*sw + kx - w.Padding[1]
+						dst := patch[(ky*kw+kx)*channels : (ky*kw+kx+1)*channels]
+
+						if iy < 0 || iy >= height || ix < 0 || ix >= width {
+							if !fold {
+								clear(dst)
+							}
+							continue
+						}
+
+						src := img[(iy*width+ix)*channels : (iy*width+ix+1)*channels]
+
+						if fold {
+							for c, v := range dst {
+								src[c] += v
+							}
+						} else {
+							copy(dst, src)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// MaxPool2D records the largest value of each channel under every position
+// of the window. Padding is ignored rather than taken as zeros.
+func (t *Tape[T]) MaxPool2D(x *Node[T], w Window) (*Node[T], error) {
+	return t.pool(opMaxPool, "max pool", x, w)
+}
+
+// AvgPool2D records the mean of each channel under every position of the
+// window, taken over the values inside the image
+func (t *Tape[T]) AvgPool2D(x *Node[T], w Window) (*Node[T], error) {
+	return t.pool(opAvgPool, "avg pool", x, w)
+}
+
+func (t *Tape[T]) pool(kind op, name string, x *Node[T], w Window) (*Node[T], error) {
+
+	batch, height, width, channels, err := image(name, x)
+	if err != nil {
+		return nil, err
+	}
+
+	outHeight, outWidth, err := w.Output(height, width)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	n := t.node(kind, x, nil, nil)
+	n.window = w
+
+	out := n.output(batch, outHeight, outWidth, channels)
+	data := x.Value.Data
+
+	// argmax holds the index into x of the value each max pool output took
+	if kind == opMaxPool {
+		n.argmax = resize(n.argmax, out.Len())
+	}
+
+	poolWindows(w, batch, height, width, channels, func(o int, in []int) {
+
+		if kind == opMaxPool {
+			best, at := T(math.Inf(-1)), in[0]
+			for _, i := range in {
+				if data[i] > best {
+					best, at = data[i], i
+				}
+			}
+			out.Data[o], n.argmax[o] = best, at
+			return
+		}
+
+		var sum T
+		for _, i := range in {
+			sum += data[i]
+		}
+		out.Data[o] = sum / T(len(in))
+	})
+
+	return n, nil
+}
+
+func (t *Tape[T]) poolBackward(n *Node[T]) error {
+
+	x, dy := n.inputs[0], n.Grad.Data
+	if !x.requiresGrad {
+		return nil
+	}
+
+	s := x.Value.Shape
+
+	g := n.buffer(0, s...)
+	g.Zero()
+
+	if n.op == opMaxPool {
+		for o, i := range n.argmax {
+			g.Data[i] += dy[o]
+		}
+		return t.accumulate(x, g.Data)
+	}
+
+	poolWindows(n.window, s[0], s[1], s[2], s[3], func(o int, in []int) {
+		share := dy[o] / T(len(in))
+		for _, i := range in {
+			g.Data[i] += share
+		}
+	})
+
+	return t.accumulate(x, g.Data)
+}
+
+// poolWindows calls fn with the index of every output of a pooling window
+// and the indices of the input values inside the image under it
+func poolWindows(w Window, batch, height, width, channels int, fn func(out int, in []int)) {
+
+	outHeight, outWidth, _ := w.Output(height, width)
+	sh, sw := w.stride(0), w.stride(1)
+
+	in := make([]int, 0, w.Kernel[0]*w.Kernel[1])
+	o := 0
+
+	for b := 0; b < batch; b++ {
+		for oy := 0; oy < outHeight; oy++ {
+
+			y0 := max(oy*sh-w.Padding[0], 0)
+			y1 := min(oy*sh-w.Padding[0]+w.Kernel[0], height)
+
+			for ox := 0; ox < outWidth; ox++ {
+
+				x0 := max(ox*sw-w.Padding[1], 0)
+				x1 := min(ox*sw-w.Padding[1]+w.Kernel[1], width)
+
+				for c := 0; c < channels; c++ {
+
+					in = in[:0]
+					for iy := y0; iy < y1; iy++ {
+						for ix := x0; ix < x1; ix++ {
+							in = append(in, ((b*height+iy)*width+ix)*channels+c)
+						}
+					}
+
+					fn(o, in)
+					o++
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package autodiff
+
+import (
+	"fmt"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/losses"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Loss records scale times the sum of loss over the rows of prediction, one
+// row per sample, as a 1x1 tensor. targets holds the target of each row and
+// is not copied. Losses work in float64, so rows of other types are converted.
+//
+// When prediction is a softmax and loss is a categorical cross-entropy, the
+// backward pass of a Backward called on this node writes scale * (p - t)
+// straight to the softmax input instead of going through the softmax Jacobian.
+func (t *Tape[T]) Loss(prediction *Node[T], targets [][]T, loss losses.Loss, scale T) (*Node[T], error) {
+
+	if err := checkMatrix("loss", prediction); err != nil {
+		return nil, err
+	}
+
+	rows, cols := prediction.Value.Shape[0], prediction.Value.Shape[1]
+
+	if len(targets) != rows {
+		return nil, fmt.Errorf("loss: %d targets for %d predictions", len(targets), rows)
+	}
+
+	n := t.node(opLoss, prediction, nil, nil)
+	n.loss = loss
+	n.targets = targets
+	n.scale = scale
+
+	if prediction.op == opActivate && prediction.activation == activation.Softmax {
+		switch loss.(type) {
+		case losses.CategoricalCrossEntropyLoss, losses.SparseCategoricalCrossEntropyLoss:
+			n.fused = true
+		}
+	}
+
+	// f64 holds the prediction, target and gradient of one row in float64
+	n.f64[0] = resize(n.f64[0], cols)
+	n.f64[2] = resize(n.f64[2], cols)
+
+	sum := 0.0
+
+	for i := 0; i < rows; i++ {
+
+		p, target := n.row(i)
+
+		value, err := loss.Value(p, target)
+		if err != nil {
+			return nil, err
+		}
+
+		sum += value
+	}
+
+	n.output(1, 1).Data[0] = T(float64(scale) * sum)
+
+	return n, nil
+}
+
+// row returns prediction and target row i of a loss node in float64
+func (n *Node[T]) row(i int) (prediction, target []float64) {
+
+	targetRow := n.targets[i]
+	if len(targetRow) > cap(n.f64[1]) {
+		n.f64[1] = make([]float64, len(targetRow))
+	}
+
+	prediction = vectors.AsFloat64(n.inputs[0].Value.Row(i), n.f64[0])
+	target = vectors.AsFloat64(targetRow, n.f64[1][:len(targetRow)])
+
+	return prediction, target
+}
+
+func (t *Tape[T]) lossBackward(n *Node[T]) error {
+
+	prediction := n.inputs[0]
+	seed := float64(n.Grad.Data[0] * n.scale)
+
+	// The shortcut skips the softmax node, which is only valid when nothing
+	// else downstream of the softmax reaches the differentiated node
+	fused := n.fused && n == t.root
+
+	dst := prediction
+	if fused {
+		dst = prediction.inputs[0]
+	}
+
+	if !dst.requiresGrad {
+		return nil
+	}
+
+	g := n.buffer(0, prediction.Value.Shape...)
+
+	for i := 0; i < g.Shape[0]; i++ {
+
+		p, target := n.row(i)
+		grad := n.f64[2]
+
+		switch loss := n.loss.(type) {
+
+		case losses.CategoricalCrossEntropyLoss:
+			if !fused {
+				if err := loss.GradientInto(grad, p, target); err != nil {
+					return err
+				}
+				break
+			}
+			if len(target) != len(p) {
+				return fmt.Errorf("predictions and targets must be of the same length")
+			}
+			for j := range p {
+				grad[j] = p[j] - target[j]
+			}
+
+		case losses.SparseCategoricalCrossEntropyLoss:
+			if !fused {
+				if err := loss.GradientInto(grad, p, target); err != nil {
+					return err
+				}
+				break
+			}
+			class, err := losses.SparseClass(p, target)
+			if err != nil {
+				return err
+			}
+			copy(grad, p)
+			grad[class] -= 1.0
+
+		case losses.GradientWriter:
+			if err := loss.GradientInto(grad, p, target); err != nil {
+				return err
+			}
+
+		default:
+			lossGrad, err := loss.Gradient(p, target)
+			if err != nil {
+				return err
+			}
+			grad = lossGrad
+		}
+
+		out := g.Row(i)
+		for j := range out {
+			out[j] = T(seed * grad[j])
+		}
+	}
+
+	return t.accumulate(dst, g.Data)
+}
@@ -0,0 +1,255 @@
+package autodiff
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// LayerNorm records gamma * x̂ + beta, where x̂ is each row of x normalized
+// to zero mean and unit variance over its columns. gamma and beta hold one
+// value per column. Statistics are computed in float64.
+func (t *Tape[T]) LayerNorm(x, gamma, beta *Node[T], epsilon float64) (*Node[T], error) {
+
+	if err := checkNormalization("layer norm", x, gamma, beta); err != nil {
+		return nil, err
+	}
+
+	n := t.node(opLayerNorm, x, gamma, beta)
+	n.epsilon = epsilon
+
+	rows, cols := x.Value.Shape[0], x.Value.Shape[1]
+
+	out := n.output(rows, cols)
+	normalized := n.buffer(0, rows, cols)
+	n.stats = resize(n.stats, rows) // 1/std of each row
+
+	g, b := gamma.Value.Data, beta.Value.Data
+
+	for i := 0; i < rows; i++ {
+
+		row := x.Value.Row(i)
+
+		mean := 0.0
+		for _, v := range row {
+			mean += float64(v)
+		}
+		mean /= float64(cols)
+
+		variance := 0.0
+		for _, v := range row {
+			d := float64(v) - mean
+			variance += d * d
+		}
+		variance /= float64(cols)
+
+		invStd := T(1.0 / math.Sqrt(variance+epsilon))
+		n.stats[i] = invStd
+
+		xhat, y := normalized.Row(i), out.Row(i)
+		for j, v := range row {
+			xhat[j] = (v - T(mean)) * invStd
+			y[j] = g[j]*xhat[j] + b[j]
+		}
+	}
+
+	return n, nil
+}
+
+// BatchNorm records gamma * x̂ + beta, where x̂ is each column of x normalized
+// over the rows of the batch. In training the batch statistics are used and
+// folded into runningMean and runningVariance with weight momentum (the
+// variance with its unbiased estimate). Otherwise the running statistics are
+// used. Statistics are computed in float64.
+func (t *Tape[T]) BatchNorm(x, gamma, beta *Node[T], runningMean, runningVariance []T, training bool, momentum, epsilon float64) (*Node[T], error) {
+
+	if err := checkNormalization("batch norm", x, gamma, beta); err != nil {
+		return nil, err
+	}
+
+	rows, cols := x.Value.Shape[0], x.Value.Shape[1]
+
+	if len(runningMean) != cols || len(runningVariance) != cols {
+		return nil, fmt.Errorf("batch norm: running statistics have %d and %d values for %d columns", len(runningMean), len(runningVariance), cols)
+	}
+
+	n := t.node(opBatchNorm, x, gamma, beta)
+	n.training = training
+	n.epsilon = epsilon
+
+	out := n.output(rows, cols)
+	normalized := n.buffer(0, rows, cols)
+	n.stats = resize(n.stats, cols) // 1/std of each column
+
+	g, b := gamma.Value.Data, beta.Value.Data
+	data := x.Value.Data
+
+	for j := 0; j < cols; j++ {
+
+		mean, variance := float64(runningMean[j]), float64(runningVariance[j])
+
+		if training {
+			mean = 0.0
+			for i := 0; i < rows; i++ {
+				mean += float64(data[i*cols+j])
+			}
+			mean /= float64(rows)
+
+			variance = 0.0
+			for i := 0; i < rows; i++ {
+				d := float64(data[i*cols+j]) - mean
+				variance += d * d
+			}
+			variance /= float64(rows)
+
+			unbiased := variance
+			if rows > 1 {
+				unbiased = variance * float64(rows) / float64(rows-1)
+			}
+
+			runningMean[j] = T((1.0-momentum)*float64(runningMean[j]) + momentum*mean)
+			runningVariance[j] = T((1.0-momentum)*float64(runningVariance[j]) + momentum*unbiased)
+		}
+
+		invStd := T(1.0 / math.Sqrt(variance+epsilon))
+		n.stats[j] = invStd
+
+		for i := 0; i < rows; i++ {
+			idx := i*cols + j
+			normalized.Data[idx] = (data[idx] - T(mean)) * invStd
+			out.Data[idx] = g[j]*normalized.Data[idx] + b[j]
+		}
+	}
+
+	return n, nil
+}
+
+func checkNormalization[T vectors.Float](op string, x, gamma, beta *Node[T]) error {
+	if err := checkMatrix(op, x); err != nil {
+		return err
+	}
+	cols := x.Value.Shape[1]
+	if gamma.Value.Len() != cols || beta.Value.Len() != cols {
+		return fmt.Errorf("%s: gamma and beta have %d and %d values for %d columns", op, gamma.Value.Len(), beta.Value.Len(), cols)
+	}
+	return nil
+}
+
+// normalizationGrads accumulates the gradients of gamma and beta, the sums
+// over the batch of dy * x̂ and dy, and returns dx̂ = dy * gamma in scratch 1
+func (t *Tape[T]) normalizationGrads(n *Node[T]) ([]T, error) {
+
+	gamma, beta, dy := n.inputs[1], n.inputs[2], n.Grad
+	rows, cols := dy.Shape[0], dy.Shape[1]
+
+	xhat := n.aux[0].Data
+	dxhat := n.buffer(1, rows, cols).Data
+	gradGamma := n.buffer(2, cols).Data
+	gradBeta := n.buffer(3, cols).Data
+
+	clear(gradGamma)
+	clear(gradBeta)
+
+	g := gamma.Value.Data
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			idx := i*cols + j
+			gradGamma[j] += dy.Data[idx] * xhat[idx]
+			gradBeta[j] += dy.Data[idx]
+			dxhat[idx] = dy.Data[idx] * g[j]
+		}
+	}
+
+	if err := t.accumulate(gamma, gradGamma); err != nil {
+		return nil, err
+	}
+	if err := t.accumulate(beta, gradBeta); err != nil {
+		return nil, err
+	}
+
+	return dxhat, nil
+}
+
+// layerNormBackward uses, per row of n values,
+// dx = invStd / n * (n * dx̂ - sum(dx̂) - x̂ * sum(dx̂ * x̂))
+func (t *Tape[T]) layerNormBackward(n *Node[T]) error {
+
+	dxhat, err := t.normalizationGrads(n)
+	if err != nil {
+		return err
+	}
+
+	x := n.inputs[0]
+	if !x.requiresGrad {
+		return nil
+	}
+
+	rows, cols := x.Value.Shape[0], x.Value.Shape[1]
+	xhat := n.aux[0].Data
+	size := T(cols)
+
+	for i := 0; i < rows; i++ {
+
+		var sum, dot T
+		for j := 0; j < cols; j++ {
+			idx := i*cols + j
+			sum += dxhat[idx]
+			dot += dxhat[idx] * xhat[idx]
+		}
+
+		for j := 0; j < cols; j++ {
+			idx := i*cols + j
+			dxhat[idx] = n.stats[i] / size * (size*dxhat[idx] - sum - xhat[idx]*dot)
+		}
+	}
+
+	return t.accumulate(x, dxhat)
+}
+
+// batchNormBackward uses the same formula as layerNormBackward with the sums
+// taken over each column. With running statistics the normalization is an
+// affine map, so dx = dx̂ * invStd.
+func (t *Tape[T]) batchNormBackward(n *Node[T]) error {
+
+	dxhat, err := t.normalizationGrads(n)
+	if err != nil {
+		return err
+	}
+
+	x := n.inputs[0]
+	if !x.requiresGrad {
+		return nil
+	}
+
+	rows, cols := x.Value.Shape[0], x.Value.Shape[1]
+	xhat := n.aux[0].Data
+	size := T(rows)
+
+	for j := 0; j < cols; j++ {
+
+		invStd := n.stats[j]
+
+		if !n.training {
+			for i := 0; i < rows; i++ {
+				dxhat[i*cols+j] *= invStd
+			}
+			continue
+		}
+
+		var sum, dot T
+		for i := 0; i < rows; i++ {
+			idx := i*cols + j
+			sum += dxhat[idx]
+			dot += dxhat[idx] * xhat[idx]
+		}
+
+		for i := 0; i < rows; i++ {
+			idx := i*cols + j
+			dxhat[idx] = invStd / size * (size*dxhat[idx] - sum - xhat[idx]*dot)
+		}
+	}
+
+	return t.accumulate(x, dxhat)
+}
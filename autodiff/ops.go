@@ -0,0 +1,328 @@
+package autodiff
+
+import (
+	"fmt"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// MatMul records op(a) · op(b), where op transposes its argument when the
+// matching trans flag is set. A dense layer is MatMul(x, w, false, true).
+func (t *Tape[T]) MatMul(a, b *Node[T], transA, transB bool) (*Node[T], error) {
+
+	if err := checkMatrix("matmul", a); err != nil {
+		return nil, err
+	}
+	if err := checkMatrix("matmul", b); err != nil {
+		return nil, err
+	}
+
+	m, k := a.Value.Shape[0], a.Value.Shape[1]
+	if transA {
+		m, k = k, m
+	}
+
+	kb, cols := b.Value.Shape[0], b.Value.Shape[1]
+	if transB {
+		kb, cols = cols, kb
+	}
+
+	if k != kb {
+		return nil, fmt.Errorf("matmul shape mismatch: op(a) is %dx%d, op(b) is %dx%d", m, k, kb, cols)
+	}
+
+	n := t.node(opMatMul, a, b, nil)
+	n.transA, n.transB = transA, transB
+
+	if err := t.backend().Gemm(transA, transB, 1, a.Value, b.Value, 0, n.output(m, cols)); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// matMulBackward uses, for C = op(A) · op(B):
+// dop(A) = dC · op(B)ᵀ and dop(B) = op(A)ᵀ · dC
+func (t *Tape[T]) matMulBackward(n *Node[T]) error {
+
+	be := t.backend()
+	a, b, dc := n.inputs[0], n.inputs[1], n.Grad
+
+	if grad, fresh := t.gradOf(a); grad != nil {
+
+		beta := T(1)
+		if fresh {
+			beta = 0
+		}
+
+		var err error
+		if n.transA {
+			err = be.Gemm(n.transB, true, 1, b.Value, dc, beta, grad)
+		} else {
+			err = be.Gemm(false, !n.transB, 1, dc, b.Value, beta, grad)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if grad, fresh := t.gradOf(b); grad != nil {
+
+		beta := T(1)
+		if fresh {
+			beta = 0
+		}
+
+		var err error
+		if n.transB {
+			err = be.Gemm(true, n.transA, 1, dc, a.Value, beta, grad)
+		} else {
+			err = be.Gemm(!n.transA, false, 1, a.Value, dc, beta, grad)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddRow records x with row added to each of its rows, e.g. a bias. row may
+// be a vector or any tensor holding one value per column of x.
+func (t *Tape[T]) AddRow(x, row *Node[T]) (*Node[T], error) {
+
+	if err := checkMatrix("add row", x); err != nil {
+		return nil, err
+	}
+
+	if row.Value.Len() != x.Value.Shape[1] {
+		return nil, fmt.Errorf("add row: row has %d values but x has %d columns", row.Value.Len(), x.Value.Shape[1])
+	}
+
+	n := t.node(opAddRow, x, row, nil)
+
+	out := n.output(x.Value.Shape...)
+	copy(out.Data, x.Value.Data)
+
+	if err := t.backend().AddRow(out, row.Value.Data); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (t *Tape[T]) addRowBackward(n *Node[T]) error {
+
+	x, row, dy := n.inputs[0], n.inputs[1], n.Grad
+
+	if err := t.accumulate(x, dy.Data); err != nil {
+		return err
+	}
+
+	if !row.requiresGrad {
+		return nil
+	}
+
+	// The row's gradient is the column sums of dy
+	sums := n.buffer(0, row.Value.Len())
+	if err := t.backend().SumRows(sums.Data, dy); err != nil {
+		return err
+	}
+
+	return t.accumulate(row, sums.Data)
+}
+
+// Mul records the element-wise product of a and b, which must have the same shape
+func (t *Tape[T]) Mul(a, b *Node[T]) (*Node[T], error) {
+
+	if !a.Value.SameShape(b.Value) {
+		return nil, fmt.Errorf("mul: shapes differ, %v and %v", a.Value.Shape, b.Value.Shape)
+	}
+
+	n := t.node(opMul, a, b, nil)
+
+	out := n.output(a.Value.Shape...)
+	copy(out.Data, a.Value.Data)
+
+	if err := t.backend().Mul(out.Data, b.Value.Data); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (t *Tape[T]) mulBackward(n *Node[T]) error {
+
+	be := t.backend()
+
+	// d(a ⊙ b)/da = b and the other way round
+	for i, other := range [2]*Node[T]{n.inputs[1], n.inputs[0]} {
+
+		in := n.inputs[i]
+		if !in.requiresGrad {
+			continue
+		}
+
+		g := n.buffer(i, n.Grad.Shape...)
+		copy(g.Data, n.Grad.Data)
+
+		if err := be.Mul(g.Data, other.Value.Data); err != nil {
+			return err
+		}
+
+		if err := t.accumulate(in, g.Data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Activate records the activation of x. Softmax is applied to each row,
+// every other activation element-wise.
+func (t *Tape[T]) Activate(x *Node[T], name activation.ActivationFunction) (*Node[T], error) {
+
+	if err := checkMatrix("activate", x); err != nil {
+		return nil, err
+	}
+
+	n := t.node(opActivate, x, nil, nil)
+	n.activation = name
+
+	if err := t.backend().Activate(name, n.output(x.Value.Shape...), x.Value); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (t *Tape[T]) activateBackward(n *Node[T]) error {
+
+	x, dy := n.inputs[0], n.Grad
+
+	if !x.requiresGrad {
+		return nil
+	}
+
+	g := n.buffer(0, dy.Shape...)
+
+	if n.activation == activation.Softmax {
+
+		// Softmax Jacobian-vector product per row: dx_j = p_j * (dy_j - sum_i dy_i * p_i)
+		p := n.Value
+		for r := 0; r < p.Shape[0]; r++ {
+			pRow, dyRow, gRow := p.Row(r), dy.Row(r), g.Row(r)
+
+			var weighted T
+			for i := range pRow {
+				weighted += dyRow[i] * pRow[i]
+			}
+			for j := range pRow {
+				gRow[j] = pRow[j] * (dyRow[j] - weighted)
+			}
+		}
+
+		return t.accumulate(x, g.Data)
+	}
+
+	copy(g.Data, dy.Data)
+
+	if err := t.backend().ActivationBackward(n.activation, g, x.Value); err != nil {
+		return err
+	}
+
+	return t.accumulate(x, g.Data)
+}
+
+// Reshape records x viewed with a new shape holding the same number of
+// values. The value shares x's memory.
+func (t *Tape[T]) Reshape(x *Node[T], shape ...int) (*Node[T], error) {
+
+	size := 1
+	for _, d := range shape {
+		size *= d
+	}
+
+	if size != x.Value.Len() {
+		return nil, fmt.Errorf("reshape: cannot view shape %v as %v", x.Value.Shape, shape)
+	}
+
+	n := t.node(opReshape, x, nil, nil)
+
+	// The view is kept apart from the value buffer, which must never alias another node's memory
+	if n.view == nil || len(n.view.Shape) != len(shape) {
+		n.view = &vectors.Tensor[T]{Shape: make([]int, len(shape)), Strides: make([]int, len(shape))}
+	}
+
+	copy(n.view.Shape, shape)
+
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		n.view.Strides[i] = stride
+		stride *= shape[i]
+	}
+
+	n.view.Data = x.Value.Data
+	n.Value = n.view
+
+	return n, nil
+}
+
+// Add records a + b element-wise, a and b must have the same shape
+func (t *Tape[T]) Add(a, b *Node[T]) (*Node[T], error) {
+	return t.add(a, b, 1)
+}
+
+// Sub records a - b element-wise, a and b must have the same shape
+func (t *Tape[T]) Sub(a, b *Node[T]) (*Node[T], error) {
+	return t.add(a, b, -1)
+}
+
+// add records a + sign * b
+func (t *Tape[T]) add(a, b *Node[T], sign T) (*Node[T], error) {
+
+	if !a.Value.SameShape(b.Value) {
+		return nil, fmt.Errorf("add: shapes differ, %v and %v", a.Value.Shape, b.Value.Shape)
+	}
+
+	n := t.node(opAdd, a, b, nil)
+	n.scale = sign
+
+	out := n.output(a.Value.Shape...)
+	copy(out.Data, a.Value.Data)
+
+	if sign > 0 {
+		return n, t.backend().Add(out.Data, b.Value.Data)
+	}
+
+	for i, v := range b.Value.Data {
+		out.Data[i] -= v
+	}
+
+	return n, nil
+}
+
+func (t *Tape[T]) addBackward(n *Node[T]) error {
+
+	a, b := n.inputs[0], n.inputs[1]
+
+	if err := t.accumulate(a, n.Grad.Data); err != nil {
+		return err
+	}
+
+	if !b.requiresGrad {
+		return nil
+	}
+
+	if n.scale > 0 {
+		return t.accumulate(b, n.Grad.Data)
+	}
+
+	g := n.buffer(0, n.Grad.Shape...)
+	for i, v := range n.Grad.Data {
+		g.Data[i] = -v
+	}
+
+	return t.accumulate(b, g.Data)
+}
@@ -0,0 +1,129 @@
+package autodiff
+
+import (
+	"fmt"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Slice records columns [start, end) of the 2D value x
+func (t *Tape[T]) Slice(x *Node[T], start, end int) (*Node[T], error) {
+
+	if err := checkMatrix("slice", x); err != nil {
+		return nil, err
+	}
+
+	rows, cols := x.Value.Shape[0], x.Value.Shape[1]
+
+	if start < 0 || end > cols || start >= end {
+		return nil, fmt.Errorf("slice: columns [%d, %d) out of range for %d columns", start, end, cols)
+	}
+
+	n := t.node(opSlice, x, nil, nil)
+	n.span = [2]int{start, end}
+
+	out := n.output(rows, end-start)
+	for i := 0; i < rows; i++ {
+		copy(out.Row(i), x.Value.Row(i)[start:end])
+	}
+
+	return n, nil
+}
+
+func (t *Tape[T]) sliceBackward(n *Node[T]) error {
+	return t.accumulateColumns(n.inputs[0], n.Grad, n.span[0])
+}
+
+// Concat records the 2D values xs side by side, their columns in order.
+// Every value must have the same number of rows.
+func (t *Tape[T]) Concat(xs ...*Node[T]) (*Node[T], error) {
+
+	if len(xs) == 0 {
+		return nil, fmt.Errorf("concat: no inputs")
+	}
+
+	cols := 0
+	for _, x := range xs {
+		if err := checkMatrix("concat", x); err != nil {
+			return nil, err
+		}
+		if x.Value.Shape[0] != xs[0].Value.Shape[0] {
+			return nil, fmt.Errorf("concat: row counts differ, %d and %d", xs[0].Value.Shape[0], x.Value.Shape[0])
+		}
+		cols += x.Value.Shape[1]
+	}
+
+	n := t.node(opConcat, nil, nil, nil)
+	n.list = append(n.list, xs...)
+
+	rows := xs[0].Value.Shape[0]
+	out := n.output(rows, cols)
+
+	start := 0
+	for _, x := range xs {
+
+		width := x.Value.Shape[1]
+		for i := 0; i < rows; i++ {
+			copy(out.Row(i)[start:start+width], x.Value.Row(i))
+		}
+
+		start += width
+
+		if x.requiresGrad {
+			n.requiresGrad = true
+		}
+	}
+
+	return n, nil
+}
+
+func (t *Tape[T]) concatBackward(n *Node[T]) error {
+
+	dy := n.Grad
+	start := 0
+
+	for _, x := range n.list {
+
+		width := x.Value.Shape[1]
+
+		if grad, fresh := t.gradOf(x); grad != nil {
+			for i := 0; i < dy.Shape[0]; i++ {
+				src, dst := dy.Row(i)[start:start+width], grad.Row(i)
+				if fresh {
+					copy(dst, src)
+					continue
+				}
+				for j, v := range src {
+					dst[j] += v
+				}
+			}
+		}
+
+		start += width
+	}
+
+	return nil
+}
+
+// accumulateColumns adds g to the columns of the gradient of x starting at
+// column start. A fresh gradient is cleared first, as g only covers part of it.
+func (t *Tape[T]) accumulateColumns(x *Node[T], g *vectors.Tensor[T], start int) error {
+
+	grad, fresh := t.gradOf(x)
+	if grad == nil {
+		return nil
+	}
+
+	if fresh {
+		grad.Zero()
+	}
+
+	for i := 0; i < g.Shape[0]; i++ {
+		dst := grad.Row(i)[start : start+g.Shape[1]]
+		for j, v := range g.Row(i) {
+			dst[j] += v
+		}
+	}
+
+	return nil
+}
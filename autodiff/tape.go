@@ -0,0 +1,329 @@
+package autodiff
+
+import (
+	"fmt"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/backend"
+	"github.com/ThakurMayank5/gonn/losses"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// op identifies the operation that produced a node
+type op int
+
+const (
+	opParam op = iota
+	opConstant
+	opReshape
+	opMatMul
+	opAddRow
+	opMul
+	opAdd
+	opSlice
+	opConcat
+	opActivate
+	opLayerNorm
+	opBatchNorm
+	opIm2Col
+	opMaxPool
+	opAvgPool
+	opAttention
+	opLoss
+)
+
+// Node is one value recorded on a Tape: a parameter, a constant, or the
+// result of an op on earlier nodes.
+//
+// Value holds the result of the op. After Tape.Backward, Grad holds the
+// gradient of the differentiated node with respect to Value. Grad is nil for
+// nodes that depend on no parameter, such as inputs and dropout masks.
+type Node[T vectors.Float] struct {
+	Value *vectors.Tensor[T]
+	Grad  *vectors.Tensor[T]
+
+	op           op
+	index        int
+	inputs       [3]*Node[T]
+	list         []*Node[T] // inputs of a Concat
+	requiresGrad bool
+
+	// gradSet records whether Grad has received a contribution in the current
+	// backward pass. The first contribution overwrites Grad, later ones add to it.
+	gradSet bool
+
+	// Buffers owned by the node and kept across Reset, so a tape that records
+	// the same graph again does not allocate
+	value, grad *vectors.Tensor[T]
+	view        *vectors.Tensor[T]
+	aux         [11]*vectors.Tensor[T]
+	stats       []T
+	argmax      []int
+	mask        []bool
+	f64         [3][]float64
+
+	// Op parameters
+	transA, transB bool
+	activation     activation.ActivationFunction
+	loss           losses.Loss
+	targets        [][]T
+	scale          T
+	fused          bool
+	training       bool
+	epsilon        float64
+	window         Window
+	span           [2]int
+	steps, heads   int
+}
+
+// Tape records the ops of a forward pass so their gradients can be computed
+// in reverse by Backward.
+//
+// A tape is an arena: Reset forgets the recorded ops but keeps every node and
+// its buffers, so recording a graph of the same shape again reuses them
+// instead of allocating. Nodes are only valid until the next Reset.
+// A tape is not safe for concurrent use.
+type Tape[T vectors.Float] struct {
+
+	// Backend runs the array operations, nil uses backend.Default
+	Backend backend.Backend[T]
+
+	nodes []*Node[T]
+	n     int
+
+	// root is the node of the running Backward
+	root *Node[T]
+}
+
+// NewTape returns an empty tape running on be, nil for the default backend
+func NewTape[T vectors.Float](be backend.Backend[T]) *Tape[T] {
+	return &Tape[T]{Backend: be}
+}
+
+// Reset forgets every recorded node, keeping their buffers for reuse
+func (t *Tape[T]) Reset() {
+	t.n = 0
+}
+
+// Len returns the number of nodes recorded since the last Reset
+func (t *Tape[T]) Len() int {
+	return t.n
+}
+
+func (t *Tape[T]) backend() backend.Backend[T] {
+	if t.Backend == nil {
+		return backend.Default[T]()
+	}
+	return t.Backend
+}
+
+// Param records a trainable tensor. Its gradient is written to grad, which
+// must have the shape of value; a nil grad is allocated by the tape. Neither
+// tensor is copied.
+func (t *Tape[T]) Param(value, grad *vectors.Tensor[T]) (*Node[T], error) {
+
+	if grad != nil && !grad.SameShape(value) {
+		return nil, fmt.Errorf("param: gradient shape %v does not match value shape %v", grad.Shape, value.Shape)
+	}
+
+	n := t.node(opParam, nil, nil, nil)
+	n.Value = value
+	n.Grad = grad
+	n.requiresGrad = true
+
+	return n, nil
+}
+
+// Constant records a tensor that is not differentiated, such as a batch of
+// inputs. The tensor is not copied.
+func (t *Tape[T]) Constant(value *vectors.Tensor[T]) *Node[T] {
+	n := t.node(opConstant, nil, nil, nil)
+	n.Value = value
+	return n
+}
+
+// Backward computes the gradient of root with respect to every node it
+// depends on. The gradient of root itself is seeded with ones. Parameters
+// root does not depend on get a zero gradient.
+func (t *Tape[T]) Backward(root *Node[T]) error {
+	return t.BackwardWith(root, nil)
+}
+
+// BackwardWith is Backward with the gradient of root seeded with grad, the
+// gradient of some outer objective with respect to root's value, such as
+// the gradient a layer receives from the layer after it. grad must have the
+// shape of root's value and is copied; nil seeds ones.
+func (t *Tape[T]) BackwardWith(root *Node[T], grad *vectors.Tensor[T]) error {
+
+	if root == nil || root.index >= t.n || t.nodes[root.index] != root {
+		return fmt.Errorf("backward: node was not recorded on this tape since the last reset")
+	}
+
+	if grad != nil && !grad.SameShape(root.Value) {
+		return fmt.Errorf("backward: gradient shape %v does not match value shape %v", grad.Shape, root.Value.Shape)
+	}
+
+	t.root = root
+
+	for i := 0; i < t.n; i++ {
+		t.nodes[i].gradSet = false
+	}
+
+	if seed, _ := t.gradOf(root); seed != nil {
+		if grad == nil {
+			seed.Fill(1)
+		} else {
+			copy(seed.Data, grad.Data)
+		}
+	}
+
+	// Nodes are recorded in topological order, so walking back from root
+	// visits every node after all the nodes that consume it
+	for i := root.index; i >= 0; i-- {
+
+		n := t.nodes[i]
+		if !n.gradSet {
+			continue
+		}
+
+		if err := t.backward(n); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < t.n; i++ {
+		if n := t.nodes[i]; n.op == opParam && !n.gradSet {
+			grad, _ := t.gradOf(n)
+			grad.Zero()
+		}
+	}
+
+	return nil
+}
+
+// backward propagates the gradient of n to its inputs
+func (t *Tape[T]) backward(n *Node[T]) error {
+	switch n.op {
+	case opReshape:
+		return t.accumulate(n.inputs[0], n.Grad.Data)
+	case opMatMul:
+		return t.matMulBackward(n)
+	case opAddRow:
+		return t.addRowBackward(n)
+	case opMul:
+		return t.mulBackward(n)
+	case opAdd:
+		return t.addBackward(n)
+	case opSlice:
+		return t.sliceBackward(n)
+	case opConcat:
+		return t.concatBackward(n)
+	case opActivate:
+		return t.activateBackward(n)
+	case opLayerNorm:
+		return t.layerNormBackward(n)
+	case opBatchNorm:
+		return t.batchNormBackward(n)
+	case opIm2Col:
+		return t.im2ColBackward(n)
+	case opMaxPool, opAvgPool:
+		return t.poolBackward(n)
+	case opAttention:
+		return t.attentionBackward(n)
+	case opLoss:
+		return t.lossBackward(n)
+	}
+	return nil
+}
+
+// node appends a node for op to the tape, reusing a node left by an earlier
+// pass when there is one. The node requires a gradient when any input does.
+func (t *Tape[T]) node(kind op, a, b, c *Node[T]) *Node[T] {
+
+	if t.n == len(t.nodes) {
+		t.nodes = append(t.nodes, &Node[T]{})
+	}
+
+	n := t.nodes[t.n]
+
+	// Clear the previous op, keeping the owned buffers
+	*n = Node[T]{value: n.value, grad: n.grad, view: n.view, aux: n.aux, stats: n.stats, argmax: n.argmax, mask: n.mask[:0], f64: n.f64, list: n.list[:0]}
+
+	n.op = kind
+	n.index = t.n
+	n.inputs = [3]*Node[T]{a, b, c}
+
+	for _, in := range n.inputs {
+		if in != nil && in.requiresGrad {
+			n.requiresGrad = true
+		}
+	}
+
+	t.n++
+
+	return n
+}
+
+// output sizes the node's own value buffer to shape and makes it the node's Value
+func (n *Node[T]) output(shape ...int) *vectors.Tensor[T] {
+	n.value = vectors.Reuse(n.value, shape...)
+	n.Value = n.value
+	return n.Value
+}
+
+// buffer returns the node's scratch tensor i, sized to shape
+func (n *Node[T]) buffer(i int, shape ...int) *vectors.Tensor[T] {
+	n.aux[i] = vectors.Reuse(n.aux[i], shape...)
+	return n.aux[i]
+}
+
+// gradOf returns the gradient tensor of n and whether it is fresh, meaning
+// nothing has been written to it yet in this pass so it must be overwritten
+// rather than added to. It returns nil for nodes that need no gradient.
+func (t *Tape[T]) gradOf(n *Node[T]) (*vectors.Tensor[T], bool) {
+
+	if !n.requiresGrad {
+		return nil, false
+	}
+
+	if n.Grad == nil {
+		n.grad = vectors.Reuse(n.grad, n.Value.Shape...)
+		n.Grad = n.grad
+	}
+
+	fresh := !n.gradSet
+	n.gradSet = true
+
+	return n.Grad, fresh
+}
+
+// accumulate adds the contribution g to the gradient of n
+func (t *Tape[T]) accumulate(n *Node[T], g []T) error {
+
+	grad, fresh := t.gradOf(n)
+	if grad == nil {
+		return nil
+	}
+
+	if fresh {
+		copy(grad.Data, g)
+		return nil
+	}
+
+	return t.backend().Add(grad.Data, g)
+}
+
+func checkMatrix[T vectors.Float](op string, x *Node[T]) error {
+	if x.Value.Dims() != 2 {
+		return fmt.Errorf("%s needs a 2D input, got shape %v", op, x.Value.Shape)
+	}
+	return nil
+}
+
+// resize returns s with length n, reusing its backing array when possible
+func resize[E any](s []E, n int) []E {
+	if cap(s) < n {
+		return make([]E, n)
+	}
+	return s[:n]
+}
@@ -0,0 +1,344 @@
+package autodiff_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/autodiff"
+	"github.com/ThakurMayank5/gonn/losses"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+const (
+	gradCheckEpsilon   = 1e-6
+	gradCheckTolerance = 1e-6
+)
+
+type (
+	tape = autodiff.Tape[float64]
+	node = autodiff.Node[float64]
+)
+
+// randomTensor returns a tensor of the given shape with standard normal values
+func randomTensor(rng *rand.Rand, shape ...int) *vectors.Tensor[float64] {
+	t := vectors.NewTensor[float64](shape...)
+	for i := range t.Data {
+		t.Data[i] = rng.NormFloat64()
+	}
+	return t
+}
+
+// checkOp records an op on a tape, with params as its parameters, and
+// compares the gradients Backward gives with central finite differences.
+// The differentiated objective is a fixed random weighting of the op's
+// output, so every output value gets a gradient of its own.
+func checkOp(t *testing.T, params []*vectors.Tensor[float64], record func(tp *tape, x []*node) (*node, error)) {
+	t.Helper()
+
+	tp := autodiff.NewTape[float64](nil)
+
+	grads := make([]*vectors.Tensor[float64], len(params))
+	for i, p := range params {
+		grads[i] = vectors.NewTensor[float64](p.Shape...)
+	}
+
+	forward := func() *node {
+
+		tp.Reset()
+
+		nodes := make([]*node, len(params))
+		for i, p := range params {
+			n, err := tp.Param(p, grads[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes[i] = n
+		}
+
+		out, err := record(tp, nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return out
+	}
+
+	out := forward()
+	weights := randomTensor(rand.New(rand.NewSource(99)), out.Value.Shape...)
+
+	if err := tp.BackwardWith(out, weights); err != nil {
+		t.Fatal(err)
+	}
+
+	analytic := make([][]float64, len(grads))
+	for i, g := range grads {
+		analytic[i] = append([]float64(nil), g.Data...)
+	}
+
+	objective := func() float64 {
+		sum := 0.0
+		for k, v := range forward().Value.Data {
+			sum += v * weights.Data[k]
+		}
+		return sum
+	}
+
+	for i, p := range params {
+		for k, v := range p.Data {
+
+			p.Data[k] = v + gradCheckEpsilon
+			plus := objective()
+			p.Data[k] = v - gradCheckEpsilon
+			minus := objective()
+			p.Data[k] = v
+
+			numeric := (plus - minus) / (2 * gradCheckEpsilon)
+
+			if d := math.Abs(numeric - analytic[i][k]); d > gradCheckTolerance*math.Max(1, math.Abs(numeric)) {
+				t.Errorf("param %d[%d]: gradient %v, finite differences give %v", i, k, analytic[i][k], numeric)
+			}
+		}
+	}
+}
+
+func TestOpGradients(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+
+	// probabilities returns rows of positive values summing to 1, as targets of cross-entropies
+	probabilities := func(rows, cols int) [][]float64 {
+		targets := make([][]float64, rows)
+		for i := range targets {
+			targets[i] = make([]float64, cols)
+			sum := 0.0
+			for j := range targets[i] {
+				targets[i][j] = rng.Float64() + 0.1
+				sum += targets[i][j]
+			}
+			for j := range targets[i] {
+				targets[i][j] /= sum
+			}
+		}
+		return targets
+	}
+
+	classes := [][]float64{{2}, {0}, {3}}
+	binary := [][]float64{{1, 0, 1, 1}, {0, 0, 1, 0}, {1, 1, 0, 0}}
+	values := probabilities(3, 4)
+	soft := probabilities(3, 4)
+
+	// The padding steps of two sequences of 3: the last of the first, all of the second
+	mask := []bool{true, true, false, false, false, false}
+
+	tests := []struct {
+		name   string
+		shapes [][]int
+		record func(tp *tape, x []*node) (*node, error)
+	}{
+		{"matmul", [][]int{{3, 4}, {4, 2}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.MatMul(x[0], x[1], false, false)
+		}},
+		{"matmul transposing a", [][]int{{4, 3}, {4, 2}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.MatMul(x[0], x[1], true, false)
+		}},
+		{"matmul transposing b", [][]int{{3, 4}, {2, 4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.MatMul(x[0], x[1], false, true)
+		}},
+		{"matmul transposing both", [][]int{{4, 3}, {2, 4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.MatMul(x[0], x[1], true, true)
+		}},
+		{"matmul of a value by itself", [][]int{{3, 3}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.MatMul(x[0], x[0], false, true)
+		}},
+		{"add row", [][]int{{3, 4}, {4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.AddRow(x[0], x[1])
+		}},
+		{"mul", [][]int{{3, 4}, {3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Mul(x[0], x[1])
+		}},
+		{"square", [][]int{{3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Mul(x[0], x[0])
+		}},
+		{"add", [][]int{{2, 3, 2}, {2, 3, 2}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Add(x[0], x[1])
+		}},
+		{"sub", [][]int{{3, 4}, {3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Sub(x[0], x[1])
+		}},
+		{"reshape", [][]int{{2, 6}, {3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			r, err := tp.Reshape(x[0], 3, 4)
+			if err != nil {
+				return nil, err
+			}
+			return tp.Mul(r, x[1])
+		}},
+		{"slice", [][]int{{3, 5}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Slice(x[0], 1, 4)
+		}},
+		{"overlapping slices", [][]int{{3, 5}}, func(tp *tape, x []*node) (*node, error) {
+			a, err := tp.Slice(x[0], 0, 3)
+			if err != nil {
+				return nil, err
+			}
+			b, err := tp.Slice(x[0], 2, 5)
+			if err != nil {
+				return nil, err
+			}
+			return tp.Mul(a, b)
+		}},
+		{"concat", [][]int{{3, 2}, {3, 3}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Concat(x[0], x[1], x[0])
+		}},
+		{"layer norm", [][]int{{4, 5}, {5}, {5}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.LayerNorm(x[0], x[1], x[2], 1e-5)
+		}},
+		{"batch norm while training", [][]int{{6, 3}, {3}, {3}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.BatchNorm(x[0], x[1], x[2], make([]float64, 3), []float64{1, 1, 1}, true, 0.1, 1e-5)
+		}},
+		{"batch norm with running statistics", [][]int{{6, 3}, {3}, {3}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.BatchNorm(x[0], x[1], x[2], []float64{0.5, -1, 0}, []float64{2, 0.5, 1}, false, 0.1, 1e-5)
+		}},
+		{"im2col", [][]int{{2, 5, 4, 3}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Im2Col(x[0], autodiff.Window{Kernel: [2]int{3, 2}, Stride: [2]int{2, 1}, Padding: [2]int{1, 1}})
+		}},
+		{"max pool", [][]int{{2, 5, 4, 3}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.MaxPool2D(x[0], autodiff.Window{Kernel: [2]int{2, 2}, Stride: [2]int{2, 1}, Padding: [2]int{1, 1}})
+		}},
+		{"average pool", [][]int{{2, 5, 4, 3}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.AvgPool2D(x[0], autodiff.Window{Kernel: [2]int{3, 2}, Stride: [2]int{1, 2}, Padding: [2]int{1, 1}})
+		}},
+		{"attention", [][]int{{6, 4}, {6, 4}, {6, 4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Attention(x[0], x[1], x[2], 3, 2, nil)
+		}},
+		{"self-attention over padded sequences", [][]int{{6, 4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Attention(x[0], x[0], x[0], 3, 1, mask)
+		}},
+		{"mean squared error", [][]int{{3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Loss(x[0], values, losses.MeanSquaredErrorLoss{}, 0.5)
+		}},
+		{"categorical cross-entropy of a softmax", [][]int{{3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			p, err := tp.Activate(x[0], activation.Softmax)
+			if err != nil {
+				return nil, err
+			}
+			return tp.Loss(p, soft, losses.CategoricalCrossEntropyLoss{}, 1.0/3)
+		}},
+		{"sparse categorical cross-entropy of a softmax", [][]int{{3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			p, err := tp.Activate(x[0], activation.Softmax)
+			if err != nil {
+				return nil, err
+			}
+			return tp.Loss(p, classes, losses.SparseCategoricalCrossEntropyLoss{}, 1)
+		}},
+		{"kl divergence of a softmax", [][]int{{3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			p, err := tp.Activate(x[0], activation.Softmax)
+			if err != nil {
+				return nil, err
+			}
+			return tp.Loss(p, soft, losses.KLDivergenceLoss{}, 1)
+		}},
+		{"binary cross-entropy of a sigmoid", [][]int{{3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			p, err := tp.Activate(x[0], activation.Sigmoid)
+			if err != nil {
+				return nil, err
+			}
+			return tp.Loss(p, binary, losses.BinaryCrossEntropyLoss{}, 1)
+		}},
+	}
+
+	for _, name := range []activation.ActivationFunction{
+		activation.ReLU, activation.LeakyReLU, activation.ELU, activation.SELU, activation.GELU, activation.Swish,
+		activation.Softplus, activation.Linear, activation.Sigmoid, activation.Tanh, activation.Softmax,
+	} {
+		tests = append(tests, struct {
+			name   string
+			shapes [][]int
+			record func(tp *tape, x []*node) (*node, error)
+		}{"activate " + string(name), [][]int{{3, 4}}, func(tp *tape, x []*node) (*node, error) {
+			return tp.Activate(x[0], name)
+		}})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			params := make([]*vectors.Tensor[float64], len(tt.shapes))
+			for i, shape := range tt.shapes {
+				params[i] = randomTensor(rng, shape...)
+			}
+
+			checkOp(t, params, tt.record)
+		})
+	}
+}
+
+func TestBackward(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+
+	tp := autodiff.NewTape[float64](nil)
+
+	x := tp.Constant(randomTensor(rng, 3, 4))
+
+	w, err := tp.Param(randomTensor(rng, 2, 4), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A stale gradient from an earlier pass
+	stale := vectors.NewTensor[float64](5)
+	stale.Fill(7)
+
+	unused, err := tp.Param(randomTensor(rng, 5), stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := tp.MatMul(x, w, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tp.Backward(y); err != nil {
+		t.Fatal(err)
+	}
+
+	// With a seed of ones, the gradient of every weight row is the sum of the input rows
+	for j := range 2 {
+		for k := range 4 {
+			want := 0.0
+			for i := range 3 {
+				want += x.Value.Data[i*4+k]
+			}
+			if got := w.Grad.Data[j*4+k]; math.Abs(got-want) > 1e-12 {
+				t.Errorf("weight gradient [%d, %d] is %v, want %v", j, k, got, want)
+			}
+		}
+	}
+
+	if x.Grad != nil {
+		t.Errorf("a constant got a gradient")
+	}
+
+	for _, g := range unused.Grad.Data {
+		if g != 0 {
+			t.Fatalf("unused parameter has gradient %v, want zeros", unused.Grad.Data)
+		}
+	}
+
+	if err := tp.BackwardWith(y, vectors.NewTensor[float64](2, 3)); err == nil {
+		t.Errorf("BackwardWith accepted a seed of another shape")
+	}
+
+	tp.Reset()
+
+	if err := tp.Backward(y); err == nil {
+		t.Errorf("Backward accepted a node recorded before Reset")
+	}
+
+	if err := autodiff.NewTape[float64](nil).Backward(w); err == nil {
+		t.Errorf("Backward accepted a node of another tape")
+	}
+}
@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"fmt"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Backend is the set of array operations a network runs on. Model code only
+// calls these, so a backend backed by other hardware can be swapped in
+// through NeuralNetwork.Backend without touching the training code.
+//
+// All tensors are 2D, row-major and contiguous. Operations write into their
+// first argument and never allocate, apart from goroutines a backend may start.
+type Backend[T vectors.Float] interface {
+
+	// Name identifies the backend, e.g. in error messages and benchmarks
+	Name() string
+
+	// Gemm computes c = alpha * op(a) * op(b) + beta * c, where op transposes
+	// its argument when the matching trans flag is set. With beta 0 the
+	// previous contents of c are ignored.
+	Gemm(transA, transB bool, alpha T, a, b *vectors.Tensor[T], beta T, c *vectors.Tensor[T]) error
+
+	// AddRow adds row to every row of x
+	AddRow(x *vectors.Tensor[T], row []T) error
+
+	// Add adds x to dst element-wise
+	Add(dst, x []T) error
+
+	// Mul multiplies dst by x element-wise
+	Mul(dst, x []T) error
+
+	// Scale multiplies every element of x by alpha
+	Scale(x []T, alpha T)
+
+	// SumRows writes the sum of the rows of x, one value per column, to dst
+	SumRows(dst []T, x *vectors.Tensor[T]) error
+
+	// Activate writes the activation of x to dst. Softmax is applied to each row.
+	Activate(name activation.ActivationFunction, dst, x *vectors.Tensor[T]) error
+
+	// ActivationBackward multiplies delta element-wise by the derivative of
+	// the activation at the pre-activation values z. Softmax is not
+	// element-wise and is not supported.
+	ActivationBackward(name activation.ActivationFunction, delta, z *vectors.Tensor[T]) error
+}
+
+// Default returns the backend used by networks that do not set one
+func Default[T vectors.Float]() Backend[T] {
+	return CPU[T]{}
+}
+
+func checkLengths(op string, a, b int) error {
+	if a != b {
+		return fmt.Errorf("%s: lengths differ, %d and %d", op, a, b)
+	}
+	return nil
+}
+
+func checkMatrix[T vectors.Float](op string, x *vectors.Tensor[T]) error {
+	if x.Dims() != 2 {
+		return fmt.Errorf("%s needs a 2D tensor, got shape %v", op, x.Shape)
+	}
+	return nil
+}
+
+// activationFuncs resolves the function and derivative registered under name
+func activationFuncs(name activation.ActivationFunction) (activation.Activation, error) {
+	act, ok := activation.Get(name)
+	if !ok {
+		return activation.Activation{}, fmt.Errorf("unsupported activation function: %s", name)
+	}
+	return act, nil
+}
+
+// checkActivation validates the operands of Activate and ActivationBackward
+func checkActivation[T vectors.Float](op string, dst, x *vectors.Tensor[T]) error {
+	if err := checkMatrix(op, dst); err != nil {
+		return err
+	}
+	if !dst.SameShape(x) {
+		return fmt.Errorf("%s: shapes differ, %v and %v", op, dst.Shape, x.Shape)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package backend_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/backend"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Shapes cover empty-ish, odd and large operands. The large ones are above
+// the CPU backend's thresholds, so its multi-goroutine paths are exercised.
+var shapes = [][2]int{{1, 1}, {3, 5}, {17, 9}, {64, 130}, {300, 257}}
+
+var activations = []activation.ActivationFunction{
+	activation.ReLU, activation.LeakyReLU, activation.LeakyReLUWithSlope(0.2), activation.ELU,
+	activation.SELU, activation.GELU, activation.Swish, activation.SiLU, activation.Softplus,
+	activation.HardSigmoid, activation.Linear, activation.Sigmoid, activation.Tanh, activation.Softmax,
+}
+
+func TestCPUConformsToReference(t *testing.T) {
+
+	// Allow several goroutines even on a single core machine
+	defer func(procs int) { vectors.MaxProcs = procs }(vectors.MaxProcs)
+	vectors.MaxProcs = 4
+
+	t.Run("float64", func(t *testing.T) {
+		testConformance[float64](t, backend.CPU[float64]{}, 1e-12)
+	})
+	t.Run("float32", func(t *testing.T) {
+		testConformance[float32](t, backend.CPU[float32]{}, 1e-4)
+	})
+}
+
+// testConformance checks every operation of candidate against the reference
+// backend on the same random operands. tol bounds the difference relative to
+// the magnitude of the reference result.
+func testConformance[T vectors.Float](t *testing.T, candidate backend.Backend[T], tol float64) {
+
+	reference := backend.Reference[T]{}
+	rng := rand.New(rand.NewSource(1))
+
+	// run applies op to a copy of dst on each backend and compares the results
+	run := func(t *testing.T, dst *vectors.Tensor[T], op func(b backend.Backend[T], dst *vectors.Tensor[T]) error) {
+		t.Helper()
+
+		want, got := dst.Clone(), dst.Clone()
+
+		if err := op(reference, want); err != nil {
+			t.Fatalf("reference: %v", err)
+		}
+		if err := op(candidate, got); err != nil {
+			t.Fatalf("%s: %v", candidate.Name(), err)
+		}
+
+		assertClose(t, got.Data, want.Data, tol)
+	}
+
+	t.Run("gemm", func(t *testing.T) {
+		for _, shape := range shapes {
+			m, n := shape[0], shape[1]
+			k := n/2 + 1
+			for _, trans := range [][2]bool{{false, false}, {false, true}, {true, false}, {true, true}} {
+				for _, coeffs := range [][2]T{{1, 0}, {0.5, 1}, {-2, 0.25}} {
+
+					ta, tb := trans[0], trans[1]
+					alpha, beta := coeffs[0], coeffs[1]
+
+					a := randomTensor[T](rng, orient(m, k, ta))
+					b := randomTensor[T](rng, orient(k, n, tb))
+
+					name := fmt.Sprintf("%dx%dx%d/trans=%v,%v/alpha=%v,beta=%v", m, k, n, ta, tb, alpha, beta)
+					t.Run(name, func(t *testing.T) {
+						run(t, randomTensor[T](rng, [2]int{m, n}), func(be backend.Backend[T], c *vectors.Tensor[T]) error {
+							return be.Gemm(ta, tb, alpha, a, b, beta, c)
+						})
+					})
+				}
+			}
+		}
+	})
+
+	for _, shape := range shapes {
+
+		x := randomTensor[T](rng, shape)
+		row := randomTensor[T](rng, [2]int{1, shape[1]}).Data
+
+		t.Run(fmt.Sprintf("elementwise/%dx%d", shape[0], shape[1]), func(t *testing.T) {
+
+			run(t, randomTensor[T](rng, shape), func(be backend.Backend[T], dst *vectors.Tensor[T]) error {
+				return be.AddRow(dst, row)
+			})
+			run(t, randomTensor[T](rng, shape), func(be backend.Backend[T], dst *vectors.Tensor[T]) error {
+				return be.Add(dst.Data, x.Data)
+			})
+			run(t, randomTensor[T](rng, shape), func(be backend.Backend[T], dst *vectors.Tensor[T]) error {
+				return be.Mul(dst.Data, x.Data)
+			})
+			run(t, randomTensor[T](rng, shape), func(be backend.Backend[T], dst *vectors.Tensor[T]) error {
+				be.Scale(dst.Data, -0.75)
+				return nil
+			})
+		})
+
+		t.Run(fmt.Sprintf("sum rows/%dx%d", shape[0], shape[1]), func(t *testing.T) {
+			run(t, randomTensor[T](rng, [2]int{1, shape[1]}), func(be backend.Backend[T], dst *vectors.Tensor[T]) error {
+				return be.SumRows(dst.Data, x)
+			})
+		})
+
+		for _, name := range activations {
+			t.Run(fmt.Sprintf("activate/%s/%dx%d", name, shape[0], shape[1]), func(t *testing.T) {
+				run(t, vectors.NewTensor[T](shape[0], shape[1]), func(be backend.Backend[T], dst *vectors.Tensor[T]) error {
+					return be.Activate(name, dst, x)
+				})
+			})
+
+			if name == activation.Softmax {
+				continue
+			}
+
+			t.Run(fmt.Sprintf("activation backward/%s/%dx%d", name, shape[0], shape[1]), func(t *testing.T) {
+				run(t, randomTensor[T](rng, shape), func(be backend.Backend[T], delta *vectors.Tensor[T]) error {
+					return be.ActivationBackward(name, delta, x)
+				})
+			})
+		}
+	}
+
+	t.Run("errors", func(t *testing.T) {
+
+		a := vectors.NewTensor[T](2, 3)
+		b := vectors.NewTensor[T](4, 5)
+		v := vectors.NewTensor[T](2, 3, 1)
+
+		for _, be := range []backend.Backend[T]{reference, candidate} {
+
+			checks := map[string]error{
+				"gemm shape mismatch":      be.Gemm(false, false, 1, a, b, 0, a),
+				"gemm 3D operand":          be.Gemm(false, false, 1, v, b, 0, a),
+				"add row length":           be.AddRow(a, make([]T, 2)),
+				"add length":               be.Add(a.Data, b.Data),
+				"mul length":               be.Mul(a.Data, b.Data),
+				"sum rows length":          be.SumRows(make([]T, 2), a),
+				"activate shapes":          be.Activate(activation.ReLU, a, b),
+				"unknown activation":       be.Activate("unknown", a, a),
+				"softmax backward":         be.ActivationBackward(activation.Softmax, a, a),
+				"unknown backward":         be.ActivationBackward("unknown", a, a),
+				"activation backward dims": be.ActivationBackward(activation.ReLU, v, v),
+			}
+
+			for check, err := range checks {
+				if err == nil {
+					t.Errorf("%s: %s returned no error", be.Name(), check)
+				}
+			}
+		}
+	})
+}
+
+// orient returns the stored shape of a rows x cols operand, transposed when trans is set
+func orient(rows, cols int, trans bool) [2]int {
+	if trans {
+		return [2]int{cols, rows}
+	}
+	return [2]int{rows, cols}
+}
+
+func randomTensor[T vectors.Float](rng *rand.Rand, shape [2]int) *vectors.Tensor[T] {
+	x := vectors.NewTensor[T](shape[0], shape[1])
+	for k := range x.Data {
+		x.Data[k] = T(3 * rng.NormFloat64())
+	}
+	return x
+}
+
+func assertClose[T vectors.Float](t *testing.T, got, want []T, tol float64) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+
+	for k := range want {
+		g, w := float64(got[k]), float64(want[k])
+		if math.Abs(g-w) > tol*(1+math.Abs(w)) {
+			t.Fatalf("element %d: got %v, want %v", k, g, w)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package backend
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Number of elements below which an operation runs on the calling goroutine.
+// Activations call into math functions, so they split earlier than plain arithmetic.
+const (
+	elementThreshold    = 1 << 15
+	activationThreshold = 1 << 12
+)
+
+// CPU is the default backend. Matrix products use the cache-blocked
+// vectors.Gemm and large element-wise operations are split across
+// goroutines, up to vectors.MaxProcs. Every output element is computed by a
+// single goroutine in a fixed order, so results do not depend on the number
+// of goroutines.
+type CPU[T vectors.Float] struct{}
+
+func (CPU[T]) Name() string { return "cpu" }
+
+func (CPU[T]) Gemm(transA, transB bool, alpha T, a, b *vectors.Tensor[T], beta T, c *vectors.Tensor[T]) error {
+	return vectors.Gemm(transA, transB, alpha, a, b, beta, c)
+}
+
+func (CPU[T]) AddRow(x *vectors.Tensor[T], row []T) error {
+
+	if err := checkMatrix("add row", x); err != nil {
+		return err
+	}
+	if err := checkLengths("add row", x.Shape[1], len(row)); err != nil {
+		return err
+	}
+
+	if w := workers(x.Len(), elementThreshold); w > 1 {
+		parallel(x.Shape[0], w, func(start, end int) { addRow(x, row, start, end) })
+		return nil
+	}
+
+	addRow(x, row, 0, x.Shape[0])
+	return nil
+}
+
+func (CPU[T]) Add(dst, x []T) error {
+
+	if err := checkLengths("add", len(dst), len(x)); err != nil {
+		return err
+	}
+
+	if w := workers(len(dst), elementThreshold); w > 1 {
+		parallel(len(dst), w, func(start, end int) { add(dst[start:end], x[start:end]) })
+		return nil
+	}
+
+	add(dst, x)
+	return nil
+}
+
+func (CPU[T]) Mul(dst, x []T) error {
+
+	if err := checkLengths("mul", len(dst), len(x)); err != nil {
+		return err
+	}
+
+	if w := workers(len(dst), elementThreshold); w > 1 {
+		parallel(len(dst), w, func(start, end int) { mul(dst[start:end], x[start:end]) })
+		return nil
+	}
+
+	mul(dst, x)
+	return nil
+}
+
+func (CPU[T]) Scale(x []T, alpha T) {
+
+	if w := workers(len(x), elementThreshold); w > 1 {
+		parallel(len(x), w, func(start, end int) { scale(x[start:end], alpha) })
+		return
+	}
+
+	scale(x, alpha)
+}
+
+func (CPU[T]) SumRows(dst []T, x *vectors.Tensor[T]) error {
+
+	if err := checkMatrix("sum rows", x); err != nil {
+		return err
+	}
+	if err := checkLengths("sum rows", len(dst), x.Shape[1]); err != nil {
+		return err
+	}
+
+	// Columns are split across goroutines, each column is summed down the rows
+	if w := workers(x.Len(), elementThreshold); w > 1 {
+		parallel(len(dst), w, func(start, end int) { sumRows(dst, x, start, end) })
+		return nil
+	}
+
+	sumRows(dst, x, 0, len(dst))
+	return nil
+}
+
+func (CPU[T]) Activate(name activation.ActivationFunction, dst, x *vectors.Tensor[T]) error {
+
+	if err := checkActivation("activate", dst, x); err != nil {
+		return err
+	}
+
+	if name == activation.Softmax {
+
+		if w := workers(x.Len(), activationThreshold); w > 1 {
+			parallel(x.Shape[0], w, func(start, end int) { softmaxRows(dst, x, start, end) })
+			return nil
+		}
+
+		softmaxRows(dst, x, 0, x.Shape[0])
+		return nil
+	}
+
+	act, err := activationFuncs(name)
+	if err != nil {
+		return err
+	}
+
+	if w := workers(x.Len(), activationThreshold); w > 1 {
+		parallel(x.Len(), w, func(start, end int) { apply(act.Func, dst.Data[start:end], x.Data[start:end]) })
+		return nil
+	}
+
+	apply(act.Func, dst.Data, x.Data)
+	return nil
+}
+
+func (CPU[T]) ActivationBackward(name activation.ActivationFunction, delta, z *vectors.Tensor[T]) error {
+
+	if err := checkActivation("activation backward", delta, z); err != nil {
+		return err
+	}
+
+	if name == activation.Softmax {
+		return fmt.Errorf("activation backward: softmax is not element-wise")
+	}
+
+	act, err := activationFuncs(name)
+	if err != nil {
+		return fmt.Errorf("unsupported activation function for backpropagation: %s", name)
+	}
+
+	if w := workers(z.Len(), activationThreshold); w > 1 {
+		parallel(z.Len(), w, func(start, end int) { mulApply(act.Derivative, delta.Data[start:end], z.Data[start:end]) })
+		return nil
+	}
+
+	mulApply(act.Derivative, delta.Data, z.Data)
+	return nil
+}
+
+// workers returns the number of goroutines for an operation over n elements
+func workers(n, threshold int) int {
+	procs := vectors.MaxProcs
+	if procs <= 0 {
+		procs = runtime.GOMAXPROCS(0)
+	}
+	return min(procs, max(1, n/threshold))
+}
+
+// parallel splits [0, n) into one contiguous chunk per worker and waits for fn to finish on all of them
+func parallel(n, workers int, fn func(start, end int)) {
+
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+
+	for start := 0; start < n; start += chunk {
+		end := min(start+chunk, n)
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, end)
+	}
+
+	wg.Wait()
+}
+
+func addRow[T vectors.Float](x *vectors.Tensor[T], row []T, start, end int) {
+	for i := start; i < end; i++ {
+		add(x.Row(i), row)
+	}
+}
+
+func add[T vectors.Float](dst, x []T) {
+	x = x[:len(dst)]
+	for k := range dst {
+		dst[k] += x[k]
+	}
+}
+
+func mul[T vectors.Float](dst, x []T) {
+	x = x[:len(dst)]
+	for k := range dst {
+		dst[k] *= x[k]
+	}
+}
+
+func scale[T vectors.Float](x []T, alpha T) {
+	for k := range x {
+		x[k] *= alpha
+	}
+}
+
+// sumRows writes the sums of columns [start, end) of x to dst
+func sumRows[T vectors.Float](dst []T, x *vectors.Tensor[T], start, end int) {
+	out := dst[start:end]
+	clear(out)
+	for i := 0; i < x.Shape[0]; i++ {
+		add(out, x.Row(i)[start:end])
+	}
+}
+
+func softmaxRows[T vectors.Float](dst, x *vectors.Tensor[T], start, end int) {
+	for i := start; i < end; i++ {
+		activation.SoftmaxInto(dst.Row(i), x.Row(i))
+	}
+}
+
+// apply writes f(x) to dst
+func apply[T vectors.Float](f func(float64) float64, dst, x []T) {
+	x = x[:len(dst)]
+	for k := range dst {
+		dst[k] = T(f(float64(x[k])))
+	}
+}
+
+// mulApply multiplies dst by f(x)
+func mulApply[T vectors.Float](f func(float64) float64, dst, x []T) {
+	x = x[:len(dst)]
+	for k := range dst {
+		dst[k] *= T(f(float64(x[k])))
+	}
+}
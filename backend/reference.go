@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"fmt"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Reference is a straightforward single-goroutine backend. It is the
+// specification other backends are checked against, not meant for training.
+type Reference[T vectors.Float] struct{}
+
+func (Reference[T]) Name() string { return "reference" }
+
+func (Reference[T]) Gemm(transA, transB bool, alpha T, a, b *vectors.Tensor[T], beta T, c *vectors.Tensor[T]) error {
+
+	for _, x := range []*vectors.Tensor[T]{a, b, c} {
+		if err := checkMatrix("gemm", x); err != nil {
+			return err
+		}
+	}
+
+	// at reads op(x)[i][j]
+	at := func(x *vectors.Tensor[T], trans bool, i, j int) T {
+		if trans {
+			return x.At(j, i)
+		}
+		return x.At(i, j)
+	}
+
+	m, k := a.Shape[0], a.Shape[1]
+	if transA {
+		m, k = k, m
+	}
+
+	kb, n := b.Shape[0], b.Shape[1]
+	if transB {
+		kb, n = n, kb
+	}
+
+	if k != kb || c.Shape[0] != m || c.Shape[1] != n {
+		return fmt.Errorf("gemm shape mismatch: op(a) is %dx%d, op(b) is %dx%d, c is %v", m, k, kb, n, c.Shape)
+	}
+
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+
+			var sum T
+			for p := 0; p < k; p++ {
+				sum += at(a, transA, i, p) * at(b, transB, p, j)
+			}
+
+			value := alpha * sum
+			if beta != 0 {
+				value += beta * c.At(i, j)
+			}
+			c.Set(value, i, j)
+		}
+	}
+
+	return nil
+}
+
+func (Reference[T]) AddRow(x *vectors.Tensor[T], row []T) error {
+	if err := checkMatrix("add row", x); err != nil {
+		return err
+	}
+	if err := checkLengths("add row", x.Shape[1], len(row)); err != nil {
+		return err
+	}
+	for i := 0; i < x.Shape[0]; i++ {
+		for j, v := range row {
+			x.Set(x.At(i, j)+v, i, j)
+		}
+	}
+	return nil
+}
+
+func (Reference[T]) Add(dst, x []T) error {
+	if err := checkLengths("add", len(dst), len(x)); err != nil {
+		return err
+	}
+	for k := range dst {
+		dst[k] += x[k]
+	}
+	return nil
+}
+
+func (Reference[T]) Mul(dst, x []T) error {
+	if err := checkLengths("mul", len(dst), len(x)); err != nil {
+		return err
+	}
+	for k := range dst {
+		dst[k] *= x[k]
+	}
+	return nil
+}
+
+func (Reference[T]) Scale(x []T, alpha T) {
+	for k := range x {
+		x[k] *= alpha
+	}
+}
+
+func (Reference[T]) SumRows(dst []T, x *vectors.Tensor[T]) error {
+	if err := checkMatrix("sum rows", x); err != nil {
+		return err
+	}
+	if err := checkLengths("sum rows", len(dst), x.Shape[1]); err != nil {
+		return err
+	}
+	for j := range dst {
+		var sum T
+		for i := 0; i < x.Shape[0]; i++ {
+			sum += x.At(i, j)
+		}
+		dst[j] = sum
+	}
+	return nil
+}
+
+func (Reference[T]) Activate(name activation.ActivationFunction, dst, x *vectors.Tensor[T]) error {
+
+	if err := checkActivation("activate", dst, x); err != nil {
+		return err
+	}
+
+	if name == activation.Softmax {
+		for i := 0; i < x.Shape[0]; i++ {
+			activation.SoftmaxInto(dst.Row(i), x.Row(i))
+		}
+		return nil
+	}
+
+	act, err := activationFuncs(name)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range x.Data {
+		dst.Data[k] = T(act.Func(float64(v)))
+	}
+
+	return nil
+}
+
+func (Reference[T]) ActivationBackward(name activation.ActivationFunction, delta, z *vectors.Tensor[T]) error {
+
+	if err := checkActivation("activation backward", delta, z); err != nil {
+		return err
+	}
+
+	if name == activation.Softmax {
+		return fmt.Errorf("activation backward: softmax is not element-wise")
+	}
+
+	act, err := activationFuncs(name)
+	if err != nil {
+		return fmt.Errorf("unsupported activation function for backpropagation: %s", name)
+	}
+
+	for k, v := range z.Data {
+		delta.Data[k] *= T(act.Derivative(float64(v)))
+	}
+
+	return nil
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/ThakurMayank5/gonn/dataset"
+	"github.com/ThakurMayank5/gonn/vectors"
 )
 
 // FromCSV loads a Dataset from a CSV file using the provided CSVConfig.
@@ -27,6 +29,10 @@ import (
 //
 // Modes can be combined: TargetColumns and LabelColumn can both be active at the
 // same time — numeric targets are written first, then the one-hot vector is appended.
+//
+// Input columns listed in CategoricalColumns are read as categories and
+// replaced by their IDs; the dataset's Categories records which ID stands
+// for which value.
 func FromCSV(filePath string, config dataset.CSVConfig) (dataset.Dataset, error) {
 
 	file, err := os.Open(filePath)
@@ -105,6 +111,12 @@ func FromCSV(filePath string, config dataset.CSVConfig) (dataset.Dataset, error)
 		}
 	}
 
+	// --- Category → ID maps of the categorical input columns ---
+	categories, features, err := readCategories(records[startRow:], config)
+	if err != nil {
+		return dataset.Dataset{}, err
+	}
+
 	// --- Second pass: build inputs and outputs ---
 	var inputs [][]float64
 	var outputs [][]float64
@@ -118,6 +130,11 @@ func FromCSV(filePath string, config dataset.CSVConfig) (dataset.Dataset, error)
 			if col >= len(row) {
 				return dataset.Dataset{}, fmt.Errorf("row %d: input column %d is out of range", i, col)
 			}
+			if ids, ok := categories[col]; ok {
+				// Unknown categories map to 0
+				input = append(input, float64(ids[strings.TrimSpace(row[col])]))
+				continue
+			}
 			val, err := strconv.ParseFloat(strings.TrimSpace(row[col]), 64)
 			if err != nil {
 				return dataset.Dataset{}, fmt.Errorf("row %d, column %d: cannot parse %q as float: %v", i, col, row[col], err)
@@ -174,9 +191,9 @@ func FromCSV(filePath string, config dataset.CSVConfig) (dataset.Dataset, error)
 	// Apply input scaling if requested
 	switch config.Scaling {
 	case dataset.MinMaxNormalize:
-		minMaxNormalize(inputs)
+		minMaxNormalize(inputs, features)
 	case dataset.ZScoreStandardize:
-		zScoreStandardize(inputs)
+		zScoreStandardize(inputs, features)
 	}
 
 	return dataset.Dataset{
@@ -185,17 +202,83 @@ func FromCSV(filePath string, config dataset.CSVConfig) (dataset.Dataset, error)
 		NumSamples:  len(inputs),
 		NumFeatures: len(config.InputColumns),
 		NumOutputs:  numOutputs,
+		Categories:  features,
 	}, nil
 }
 
-// minMaxNormalize scales each feature column to [0, 1].
+// FromCSVOf is FromCSV for a dataset of element type T. Values are parsed and
+// scaled in float64, then converted to T.
+func FromCSVOf[T vectors.Float](filePath string, config dataset.CSVConfig) (dataset.DatasetOf[T], error) {
+
+	ds, err := FromCSV(filePath, config)
+	if err != nil {
+		return dataset.DatasetOf[T]{}, err
+	}
+
+	return dataset.Convert[T](ds), nil
+}
+
+// readCategories numbers the categories of every categorical input column
+// of the data rows. It returns the category → ID map of each column, by
+// column index, and the categories of each categorical feature, by feature
+// index, or nil maps when there are no categorical columns.
+func readCategories(rows [][]string, config dataset.CSVConfig) (map[int]map[string]int, map[int][]string, error) {
+
+	if len(config.CategoricalColumns) == 0 {
+		return nil, nil, nil
+	}
+
+	ids := map[int]map[string]int{}
+	features := map[int][]string{}
+
+	for _, col := range config.CategoricalColumns {
+
+		feature := slices.Index(config.InputColumns, col)
+		if feature < 0 {
+			return nil, nil, fmt.Errorf("categorical column %d is not an input column", col)
+		}
+
+		names, known := config.Categories[col]
+
+		if !known {
+			seen := map[string]bool{}
+			for i, row := range rows {
+				if col >= len(row) {
+					return nil, nil, fmt.Errorf("data row %d: categorical column %d is out of range", i, col)
+				}
+				name := strings.TrimSpace(row[col])
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+		}
+
+		// ID 0 is kept for categories missing from names
+		ids[col] = make(map[string]int, len(names))
+		for i, name := range names {
+			ids[col][name] = i + 1
+		}
+
+		features[feature] = names
+	}
+
+	return ids, features, nil
+}
+
+// minMaxNormalize scales each feature column to [0, 1], leaving the
+// categorical features alone.
 // x' = (x - min) / (max - min)
-func minMaxNormalize(inputs [][]float64) {
+func minMaxNormalize(inputs [][]float64, categorical map[int][]string) {
 	if len(inputs) == 0 {
 		return
 	}
 	numFeatures := len(inputs[0])
 	for f := 0; f < numFeatures; f++ {
+		if _, ok := categorical[f]; ok {
+			continue
+		}
 		min, max := inputs[0][f], inputs[0][f]
 		for _, row := range inputs {
 			if row[f] < min {
@@ -216,15 +299,19 @@ func minMaxNormalize(inputs [][]float64) {
 	}
 }
 
-// zScoreStandardize scales each feature column to mean=0, std=1.
+// zScoreStandardize scales each feature column to mean=0, std=1, leaving
+// the categorical features alone.
 // x' = (x - mean) / std
-func zScoreStandardize(inputs [][]float64) {
+func zScoreStandardize(inputs [][]float64, categorical map[int][]string) {
 	if len(inputs) == 0 {
 		return
 	}
 	n := float64(len(inputs))
 	numFeatures := len(inputs[0])
 	for f := 0; f < numFeatures; f++ {
+		if _, ok := categorical[f]; ok {
+			continue
+		}
 		mean := 0.0
 		for _, row := range inputs {
 			mean += row[f]
@@ -43,6 +43,19 @@ type CSVConfig struct {
 	// Set to 0 to let the loader auto-detect unique labels from the data.
 	NumClasses int
 
+	// CategoricalColumns are input columns holding categories, such as IDs
+	// or names, rather than numbers, and must also be listed in InputColumns.
+	// Each value is replaced by the ID of its category, 1 + its index in the
+	// column's categories, or 0 for a value not among them, so the feature
+	// can feed an Embedding layer. Categorical features are never scaled.
+	CategoricalColumns []int
+
+	// Categories gives the known categories of categorical columns by column
+	// index, such as the Categories of the training set when loading a test
+	// set. The categories of other categorical columns are the distinct
+	// values found in the file, sorted.
+	Categories map[int][]string
+
 	// Scaling controls whether and how input features are scaled after loading.
 	// Use MinMaxNormalize or ZScoreStandardize. Defaults to NoScaling.
 	Scaling ScalingMethod
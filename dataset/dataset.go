@@ -1,9 +1,56 @@
 package dataset
 
-type Dataset struct {
-	Inputs      [][]float64
-	Outputs     [][]float64
+import "github.com/ThakurMayank5/gonn/vectors"
+
+// DatasetOf holds samples whose values have element type T (float32 or float64)
+type DatasetOf[T vectors.Float] struct {
+	Inputs      [][]T
+	Outputs     [][]T
 	NumSamples  int
 	NumFeatures int
 	NumOutputs  int
+
+	// Timesteps is the length of every sequence when each input row holds a
+	// [Timesteps, NumFeatures] sequence flattened step by step, zero when the
+	// inputs are plain feature vectors
+	Timesteps int
+
+	// Categories lists the categories of every categorical input feature by
+	// feature index, nil when every feature is numeric. A categorical feature
+	// holds 1 + the index of its category, or 0 for an unknown one.
+	Categories map[int][]string
+}
+
+// Dataset is a float64 dataset
+type Dataset = DatasetOf[float64]
+
+// Vocabulary returns the number of IDs of categorical feature f, counting
+// the 0 of unknown categories, which is the Vocabulary of an Embedding of it
+func (d DatasetOf[T]) Vocabulary(f int) int {
+	return len(d.Categories[f]) + 1
+}
+
+// Convert returns a copy of d with every value converted to T
+func Convert[T, U vectors.Float](d DatasetOf[U]) DatasetOf[T] {
+	return DatasetOf[T]{
+		Inputs:      convertRows[T](d.Inputs),
+		Outputs:     convertRows[T](d.Outputs),
+		NumSamples:  d.NumSamples,
+		NumFeatures: d.NumFeatures,
+		NumOutputs:  d.NumOutputs,
+		Timesteps:   d.Timesteps,
+		Categories:  d.Categories,
+	}
+}
+
+func convertRows[T, U vectors.Float](rows [][]U) [][]T {
+	if rows == nil {
+		return nil
+	}
+	out := make([][]T, len(rows))
+	for i, row := range rows {
+		out[i] = make([]T, len(row))
+		vectors.Convert(out[i], row)
+	}
+	return out
 }
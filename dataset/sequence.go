@@ -0,0 +1,109 @@
+package dataset
+
+import (
+	"fmt"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// FromSequences builds a dataset of sequence samples, for recurrent
+// networks. sequences[i] is sample i as a [timesteps][features] sequence and
+// targets[i] its output. Every sequence must have the same number of steps
+// and features; each is stored flattened step by step.
+func FromSequences[T vectors.Float](sequences [][][]T, targets [][]T) (DatasetOf[T], error) {
+
+	if len(sequences) == 0 {
+		return DatasetOf[T]{}, fmt.Errorf("no sequences given")
+	}
+
+	if len(sequences) != len(targets) {
+		return DatasetOf[T]{}, fmt.Errorf("got %d sequences but %d targets", len(sequences), len(targets))
+	}
+
+	timesteps := len(sequences[0])
+	if timesteps == 0 {
+		return DatasetOf[T]{}, fmt.Errorf("sequence 0 has no steps")
+	}
+
+	features := len(sequences[0][0])
+	outputs := len(targets[0])
+
+	inputs := make([][]T, len(sequences))
+
+	for i, sequence := range sequences {
+
+		if len(sequence) != timesteps {
+			return DatasetOf[T]{}, fmt.Errorf("sequence %d has %d steps, want %d", i, len(sequence), timesteps)
+		}
+
+		if len(targets[i]) != outputs {
+			return DatasetOf[T]{}, fmt.Errorf("target %d has %d values, want %d", i, len(targets[i]), outputs)
+		}
+
+		inputs[i] = make([]T, 0, timesteps*features)
+
+		for s, step := range sequence {
+			if len(step) != features {
+				return DatasetOf[T]{}, fmt.Errorf("sequence %d step %d has %d features, want %d", i, s, len(step), features)
+			}
+			inputs[i] = append(inputs[i], step...)
+		}
+	}
+
+	return DatasetOf[T]{
+		Inputs:      inputs,
+		Outputs:     targets,
+		NumSamples:  len(inputs),
+		NumFeatures: features,
+		NumOutputs:  outputs,
+		Timesteps:   timesteps,
+	}, nil
+}
+
+// Windows cuts a time series into overlapping samples for forecasting.
+// series[t] holds the features observed at time t. Every sample is window
+// consecutive steps and its target is the values of targetColumns at horizon
+// steps after the last of them, so horizon 1 predicts the next step.
+func Windows[T vectors.Float](series [][]T, window, horizon int, targetColumns []int) (DatasetOf[T], error) {
+
+	if window <= 0 || horizon <= 0 {
+		return DatasetOf[T]{}, fmt.Errorf("window and horizon must be positive, got %d and %d", window, horizon)
+	}
+
+	if len(targetColumns) == 0 {
+		return DatasetOf[T]{}, fmt.Errorf("no target columns given")
+	}
+
+	samples := len(series) - window - horizon + 1
+	if samples <= 0 {
+		return DatasetOf[T]{}, fmt.Errorf("series of %d steps is too short for windows of %d steps and horizon %d", len(series), window, horizon)
+	}
+
+	features := len(series[0])
+
+	for _, c := range targetColumns {
+		if c < 0 || c >= features {
+			return DatasetOf[T]{}, fmt.Errorf("target column %d is out of range for %d features", c, features)
+		}
+	}
+
+	sequences := make([][][]T, samples)
+	targets := make([][]T, samples)
+
+	for i := range sequences {
+
+		sequences[i] = series[i : i+window]
+
+		next := series[i+window+horizon-1]
+		if len(next) != features {
+			return DatasetOf[T]{}, fmt.Errorf("step %d has %d features, want %d", i+window+horizon-1, len(next), features)
+		}
+
+		targets[i] = make([]T, len(targetColumns))
+		for j, c := range targetColumns {
+			targets[i][j] = next[c]
+		}
+	}
+
+	return FromSequences(sequences, targets)
+}
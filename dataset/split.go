@@ -3,48 +3,54 @@ package dataset
 import (
 	"fmt"
 	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/vectors"
 )
 
-func SplitWithoutShuffle(dataset Dataset, trainRatio float64) (Dataset, Dataset, error) {
+func SplitWithoutShuffle[T vectors.Float](dataset DatasetOf[T], trainRatio float64) (DatasetOf[T], DatasetOf[T], error) {
 
 	if trainRatio <= 0 || trainRatio >= 1 {
-		return Dataset{}, Dataset{}, fmt.Errorf("trainRatio must be between 0 and 1")
+		return DatasetOf[T]{}, DatasetOf[T]{}, fmt.Errorf("trainRatio must be between 0 and 1")
 	}
 
 	if len(dataset.Inputs) == 0 {
-		return Dataset{}, Dataset{}, fmt.Errorf("dataset is empty")
+		return DatasetOf[T]{}, DatasetOf[T]{}, fmt.Errorf("dataset is empty")
 	}
 
 	totalSamples := len(dataset.Inputs)
 	trainSize := int(float64(totalSamples) * trainRatio)
 
-	trainDataset := Dataset{
+	trainDataset := DatasetOf[T]{
 		Inputs:      dataset.Inputs[:trainSize],
 		Outputs:     dataset.Outputs[:trainSize],
 		NumSamples:  trainSize,
 		NumFeatures: dataset.NumFeatures,
 		NumOutputs:  dataset.NumOutputs,
+		Timesteps:   dataset.Timesteps,
+		Categories:  dataset.Categories,
 	}
 
-	testDataset := Dataset{
+	testDataset := DatasetOf[T]{
 		Inputs:      dataset.Inputs[trainSize:],
 		Outputs:     dataset.Outputs[trainSize:],
 		NumSamples:  totalSamples - trainSize,
 		NumFeatures: dataset.NumFeatures,
 		NumOutputs:  dataset.NumOutputs,
+		Timesteps:   dataset.Timesteps,
+		Categories:  dataset.Categories,
 	}
 
 	return trainDataset, testDataset, nil
 }
 
-func SplitWithShuffle(dataset Dataset, trainRatio float64) (Dataset, Dataset, error) {
+func SplitWithShuffle[T vectors.Float](dataset DatasetOf[T], trainRatio float64) (DatasetOf[T], DatasetOf[T], error) {
 
 	if trainRatio <= 0 || trainRatio >= 1 {
-		return Dataset{}, Dataset{}, fmt.Errorf("trainRatio must be between 0 and 1")
+		return DatasetOf[T]{}, DatasetOf[T]{}, fmt.Errorf("trainRatio must be between 0 and 1")
 	}
 
 	if len(dataset.Inputs) == 0 {
-		return Dataset{}, Dataset{}, fmt.Errorf("dataset is empty")
+		return DatasetOf[T]{}, DatasetOf[T]{}, fmt.Errorf("dataset is empty")
 	}
 
 	totalSamples := len(dataset.Inputs)
@@ -59,20 +65,24 @@ func SplitWithShuffle(dataset Dataset, trainRatio float64) (Dataset, Dataset, er
 		indices[i], indices[j] = indices[j], indices[i]
 	})
 
-	trainDataset := Dataset{
-		Inputs:      make([][]float64, trainSize),
-		Outputs:     make([][]float64, trainSize),
+	trainDataset := DatasetOf[T]{
+		Inputs:      make([][]T, trainSize),
+		Outputs:     make([][]T, trainSize),
 		NumSamples:  trainSize,
 		NumFeatures: dataset.NumFeatures,
 		NumOutputs:  dataset.NumOutputs,
+		Timesteps:   dataset.Timesteps,
+		Categories:  dataset.Categories,
 	}
 
-	testDataset := Dataset{
-		Inputs:      make([][]float64, totalSamples-trainSize),
-		Outputs:     make([][]float64, totalSamples-trainSize),
+	testDataset := DatasetOf[T]{
+		Inputs:      make([][]T, totalSamples-trainSize),
+		Outputs:     make([][]T, totalSamples-trainSize),
 		NumSamples:  totalSamples - trainSize,
 		NumFeatures: dataset.NumFeatures,
 		NumOutputs:  dataset.NumOutputs,
+		Timesteps:   dataset.Timesteps,
+		Categories:  dataset.Categories,
 	}
 
 	for i, idx := range indices {
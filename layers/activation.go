@@ -0,0 +1,61 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Activation applies an activation function to its input. Softmax is taken
+// over all the values of a sample, every other function element-wise.
+type Activation[T vectors.Float] struct {
+	Function activation.ActivationFunction
+
+	taped[T]
+}
+
+func (a *Activation[T]) Build(input []int, rng *rand.Rand) error {
+	_, err := a.OutputShape(input)
+	return err
+}
+
+func (a *Activation[T]) OutputShape(input []int) ([]int, error) {
+	if a.Function == "" {
+		return nil, fmt.Errorf("activation: no activation function")
+	}
+	return input, nil
+}
+
+func (a *Activation[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	in, err := a.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := a.matrix(in)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := a.tape.Activate(m, a.Function)
+	if err != nil {
+		return nil, err
+	}
+
+	if out, err = a.restore(out); err != nil {
+		return nil, err
+	}
+
+	return a.end(out), nil
+}
+
+func (a *Activation[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (a *Activation[T]) Grads() []*vectors.Tensor[T] { return nil }
+
+func (a *Activation[T]) outputActivation() activation.ActivationFunction {
+	return a.Function
+}
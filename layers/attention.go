@@ -0,0 +1,542 @@
+package layers
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/autodiff"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// attention holds the projections of multi-head self-attention: w and b
+// stack the query, key and value projections, [3*dims, dims] and [3*dims],
+// and wo and bo project the concatenated heads back, [dims, dims] and [dims]
+type attention[T vectors.Float] struct {
+	heads int
+
+	w, b, wo, bo                 *vectors.Tensor[T]
+	gradW, gradB, gradWo, gradBo *vectors.Tensor[T]
+}
+
+// checkAttention returns an error unless input samples are [steps, dims]
+// sequences whose dims split into heads heads
+func checkAttention(layer string, input []int, heads int) error {
+
+	if len(input) != 2 {
+		return fmt.Errorf("%s: input samples must be [steps, dims] sequences, got shape %v", layer, input)
+	}
+
+	if heads <= 0 || input[1]%heads != 0 {
+		return fmt.Errorf("%s: %d dims do not split into %d heads", layer, input[1], heads)
+	}
+
+	return nil
+}
+
+func (a *attention[T]) build(dims, heads int, init Initialization, rng *rand.Rand) {
+
+	a.heads = heads
+
+	a.w = vectors.NewTensor[T](3*dims, dims)
+	a.b = vectors.NewTensor[T](3 * dims)
+	a.wo = vectors.NewTensor[T](dims, dims)
+	a.bo = vectors.NewTensor[T](dims)
+	a.gradW = vectors.NewTensor[T](3*dims, dims)
+	a.gradB = vectors.NewTensor[T](3 * dims)
+	a.gradWo = vectors.NewTensor[T](dims, dims)
+	a.gradBo = vectors.NewTensor[T](dims)
+
+	// Each of the three stacked projections maps dims values to dims
+	Initialize(a.w.Data, dims, dims, init, rng)
+	Initialize(a.wo.Data, dims, dims, init, rng)
+}
+
+// record records self-attention over x, the [batch*steps, dims] rows of
+// sequences of steps steps, on tape
+func (a *attention[T]) record(tape *autodiff.Tape[T], x *autodiff.Node[T], steps int, mask []bool) (*autodiff.Node[T], error) {
+
+	dims := a.wo.Shape[0]
+
+	w, err := tape.Param(a.w, a.gradW)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := tape.Param(a.b, a.gradB)
+	if err != nil {
+		return nil, err
+	}
+
+	wo, err := tape.Param(a.wo, a.gradWo)
+	if err != nil {
+		return nil, err
+	}
+
+	bo, err := tape.Param(a.bo, a.gradBo)
+	if err != nil {
+		return nil, err
+	}
+
+	qkv, err := tape.MatMul(x, w, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if qkv, err = tape.AddRow(qkv, b); err != nil {
+		return nil, err
+	}
+
+	var projections [3]*autodiff.Node[T]
+	for i := range projections {
+		if projections[i], err = tape.Slice(qkv, i*dims, (i+1)*dims); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := tape.Attention(projections[0], projections[1], projections[2], steps, a.heads, mask)
+	if err != nil {
+		return nil, err
+	}
+
+	if out, err = tape.MatMul(out, wo, false, true); err != nil {
+		return nil, err
+	}
+
+	return tape.AddRow(out, bo)
+}
+
+func (a *attention[T]) params() []*vectors.Tensor[T] {
+	return []*vectors.Tensor[T]{a.w, a.b, a.wo, a.bo}
+}
+
+func (a *attention[T]) grads() []*vectors.Tensor[T] {
+	return []*vectors.Tensor[T]{a.gradW, a.gradB, a.gradWo, a.gradBo}
+}
+
+// MultiHeadAttention is self-attention over [steps, dims] sequences. Every
+// step is projected to a query, a key and a value, which are split into
+// Heads heads of dims/Heads values; in each head every step attends to the
+// steps of its sequence with scaled dot-product attention. The heads are
+// concatenated and projected back, giving [steps, dims] samples.
+//
+// Padding steps flagged by the mask of an earlier layer, such as an
+// Embedding with MaskZero, are not attended to.
+type MultiHeadAttention[T vectors.Float] struct {
+	Heads int
+
+	// Initialization of the projections, Xavier Normal when empty. Biases start at zero.
+	Initialization Initialization
+
+	input []int
+	mask  []bool
+
+	attention[T]
+	taped[T]
+}
+
+func (m *MultiHeadAttention[T]) Build(input []int, rng *rand.Rand) error {
+
+	if err := checkAttention("multi-head attention", input, m.Heads); err != nil {
+		return err
+	}
+
+	m.input = append([]int(nil), input...)
+	m.build(input[1], m.Heads, m.Initialization, rng)
+
+	return nil
+}
+
+func (m *MultiHeadAttention[T]) OutputShape(input []int) ([]int, error) {
+	if err := checkAttention("multi-head attention", input, m.Heads); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (m *MultiHeadAttention[T]) SetMask(mask []bool) { m.mask = mask }
+
+func (m *MultiHeadAttention[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if m.w == nil {
+		return nil, fmt.Errorf("multi-head attention: layer is not built")
+	}
+
+	if err := checkSample("multi-head attention", x, m.input); err != nil {
+		return nil, err
+	}
+
+	batch, steps, dims := x.Shape[0], x.Shape[1], x.Shape[2]
+
+	in, err := m.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	if in, err = m.tape.Reshape(in, batch*steps, dims); err != nil {
+		return nil, err
+	}
+
+	out, err := m.record(&m.tape, in, steps, m.mask)
+	if err != nil {
+		return nil, err
+	}
+
+	if out, err = m.tape.Reshape(out, batch, steps, dims); err != nil {
+		return nil, err
+	}
+
+	return m.end(out), nil
+}
+
+func (m *MultiHeadAttention[T]) Params() []*vectors.Tensor[T] {
+	if m.w == nil {
+		return nil
+	}
+	return m.params()
+}
+
+func (m *MultiHeadAttention[T]) Grads() []*vectors.Tensor[T] {
+	if m.w == nil {
+		return nil
+	}
+	return m.grads()
+}
+
+// TransformerEncoder is an encoder block of a Transformer over [steps, dims]
+// sequences: multi-head self-attention, then a feed-forward network applied
+// to every step, each followed by dropout, a residual connection and layer
+// normalization of every step:
+//
+//	h = LayerNorm(x + Dropout(MultiHeadAttention(x)))
+//	out = LayerNorm(h + Dropout(activation(h·W1ᵀ + b1)·W2ᵀ + b2))
+//
+// Padding steps flagged by the mask of an earlier layer are not attended to.
+type TransformerEncoder[T vectors.Float] struct {
+	Heads int
+
+	// FeedForward is the width of the hidden layer of the feed-forward
+	// network, 4 times dims when zero
+	FeedForward int
+
+	// ActivationFunction of the feed-forward hidden layer, ReLU when empty
+	ActivationFunction activation.ActivationFunction
+
+	// Dropout is the rate of the dropout after the attention and the
+	// feed-forward network, applied while training only
+	Dropout float64
+
+	// Epsilon is added to the variance by the layer normalizations, 1e-5 when zero
+	Epsilon float64
+
+	// Initialization of the weights, Xavier Normal when empty. Biases start at zero.
+	Initialization Initialization
+
+	input []int
+	mask  []bool
+	rng   *rand.Rand
+	drop  [2]*vectors.Tensor[T]
+
+	attention[T]
+
+	// The feed-forward network and the gammas and betas of the two normalizations
+	w1, b1, w2, b2                 *vectors.Tensor[T]
+	gradW1, gradB1, gradW2, gradB2 *vectors.Tensor[T]
+	gamma, beta                    [2]*vectors.Tensor[T]
+	gradGamma, gradBeta            [2]*vectors.Tensor[T]
+
+	taped[T]
+}
+
+func (e *TransformerEncoder[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := e.OutputShape(input); err != nil {
+		return err
+	}
+
+	e.input = append([]int(nil), input...)
+	e.rng = rng
+
+	dims := input[1]
+	hidden := e.hidden(dims)
+
+	e.build(dims, e.Heads, e.Initialization, rng)
+
+	e.w1 = vectors.NewTensor[T](hidden, dims)
+	e.b1 = vectors.NewTensor[T](hidden)
+	e.w2 = vectors.NewTensor[T](dims, hidden)
+	e.b2 = vectors.NewTensor[T](dims)
+	e.gradW1 = vectors.NewTensor[T](hidden, dims)
+	e.gradB1 = vectors.NewTensor[T](hidden)
+	e.gradW2 = vectors.NewTensor[T](dims, hidden)
+	e.gradB2 = vectors.NewTensor[T](dims)
+
+	Initialize(e.w1.Data, dims, hidden, e.Initialization, rng)
+	Initialize(e.w2.Data, hidden, dims, e.Initialization, rng)
+
+	for i := range e.gamma {
+		e.gamma[i] = vectors.NewTensor[T](dims)
+		e.gamma[i].Fill(1)
+		e.beta[i] = vectors.NewTensor[T](dims)
+		e.gradGamma[i] = vectors.NewTensor[T](dims)
+		e.gradBeta[i] = vectors.NewTensor[T](dims)
+	}
+
+	return nil
+}
+
+func (e *TransformerEncoder[T]) hidden(dims int) int {
+	if e.FeedForward == 0 {
+		return 4 * dims
+	}
+	return e.FeedForward
+}
+
+func (e *TransformerEncoder[T]) OutputShape(input []int) ([]int, error) {
+
+	if err := checkAttention("transformer encoder", input, e.Heads); err != nil {
+		return nil, err
+	}
+
+	if e.FeedForward < 0 {
+		return nil, fmt.Errorf("transformer encoder: feed-forward width must not be negative, got %d", e.FeedForward)
+	}
+
+	if e.Dropout < 0 || e.Dropout >= 1 {
+		return nil, fmt.Errorf("transformer encoder: dropout rate must be in [0, 1), got %g", e.Dropout)
+	}
+
+	return input, nil
+}
+
+func (e *TransformerEncoder[T]) SetMask(mask []bool) { e.mask = mask }
+
+func (e *TransformerEncoder[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if e.w1 == nil {
+		return nil, fmt.Errorf("transformer encoder: layer is not built")
+	}
+
+	if err := checkSample("transformer encoder", x, e.input); err != nil {
+		return nil, err
+	}
+
+	tape := &e.tape
+	batch, steps, dims := x.Shape[0], x.Shape[1], x.Shape[2]
+
+	in, err := e.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every op below works on the steps of all sequences as rows
+	if in, err = tape.Reshape(in, batch*steps, dims); err != nil {
+		return nil, err
+	}
+
+	attended, err := e.record(tape, in, steps, e.mask)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := e.residual(0, in, attended, training)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := e.feedForward(h)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := e.residual(1, h, f, training)
+	if err != nil {
+		return nil, err
+	}
+
+	if out, err = tape.Reshape(out, batch, steps, dims); err != nil {
+		return nil, err
+	}
+
+	return e.end(out), nil
+}
+
+// feedForward records activation(h·W1ᵀ + b1)·W2ᵀ + b2
+func (e *TransformerEncoder[T]) feedForward(h *autodiff.Node[T]) (*autodiff.Node[T], error) {
+
+	tape := &e.tape
+
+	name := e.ActivationFunction
+	if name == "" {
+		name = activation.ReLU
+	}
+
+	f := h
+
+	for i, layer := range [][4]*vectors.Tensor[T]{
+		{e.w1, e.gradW1, e.b1, e.gradB1},
+		{e.w2, e.gradW2, e.b2, e.gradB2},
+	} {
+
+		w, err := tape.Param(layer[0], layer[1])
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := tape.Param(layer[2], layer[3])
+		if err != nil {
+			return nil, err
+		}
+
+		if f, err = tape.MatMul(f, w, false, true); err != nil {
+			return nil, err
+		}
+
+		if f, err = tape.AddRow(f, b); err != nil {
+			return nil, err
+		}
+
+		// Only the hidden layer is activated
+		if i == 0 {
+			if f, err = tape.Activate(f, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// residual records LayerNorm(x + Dropout(y)) with the i-th normalization
+func (e *TransformerEncoder[T]) residual(i int, x, y *autodiff.Node[T], training bool) (*autodiff.Node[T], error) {
+
+	tape := &e.tape
+	var err error
+
+	if training && e.Dropout > 0 {
+		e.drop[i] = dropoutMask(e.drop[i], y.Value.Shape, e.Dropout, e.rng)
+		if y, err = tape.Mul(y, tape.Constant(e.drop[i])); err != nil {
+			return nil, err
+		}
+	}
+
+	sum, err := tape.Add(x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	gamma, err := tape.Param(e.gamma[i], e.gradGamma[i])
+	if err != nil {
+		return nil, err
+	}
+
+	beta, err := tape.Param(e.beta[i], e.gradBeta[i])
+	if err != nil {
+		return nil, err
+	}
+
+	epsilon := e.Epsilon
+	if epsilon == 0 {
+		epsilon = defaultEpsilon
+	}
+
+	return tape.LayerNorm(sum, gamma, beta, epsilon)
+}
+
+func (e *TransformerEncoder[T]) Params() []*vectors.Tensor[T] {
+	if e.w1 == nil {
+		return nil
+	}
+	return append(e.params(), e.w1, e.b1, e.w2, e.b2, e.gamma[0], e.beta[0], e.gamma[1], e.beta[1])
+}
+
+func (e *TransformerEncoder[T]) Grads() []*vectors.Tensor[T] {
+	if e.w1 == nil {
+		return nil
+	}
+	return append(e.grads(), e.gradW1, e.gradB1, e.gradW2, e.gradB2, e.gradGamma[0], e.gradBeta[0], e.gradGamma[1], e.gradBeta[1])
+}
+
+// PositionalEncoding adds the sinusoidal encoding of every position to
+// [steps, dims] sequences, so the layers after it can tell the steps apart:
+//
+//	PE(pos, 2i) = sin(pos / 10000^(2i/dims))
+//	PE(pos, 2i+1) = cos(pos / 10000^(2i/dims))
+type PositionalEncoding[T vectors.Float] struct {
+	input []int
+
+	// encoding is [steps, dims] and tiled holds it for every sample of the batch
+	encoding, tiled *vectors.Tensor[T]
+
+	taped[T]
+}
+
+func (p *PositionalEncoding[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := p.OutputShape(input); err != nil {
+		return err
+	}
+
+	p.input = append([]int(nil), input...)
+	p.tiled = nil
+
+	steps, dims := input[0], input[1]
+	p.encoding = vectors.NewTensor[T](steps, dims)
+
+	for pos := range steps {
+		row := p.encoding.Row(pos)
+		for i := range row {
+			angle := float64(pos) / math.Pow(10000, float64(i-i%2)/float64(dims))
+			if i%2 == 0 {
+				row[i] = T(math.Sin(angle))
+			} else {
+				row[i] = T(math.Cos(angle))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *PositionalEncoding[T]) OutputShape(input []int) ([]int, error) {
+	if len(input) != 2 {
+		return nil, fmt.Errorf("positional encoding: input samples must be [steps, dims] sequences, got shape %v", input)
+	}
+	return input, nil
+}
+
+func (p *PositionalEncoding[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if p.encoding == nil {
+		return nil, fmt.Errorf("positional encoding: layer is not built")
+	}
+
+	if err := checkSample("positional encoding", x, p.input); err != nil {
+		return nil, err
+	}
+
+	// The encoding only changes with the batch size
+	if p.tiled == nil || !p.tiled.SameShape(x) {
+		p.tiled = vectors.Reuse(p.tiled, x.Shape...)
+		for b := range x.Shape[0] {
+			copy(p.tiled.Data[b*p.encoding.Len():], p.encoding.Data)
+		}
+	}
+
+	in, err := p.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := p.tape.Add(in, p.tape.Constant(p.tiled))
+	if err != nil {
+		return nil, err
+	}
+
+	return p.end(out), nil
+}
+
+func (p *PositionalEncoding[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (p *PositionalEncoding[T]) Grads() []*vectors.Tensor[T] { return nil }
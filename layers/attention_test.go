@@ -0,0 +1,207 @@
+package layers_test
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+func TestAttentionShapes(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		layer layers.Layer[float64]
+		input []int
+		want  []int // nil when the input is refused
+	}{
+		{"multi-head attention", &layers.MultiHeadAttention[float64]{Heads: 2}, []int{5, 4}, []int{5, 4}},
+		{"single-head attention over one step", &layers.MultiHeadAttention[float64]{Heads: 1}, []int{1, 3}, []int{1, 3}},
+		{"transformer encoder", &layers.TransformerEncoder[float64]{Heads: 3, FeedForward: 5, Dropout: 0.1}, []int{4, 6}, []int{4, 6}},
+		{"transformer encoder with the default width", &layers.TransformerEncoder[float64]{Heads: 1, ActivationFunction: activation.GELU}, []int{3, 2}, []int{3, 2}},
+		{"positional encoding", &layers.PositionalEncoding[float64]{}, []int{5, 3}, []int{5, 3}},
+		{"global average pooling", &layers.GlobalAveragePooling1D[float64]{}, []int{5, 3}, []int{3}},
+		{"heads not splitting the dims", &layers.MultiHeadAttention[float64]{Heads: 3}, []int{5, 4}, nil},
+		{"no heads", &layers.TransformerEncoder[float64]{}, []int{5, 4}, nil},
+		{"flat input", &layers.MultiHeadAttention[float64]{Heads: 1}, []int{4}, nil},
+		{"negative feed-forward width", &layers.TransformerEncoder[float64]{Heads: 1, FeedForward: -1}, []int{5, 4}, nil},
+		{"dropout of 1", &layers.TransformerEncoder[float64]{Heads: 1, Dropout: 1}, []int{5, 4}, nil},
+		{"positional encoding of images", &layers.PositionalEncoding[float64]{}, []int{5, 4, 1}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if tt.want == nil {
+				if err := tt.layer.Build(tt.input, rand.New(rand.NewSource(1))); err == nil {
+					t.Errorf("Build accepted samples of shape %v", tt.input)
+				}
+				return
+			}
+
+			checkShapes(t, tt.layer, tt.input, tt.want)
+		})
+	}
+}
+
+func TestPositionalEncoding(t *testing.T) {
+
+	encoding := &layers.PositionalEncoding[float64]{}
+	if err := encoding.Build([]int{3, 4}, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Zeros come out as the encoding itself, for every sample
+	y, err := encoding.Forward(vectors.NewTensor[float64](2, 3, 4), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pos := range 3 {
+
+		p := float64(pos)
+		want := []float64{math.Sin(p), math.Cos(p), math.Sin(p / 100), math.Cos(p / 100)}
+
+		for b := range 2 {
+			got := y.Data[(b*3+pos)*4 : (b*3+pos+1)*4]
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-12 {
+					t.Errorf("sample %d position %d: encoding %v, want %v", b, pos, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestAttentionMask(t *testing.T) {
+
+	const batch, steps, dims = 2, 4, 4
+
+	// The last two steps of the first sample and the last one of the second are padding
+	mask := []bool{true, true, false, false, true, true, true, false}
+
+	tests := []struct {
+		name  string
+		layer func() layers.Layer[float64]
+
+		// pooled layers output one vector per sample instead of one per step
+		pooled bool
+	}{
+		{"multi-head attention", func() layers.Layer[float64] {
+			return &layers.MultiHeadAttention[float64]{Heads: 2}
+		}, false},
+		{"transformer encoder", func() layers.Layer[float64] {
+			return &layers.TransformerEncoder[float64]{Heads: 2, FeedForward: 6}
+		}, false},
+		{"global average pooling", func() layers.Layer[float64] {
+			return &layers.GlobalAveragePooling1D[float64]{}
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			rng := rand.New(rand.NewSource(1))
+
+			layer := tt.layer()
+			if err := layer.Build([]int{steps, dims}, rng); err != nil {
+				t.Fatal(err)
+			}
+
+			x := randomTensor(rng, batch, steps, dims)
+
+			// outputs returns the values of the real steps, or of every sample when pooled
+			outputs := func(masked bool) []float64 {
+
+				if masked {
+					layer.(layers.Masked).SetMask(mask)
+				} else {
+					layer.(layers.Masked).SetMask(nil)
+				}
+
+				y, err := layer.Forward(x, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if tt.pooled {
+					return slices.Clone(y.Data)
+				}
+
+				var kept []float64
+				for i, real := range mask {
+					if real {
+						kept = append(kept, y.Data[i*dims:(i+1)*dims]...)
+					}
+				}
+
+				return kept
+			}
+
+			before, unmasked := outputs(true), outputs(false)
+
+			// Moving the padding steps reaches the real ones only without the mask
+			for i, real := range mask {
+				if !real {
+					for k := range dims {
+						x.Data[i*dims+k] += 1
+					}
+				}
+			}
+
+			if after := outputs(true); !slices.Equal(after, before) {
+				t.Errorf("masked output changed with the padding")
+			}
+
+			if after := outputs(false); slices.Equal(after, unmasked) {
+				t.Errorf("unmasked output did not change with the padding")
+			}
+		})
+	}
+}
+
+func TestSequentialPassesMasks(t *testing.T) {
+
+	for _, maskZero := range []bool{true, false} {
+
+		embedding := &layers.Embedding[float64]{Vocabulary: 7, Dimensions: 4, MaskZero: maskZero}
+		network := layers.NewSequential[float64](
+			embedding,
+			&layers.PositionalEncoding[float64]{},
+			&layers.TransformerEncoder[float64]{Heads: 2},
+			&layers.GlobalAveragePooling1D[float64]{},
+		)
+
+		if err := network.Build([]int{5}, rand.New(rand.NewSource(1))); err != nil {
+			t.Fatal(err)
+		}
+
+		x := vectors.NewTensor[float64](1, 5)
+		copy(x.Data, []float64{3, 5, 2, 0, 0})
+
+		y, err := network.Forward(x, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		before := slices.Clone(y.Data)
+
+		// Moving the padding vector must not reach the output of a masked network
+		for k := range embedding.Table().Row(0) {
+			embedding.Table().Row(0)[k] += 1
+		}
+
+		if y, err = network.Forward(x, false); err != nil {
+			t.Fatal(err)
+		}
+
+		if changed := !slices.Equal(before, y.Data); changed == maskZero {
+			t.Errorf("mask zero %v: output changed with the padding vector: %v, want %v", maskZero, changed, !maskZero)
+		}
+	}
+}
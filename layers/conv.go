@@ -0,0 +1,168 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/autodiff"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Conv2D slides Filters learned kernels over images of shape
+// [height, width, channels] and returns activation(convolution + bias),
+// of shape [output height, output width, Filters].
+type Conv2D[T vectors.Float] struct {
+	Filters int
+
+	// Kernel is the height and width of each kernel
+	Kernel [2]int
+
+	// Stride is the step between positions along height and width, zero means 1
+	Stride [2]int
+
+	// Padding is the number of rows and columns of zeros added on each side.
+	// (Kernel-1)/2 keeps the image size for odd kernels with stride 1.
+	Padding [2]int
+
+	ActivationFunction activation.ActivationFunction
+
+	// Initialization of the kernels, Xavier Normal when empty. Biases start at zero.
+	Initialization Initialization
+
+	input []int
+
+	kernels, biases         *vectors.Tensor[T]
+	gradKernels, gradBiases *vectors.Tensor[T]
+
+	taped[T]
+}
+
+// Kernels returns the kernel tensor, [filters, kernel height, kernel width,
+// channels], nil before Build
+func (c *Conv2D[T]) Kernels() *vectors.Tensor[T] { return c.kernels }
+
+// Biases returns the bias tensor, [filters], nil before Build
+func (c *Conv2D[T]) Biases() *vectors.Tensor[T] { return c.biases }
+
+func (c *Conv2D[T]) window() autodiff.Window {
+	return autodiff.Window{Kernel: c.Kernel, Stride: c.Stride, Padding: c.Padding}
+}
+
+func (c *Conv2D[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := c.OutputShape(input); err != nil {
+		return err
+	}
+
+	c.input = append([]int(nil), input...)
+
+	channels := input[2]
+
+	c.kernels = vectors.NewTensor[T](c.Filters, c.Kernel[0], c.Kernel[1], channels)
+	c.biases = vectors.NewTensor[T](c.Filters)
+	c.gradKernels = vectors.NewTensor[T](c.Filters, c.Kernel[0], c.Kernel[1], channels)
+	c.gradBiases = vectors.NewTensor[T](c.Filters)
+
+	area := c.Kernel[0] * c.Kernel[1]
+	Initialize(c.kernels.Data, area*channels, area*c.Filters, c.Initialization, rng)
+
+	return nil
+}
+
+func (c *Conv2D[T]) OutputShape(input []int) ([]int, error) {
+
+	if len(input) != 3 {
+		return nil, fmt.Errorf("conv2d: input samples must be [height, width, channels], got shape %v", input)
+	}
+
+	if c.Filters <= 0 {
+		return nil, fmt.Errorf("conv2d: filters must be positive, got %d", c.Filters)
+	}
+
+	height, width, err := c.window().Output(input[0], input[1])
+	if err != nil {
+		return nil, fmt.Errorf("conv2d: %v", err)
+	}
+
+	return []int{height, width, c.Filters}, nil
+}
+
+func (c *Conv2D[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if c.kernels == nil {
+		return nil, fmt.Errorf("conv2d: layer is not built")
+	}
+
+	if err := checkSample("conv2d", x, c.input); err != nil {
+		return nil, err
+	}
+
+	tape := &c.tape
+
+	in, err := c.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	kernels, err := tape.Param(c.kernels, c.gradKernels)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := tape.Param(c.biases, c.gradBiases)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every output position is the dot product of one patch with each kernel
+	cols, err := tape.Im2Col(in, c.window())
+	if err != nil {
+		return nil, err
+	}
+
+	if kernels, err = tape.Reshape(kernels, c.Filters, c.kernels.Len()/c.Filters); err != nil {
+		return nil, err
+	}
+
+	z, err := tape.MatMul(cols, kernels, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if z, err = tape.AddRow(z, b); err != nil {
+		return nil, err
+	}
+
+	name := c.ActivationFunction
+	if name == "" {
+		name = activation.Linear
+	}
+
+	out, err := tape.Activate(z, name)
+	if err != nil {
+		return nil, err
+	}
+
+	height, width, _ := c.window().Output(c.input[0], c.input[1])
+
+	if out, err = tape.Reshape(out, x.Shape[0], height, width, c.Filters); err != nil {
+		return nil, err
+	}
+
+	return c.end(out), nil
+}
+
+func (c *Conv2D[T]) Params() []*vectors.Tensor[T] {
+	if c.kernels == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{c.kernels, c.biases}
+}
+
+func (c *Conv2D[T]) Grads() []*vectors.Tensor[T] {
+	if c.kernels == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{c.gradKernels, c.gradBiases}
+}
@@ -0,0 +1,139 @@
+package layers_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+func TestConvShapes(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		layer layers.Layer[float64]
+		input []int
+		want  []int // nil when the input is refused
+	}{
+		{
+			name:  "conv keeping the size",
+			layer: &layers.Conv2D[float64]{Filters: 4, Kernel: [2]int{3, 3}, Padding: [2]int{1, 1}},
+			input: []int{6, 5, 2},
+			want:  []int{6, 5, 4},
+		},
+		{
+			name:  "strided conv",
+			layer: &layers.Conv2D[float64]{Filters: 3, Kernel: [2]int{2, 3}, Stride: [2]int{2, 2}},
+			input: []int{7, 7, 1},
+			want:  []int{3, 3, 3},
+		},
+		{
+			name:  "conv of a flat input",
+			layer: &layers.Conv2D[float64]{Filters: 1, Kernel: [2]int{1, 1}},
+			input: []int{4, 4},
+		},
+		{
+			name:  "conv kernel larger than the image",
+			layer: &layers.Conv2D[float64]{Filters: 1, Kernel: [2]int{5, 5}},
+			input: []int{4, 4, 1},
+		},
+		{
+			name:  "max pool",
+			layer: &layers.MaxPool2D[float64]{Kernel: [2]int{2, 2}},
+			input: []int{7, 6, 3},
+			want:  []int{3, 3, 3},
+		},
+		{
+			name:  "padded, overlapping max pool",
+			layer: &layers.MaxPool2D[float64]{Kernel: [2]int{3, 3}, Stride: [2]int{1, 2}, Padding: [2]int{1, 1}},
+			input: []int{4, 5, 2},
+			want:  []int{4, 3, 2},
+		},
+		{
+			name:  "average pool",
+			layer: &layers.AvgPool2D[float64]{Kernel: [2]int{2, 3}, Stride: [2]int{1, 1}},
+			input: []int{4, 4, 2},
+			want:  []int{3, 2, 2},
+		},
+		{
+			name:  "average pool padded as wide as its kernel",
+			layer: &layers.AvgPool2D[float64]{Kernel: [2]int{2, 2}, Padding: [2]int{2, 0}},
+			input: []int{4, 4, 2},
+		},
+		{
+			name:  "global average pooling",
+			layer: &layers.GlobalAveragePooling2D[float64]{},
+			input: []int{3, 4, 5},
+			want:  []int{5},
+		},
+		{
+			name:  "flatten",
+			layer: &layers.Flatten[float64]{},
+			input: []int{3, 4, 5},
+			want:  []int{60},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if tt.want == nil {
+				if err := tt.layer.Build(tt.input, rand.New(rand.NewSource(1))); err == nil {
+					t.Errorf("Build accepted samples of shape %v", tt.input)
+				}
+				return
+			}
+
+			checkShapes(t, tt.layer, tt.input, tt.want)
+		})
+	}
+}
+
+func TestConvValues(t *testing.T) {
+
+	// One 3×3 image holding 1 to 9 row by row
+	image := vectors.NewTensor[float64](1, 3, 3, 1)
+	for i := range image.Data {
+		image.Data[i] = float64(i + 1)
+	}
+
+	conv := &layers.Conv2D[float64]{Filters: 1, Kernel: [2]int{2, 2}, ActivationFunction: activation.Linear}
+
+	tests := []struct {
+		name  string
+		layer layers.Layer[float64]
+		want  []float64
+	}{
+		{"conv summing every 2×2 window, plus a bias of 1", conv, []float64{13, 17, 25, 29}},
+		{"overlapping max pool", &layers.MaxPool2D[float64]{Kernel: [2]int{2, 2}, Stride: [2]int{1, 1}}, []float64{5, 6, 8, 9}},
+		{"padded max pool leaving the padding out", &layers.MaxPool2D[float64]{Kernel: [2]int{2, 2}, Stride: [2]int{2, 2}, Padding: [2]int{1, 1}}, []float64{1, 3, 7, 9}},
+		{"overlapping average pool", &layers.AvgPool2D[float64]{Kernel: [2]int{2, 2}, Stride: [2]int{1, 1}}, []float64{3, 4, 6, 7}},
+		{"global average pooling", &layers.GlobalAveragePooling2D[float64]{}, []float64{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if err := tt.layer.Build([]int{3, 3, 1}, rand.New(rand.NewSource(1))); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.layer == conv {
+				conv.Kernels().Fill(1)
+				conv.Biases().Fill(1)
+			}
+
+			y, err := tt.layer.Forward(image, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(y.Data, tt.want) {
+				t.Errorf("output %v, want %v", y.Data, tt.want)
+			}
+		})
+	}
+}
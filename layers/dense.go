@@ -0,0 +1,155 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Dense is a fully connected layer: activation(x · Wᵀ + b), where W has
+// shape [neurons, inputs] and b has shape [neurons]. Input samples must be
+// flat vectors.
+type Dense[T vectors.Float] struct {
+	Neurons            int
+	ActivationFunction activation.ActivationFunction
+
+	// Initialization of the weights, Xavier Normal when empty. Biases start at zero.
+	Initialization Initialization
+
+	inputs int
+
+	weights, biases         *vectors.Tensor[T]
+	gradWeights, gradBiases *vectors.Tensor[T]
+
+	// bound is set when the weights and biases were given by SetParams
+	bound bool
+
+	taped[T]
+}
+
+// Weights returns the weight tensor, [neurons, inputs], nil before Build
+func (d *Dense[T]) Weights() *vectors.Tensor[T] { return d.weights }
+
+// Biases returns the bias tensor, [neurons], nil before Build
+func (d *Dense[T]) Biases() *vectors.Tensor[T] { return d.biases }
+
+// SetParams makes the layer use weights, [neurons, inputs], and biases,
+// [neurons], owned by the caller, instead of tensors of its own. Neither is
+// copied. Build then keeps them and only allocates the gradients.
+func (d *Dense[T]) SetParams(weights, biases *vectors.Tensor[T]) error {
+
+	if weights.Dims() != 2 || biases.Dims() != 1 || biases.Shape[0] != weights.Shape[0] {
+		return fmt.Errorf("dense: weights of shape %v and biases of shape %v, want [neurons, inputs] and [neurons]", weights.Shape, biases.Shape)
+	}
+
+	d.Neurons = weights.Shape[0]
+	d.weights, d.biases = weights, biases
+	d.bound = true
+
+	return nil
+}
+
+func (d *Dense[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := d.OutputShape(input); err != nil {
+		return err
+	}
+
+	d.inputs = input[0]
+
+	d.gradWeights = vectors.NewTensor[T](d.Neurons, d.inputs)
+	d.gradBiases = vectors.NewTensor[T](d.Neurons)
+
+	if d.bound {
+		if d.weights.Shape[1] != d.inputs {
+			return fmt.Errorf("dense: weights of shape %v for inputs of %d values", d.weights.Shape, d.inputs)
+		}
+		return nil
+	}
+
+	d.weights = vectors.NewTensor[T](d.Neurons, d.inputs)
+	d.biases = vectors.NewTensor[T](d.Neurons)
+
+	Initialize(d.weights.Data, d.inputs, d.Neurons, d.Initialization, rng)
+
+	return nil
+}
+
+func (d *Dense[T]) OutputShape(input []int) ([]int, error) {
+
+	if len(input) != 1 {
+		return nil, fmt.Errorf("dense: input samples must be flat vectors, got shape %v", input)
+	}
+
+	if d.Neurons <= 0 {
+		return nil, fmt.Errorf("dense: neurons must be positive, got %d", d.Neurons)
+	}
+
+	return []int{d.Neurons}, nil
+}
+
+func (d *Dense[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if d.weights == nil {
+		return nil, fmt.Errorf("dense: layer is not built")
+	}
+
+	if x.Dims() != 2 || x.Shape[1] != d.inputs {
+		return nil, fmt.Errorf("dense: input has shape %v, want [batch %d]", x.Shape, d.inputs)
+	}
+
+	tape := &d.tape
+
+	in, err := d.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := tape.Param(d.weights, d.gradWeights)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := tape.Param(d.biases, d.gradBiases)
+	if err != nil {
+		return nil, err
+	}
+
+	z, err := tape.MatMul(in, w, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if z, err = tape.AddRow(z, b); err != nil {
+		return nil, err
+	}
+
+	// The identity needs no node of its own
+	if name := d.ActivationFunction; name != "" && name != activation.Linear {
+		if z, err = tape.Activate(z, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.end(z), nil
+}
+
+func (d *Dense[T]) Params() []*vectors.Tensor[T] {
+	if d.weights == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{d.weights, d.biases}
+}
+
+func (d *Dense[T]) Grads() []*vectors.Tensor[T] {
+	if d.weights == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{d.gradWeights, d.gradBiases}
+}
+
+func (d *Dense[T]) outputActivation() activation.ActivationFunction {
+	return d.ActivationFunction
+}
@@ -0,0 +1,87 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Dropout zeroes each value with probability Rate while training and scales
+// the kept values by 1/(1-Rate), so their expected value is unchanged.
+// Outside training it passes its input through.
+type Dropout[T vectors.Float] struct {
+	Rate float64
+
+	rng  *rand.Rand
+	mask *vectors.Tensor[T]
+
+	taped[T]
+}
+
+// Build keeps rng to draw the masks from, nil for the global source
+func (d *Dropout[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := d.OutputShape(input); err != nil {
+		return err
+	}
+
+	d.rng = rng
+
+	return nil
+}
+
+func (d *Dropout[T]) OutputShape(input []int) ([]int, error) {
+	if d.Rate < 0 || d.Rate >= 1 {
+		return nil, fmt.Errorf("dropout: rate must be in [0, 1), got %g", d.Rate)
+	}
+	return input, nil
+}
+
+func (d *Dropout[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	in, err := d.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	if !training || d.Rate == 0 {
+		return d.end(in), nil
+	}
+
+	d.mask = dropoutMask(d.mask, x.Shape, d.Rate, d.rng)
+
+	out, err := d.tape.Mul(in, d.tape.Constant(d.mask))
+	if err != nil {
+		return nil, err
+	}
+
+	return d.end(out), nil
+}
+
+// dropoutMask returns mask resized to shape and filled with 0 at a fraction
+// rate of the values and 1/(1-rate) elsewhere, drawn from rng, nil for the
+// global source
+func dropoutMask[T vectors.Float](mask *vectors.Tensor[T], shape []int, rate float64, rng *rand.Rand) *vectors.Tensor[T] {
+
+	mask = vectors.Reuse(mask, shape...)
+
+	uniform := rand.Float64
+	if rng != nil {
+		uniform = rng.Float64
+	}
+
+	keep := T(1.0 / (1.0 - rate))
+	for k := range mask.Data {
+		mask.Data[k] = 0
+		if uniform() >= rate {
+			mask.Data[k] = keep
+		}
+	}
+
+	return mask
+}
+
+func (d *Dropout[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (d *Dropout[T]) Grads() []*vectors.Tensor[T] { return nil }
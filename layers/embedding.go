@@ -0,0 +1,385 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Embedding maps integer IDs, such as tokens or categories, to learned
+// vectors. Input samples are [length] IDs stored as floats, each in
+// [0, Vocabulary), and output samples are [length, Dimensions] with the
+// vector of every ID.
+//
+// The table is [Vocabulary, Dimensions] and only the rows of the IDs in a
+// batch receive gradient, so Backward touches those rows alone and the
+// layer is Sparse: optimizers supporting it update just those rows.
+type Embedding[T vectors.Float] struct {
+	Vocabulary int
+	Dimensions int
+
+	// Initialization of the table, Xavier Normal over Dimensions when empty,
+	// which gives vectors of unit length on average
+	Initialization Initialization
+
+	// MaskZero marks ID 0 as padding: its steps are left out by the Masked
+	// layers after this one, such as attention
+	MaskZero bool
+
+	length int
+
+	table, gradTable *vectors.Tensor[T]
+
+	// ids holds the IDs of the last Forward and rows the rows of gradTable
+	// written by the last Backward, outside of which gradTable is zero
+	ids     []int
+	rows    []int
+	touched []bool
+
+	out, dx *vectors.Tensor[T]
+	mask    []bool
+}
+
+// Table returns the embedding table, [vocabulary, dimensions], nil before Build
+func (e *Embedding[T]) Table() *vectors.Tensor[T] { return e.table }
+
+func (e *Embedding[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := e.OutputShape(input); err != nil {
+		return err
+	}
+
+	e.length = input[0]
+
+	e.table = vectors.NewTensor[T](e.Vocabulary, e.Dimensions)
+	e.gradTable = vectors.NewTensor[T](e.Vocabulary, e.Dimensions)
+	e.touched = make([]bool, e.Vocabulary)
+	e.ids, e.rows = e.ids[:0], e.rows[:0]
+
+	Initialize(e.table.Data, e.Dimensions, e.Dimensions, e.Initialization, rng)
+
+	return nil
+}
+
+func (e *Embedding[T]) OutputShape(input []int) ([]int, error) {
+
+	if len(input) != 1 {
+		return nil, fmt.Errorf("embedding: input samples must be flat vectors of IDs, got shape %v", input)
+	}
+
+	if e.Vocabulary <= 0 || e.Dimensions <= 0 {
+		return nil, fmt.Errorf("embedding: vocabulary and dimensions must be positive, got %d and %d", e.Vocabulary, e.Dimensions)
+	}
+
+	return []int{input[0], e.Dimensions}, nil
+}
+
+func (e *Embedding[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if e.table == nil {
+		return nil, fmt.Errorf("embedding: layer is not built")
+	}
+
+	if err := checkSample("embedding", x, []int{e.length}); err != nil {
+		return nil, err
+	}
+
+	e.ids = e.ids[:0]
+
+	for _, v := range x.Data {
+		id := int(v)
+		if T(id) != v || id < 0 || id >= e.Vocabulary {
+			return nil, fmt.Errorf("embedding: input %v is not an ID in [0, %d)", v, e.Vocabulary)
+		}
+		e.ids = append(e.ids, id)
+	}
+
+	e.out = vectors.Reuse(e.out, x.Shape[0], e.length, e.Dimensions)
+
+	for i, id := range e.ids {
+		copy(e.out.Data[i*e.Dimensions:(i+1)*e.Dimensions], e.table.Row(id))
+	}
+
+	return e.out, nil
+}
+
+func (e *Embedding[T]) Backward(dy *vectors.Tensor[T]) (*vectors.Tensor[T], error) {
+
+	if e.out == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+
+	if !dy.SameShape(e.out) {
+		return nil, fmt.Errorf("embedding: gradient shape %v does not match output shape %v", dy.Shape, e.out.Shape)
+	}
+
+	// Clear the rows of the previous step, the rest of the table is still zero
+	for _, r := range e.rows {
+		clear(e.gradTable.Row(r))
+	}
+
+	e.rows = e.rows[:0]
+
+	for i, id := range e.ids {
+
+		if !e.touched[id] {
+			e.touched[id] = true
+			e.rows = append(e.rows, id)
+		}
+
+		row := e.gradTable.Row(id)
+		for k, g := range dy.Data[i*e.Dimensions : (i+1)*e.Dimensions] {
+			row[k] += g
+		}
+	}
+
+	for _, r := range e.rows {
+		e.touched[r] = false
+	}
+
+	// IDs are not differentiable
+	e.dx = vectors.Reuse(e.dx, e.out.Shape[0], e.length)
+	e.dx.Zero()
+
+	return e.dx, nil
+}
+
+func (e *Embedding[T]) Params() []*vectors.Tensor[T] {
+	if e.table == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{e.table}
+}
+
+func (e *Embedding[T]) Grads() []*vectors.Tensor[T] {
+	if e.table == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{e.gradTable}
+}
+
+// OutputMask flags the steps of the last Forward whose ID is not 0, nil
+// unless MaskZero is set
+func (e *Embedding[T]) OutputMask() []bool {
+
+	if !e.MaskZero {
+		return nil
+	}
+
+	e.mask = e.mask[:0]
+	for _, id := range e.ids {
+		e.mask = append(e.mask, id != 0)
+	}
+
+	return e.mask
+}
+
+// SparseRows returns the rows of the table looked up in the last batch
+func (e *Embedding[T]) SparseRows() []Rows {
+	if e.table == nil {
+		return nil
+	}
+	return []Rows{{Width: e.Dimensions, Indices: e.rows}}
+}
+
+// FeatureEmbedding embeds the categorical features of flat samples, such as
+// the ones dataloader.FromCSV reads from CategoricalColumns, and passes the
+// other features through. Every output sample holds the features in their
+// order, each categorical one replaced by the vector of its Embedding.
+type FeatureEmbedding[T vectors.Float] struct {
+
+	// Embeddings maps the index of each categorical feature to the
+	// Embedding of its IDs
+	Embeddings map[int]*Embedding[T]
+
+	// features lists the embedded features in order, offsets gives where
+	// every input feature starts in an output sample
+	features []int
+	offsets  []int
+	width    int
+
+	// ids and grads feed the embedding of every feature in order
+	ids, grads []*vectors.Tensor[T]
+
+	out, dx *vectors.Tensor[T]
+
+	params, gradients []*vectors.Tensor[T]
+	rows              []Rows
+}
+
+func (f *FeatureEmbedding[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := f.OutputShape(input); err != nil {
+		return err
+	}
+
+	f.features = f.features[:0]
+	for feature := range f.Embeddings {
+		f.features = append(f.features, feature)
+	}
+	slices.Sort(f.features)
+
+	f.offsets = f.offsets[:0]
+	f.width = 0
+
+	for feature := range input[0] {
+		f.offsets = append(f.offsets, f.width)
+		if e, ok := f.Embeddings[feature]; ok {
+			f.width += e.Dimensions
+		} else {
+			f.width++
+		}
+	}
+
+	f.params, f.gradients = nil, nil
+	f.ids = make([]*vectors.Tensor[T], len(f.features))
+	f.grads = make([]*vectors.Tensor[T], len(f.features))
+
+	for _, feature := range f.features {
+
+		e := f.Embeddings[feature]
+
+		if err := e.Build([]int{1}, rng); err != nil {
+			return fmt.Errorf("feature %d: %v", feature, err)
+		}
+
+		f.params = append(f.params, e.Params()...)
+		f.gradients = append(f.gradients, e.Grads()...)
+	}
+
+	return nil
+}
+
+func (f *FeatureEmbedding[T]) OutputShape(input []int) ([]int, error) {
+
+	if len(input) != 1 {
+		return nil, fmt.Errorf("feature embedding: input samples must be flat vectors, got shape %v", input)
+	}
+
+	width := input[0]
+
+	for feature, e := range f.Embeddings {
+
+		if feature < 0 || feature >= input[0] {
+			return nil, fmt.Errorf("feature embedding: feature %d is out of range for %d features", feature, input[0])
+		}
+
+		if e == nil {
+			return nil, fmt.Errorf("feature embedding: feature %d has no embedding", feature)
+		}
+
+		if _, err := e.OutputShape([]int{1}); err != nil {
+			return nil, fmt.Errorf("feature %d: %v", feature, err)
+		}
+
+		width += e.Dimensions - 1
+	}
+
+	return []int{width}, nil
+}
+
+func (f *FeatureEmbedding[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if f.offsets == nil {
+		return nil, fmt.Errorf("feature embedding: layer is not built")
+	}
+
+	features := len(f.offsets)
+
+	if err := checkSample("feature embedding", x, []int{features}); err != nil {
+		return nil, err
+	}
+
+	batch := x.Shape[0]
+
+	f.out = vectors.Reuse(f.out, batch, f.width)
+
+	// Numeric features first, then the vector of every categorical one on top
+	for i := range batch {
+		row := f.out.Row(i)
+		for feature, v := range x.Row(i) {
+			row[f.offsets[feature]] = v
+		}
+	}
+
+	for j, feature := range f.features {
+
+		e := f.Embeddings[feature]
+
+		f.ids[j] = vectors.Reuse(f.ids[j], batch, 1)
+		for i := range batch {
+			f.ids[j].Data[i] = x.Data[i*features+feature]
+		}
+
+		embedded, err := e.Forward(f.ids[j], training)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %v", feature, err)
+		}
+
+		at := f.offsets[feature]
+		for i := range batch {
+			copy(f.out.Row(i)[at:at+e.Dimensions], embedded.Data[i*e.Dimensions:(i+1)*e.Dimensions])
+		}
+	}
+
+	return f.out, nil
+}
+
+func (f *FeatureEmbedding[T]) Backward(dy *vectors.Tensor[T]) (*vectors.Tensor[T], error) {
+
+	if f.out == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+
+	if !dy.SameShape(f.out) {
+		return nil, fmt.Errorf("feature embedding: gradient shape %v does not match output shape %v", dy.Shape, f.out.Shape)
+	}
+
+	batch, features := dy.Shape[0], len(f.offsets)
+
+	f.dx = vectors.Reuse(f.dx, batch, features)
+
+	for i := range batch {
+		row := dy.Row(i)
+		for feature := range features {
+			f.dx.Data[i*features+feature] = row[f.offsets[feature]]
+		}
+	}
+
+	for j, feature := range f.features {
+
+		e := f.Embeddings[feature]
+		at := f.offsets[feature]
+
+		f.grads[j] = vectors.Reuse(f.grads[j], batch, 1, e.Dimensions)
+		for i := range batch {
+			copy(f.grads[j].Data[i*e.Dimensions:(i+1)*e.Dimensions], dy.Row(i)[at:at+e.Dimensions])
+			f.dx.Data[i*features+feature] = 0
+		}
+
+		if _, err := e.Backward(f.grads[j]); err != nil {
+			return nil, fmt.Errorf("feature %d: %v", feature, err)
+		}
+	}
+
+	return f.dx, nil
+}
+
+// Params returns the tables of the embeddings in feature order
+func (f *FeatureEmbedding[T]) Params() []*vectors.Tensor[T] { return f.params }
+
+func (f *FeatureEmbedding[T]) Grads() []*vectors.Tensor[T] { return f.gradients }
+
+// SparseRows returns the rows of every table looked up in the last batch
+func (f *FeatureEmbedding[T]) SparseRows() []Rows {
+
+	f.rows = f.rows[:0]
+
+	for _, feature := range f.features {
+		f.rows = append(f.rows, f.Embeddings[feature].SparseRows()...)
+	}
+
+	return f.rows
+}
@@ -0,0 +1,171 @@
+package layers_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+func TestEmbeddingLookup(t *testing.T) {
+
+	for _, maskZero := range []bool{false, true} {
+
+		embedding := &layers.Embedding[float64]{Vocabulary: 4, Dimensions: 2, MaskZero: maskZero}
+		if err := embedding.Build([]int{3}, rand.New(rand.NewSource(1))); err != nil {
+			t.Fatal(err)
+		}
+
+		table := embedding.Table()
+
+		x := vectors.NewTensor[float64](2, 3)
+		copy(x.Data, []float64{1, 0, 3, 3, 3, 2})
+
+		y, err := embedding.Forward(x, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(y.Shape, []int{2, 3, 2}) {
+			t.Fatalf("output has shape %v, want [2 3 2]", y.Shape)
+		}
+
+		for i, id := range x.Data {
+			if got := y.Data[2*i : 2*i+2]; !slices.Equal(got, table.Row(int(id))) {
+				t.Errorf("step %d: vector %v, want row %v of the table", i, got, id)
+			}
+		}
+
+		var want []bool
+		if maskZero {
+			want = []bool{true, false, true, true, true, true}
+		}
+		if mask := embedding.OutputMask(); !slices.Equal(mask, want) {
+			t.Errorf("mask zero %v: output mask %v, want %v", maskZero, mask, want)
+		}
+
+		dy := vectors.NewTensor[float64](2, 3, 2)
+		dy.Fill(1)
+
+		dx, err := embedding.Backward(dy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(dx.Shape, x.Shape) || slices.ContainsFunc(dx.Data, func(v float64) bool { return v != 0 }) {
+			t.Errorf("input gradient %v of shape %v, want zeros of shape %v", dx.Data, dx.Shape, x.Shape)
+		}
+
+		// Every lookup adds its gradient to the row, ID 3 was looked up three times
+		grad := embedding.Grads()[0]
+		if got := grad.Data; !slices.Equal(got, []float64{1, 1, 1, 1, 1, 1, 3, 3}) {
+			t.Errorf("table gradient %v, want [1 1 1 1 1 1 3 3]", got)
+		}
+
+		if rows := embedding.SparseRows(); len(rows) != 1 || rows[0].Width != 2 || !slices.Equal(rows[0].Indices, []int{1, 0, 3, 2}) {
+			t.Errorf("sparse rows %+v, want rows [1 0 3 2] of width 2", rows)
+		}
+
+		// The next step clears the rows of the previous one
+		copy(x.Data, []float64{2, 2, 2, 2, 2, 2})
+
+		if _, err := embedding.Forward(x, true); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := embedding.Backward(dy); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := grad.Data; !slices.Equal(got, []float64{0, 0, 0, 0, 6, 6, 0, 0}) {
+			t.Errorf("table gradient %v after a second step, want [0 0 0 0 6 6 0 0]", got)
+		}
+	}
+}
+
+func TestEmbeddingRefusedInputs(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		embedding *layers.Embedding[float64]
+		input     []int
+		ids       []float64 // fed to the built layer when the shape is accepted
+	}{
+		{"sequences of vectors", &layers.Embedding[float64]{Vocabulary: 4, Dimensions: 2}, []int{3, 2}, nil},
+		{"no vocabulary", &layers.Embedding[float64]{Dimensions: 2}, []int{3}, nil},
+		{"no dimensions", &layers.Embedding[float64]{Vocabulary: 4}, []int{3}, nil},
+		{"an ID past the vocabulary", &layers.Embedding[float64]{Vocabulary: 4, Dimensions: 2}, []int{3}, []float64{0, 4, 1}},
+		{"a negative ID", &layers.Embedding[float64]{Vocabulary: 4, Dimensions: 2}, []int{3}, []float64{0, -1, 1}},
+		{"a fractional ID", &layers.Embedding[float64]{Vocabulary: 4, Dimensions: 2}, []int{3}, []float64{0, 1.5, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			err := tt.embedding.Build(tt.input, rand.New(rand.NewSource(1)))
+
+			if tt.ids == nil {
+				if err == nil {
+					t.Errorf("Build accepted the layer for samples of shape %v", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			x := vectors.NewTensor[float64](1, len(tt.ids))
+			copy(x.Data, tt.ids)
+
+			if _, err := tt.embedding.Forward(x, false); err == nil {
+				t.Errorf("Forward accepted IDs %v", tt.ids)
+			}
+		})
+	}
+}
+
+func TestFeatureEmbedding(t *testing.T) {
+
+	colour := &layers.Embedding[float64]{Vocabulary: 3, Dimensions: 2}
+	size := &layers.Embedding[float64]{Vocabulary: 5, Dimensions: 3}
+
+	features := &layers.FeatureEmbedding[float64]{Embeddings: map[int]*layers.Embedding[float64]{1: colour, 3: size}}
+
+	if err := features.Build([]int{4}, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	shape, err := features.OutputShape([]int{4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(shape, []int{7}) {
+		t.Fatalf("output shape %v, want [7]", shape)
+	}
+
+	x := vectors.NewTensor[float64](1, 4)
+	copy(x.Data, []float64{0.5, 2, -1, 4})
+
+	y, err := features.Forward(x, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := slices.Concat([]float64{0.5}, colour.Table().Row(2), []float64{-1}, size.Table().Row(4))
+	if !slices.Equal(y.Data, want) {
+		t.Errorf("output %v, want %v", y.Data, want)
+	}
+
+	if params := features.Params(); len(params) != 2 || params[0] != colour.Table() || params[1] != size.Table() {
+		t.Errorf("params are not the tables in feature order")
+	}
+
+	out := &layers.FeatureEmbedding[float64]{Embeddings: map[int]*layers.Embedding[float64]{4: colour}}
+	if err := out.Build([]int{4}, rand.New(rand.NewSource(1))); err == nil {
+		t.Errorf("Build accepted an embedding of feature 4 for 4 features")
+	}
+}
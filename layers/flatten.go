@@ -0,0 +1,43 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Flatten turns samples of any shape into flat vectors, e.g. to feed the
+// output of a convolution into a Dense layer. Values keep their order.
+type Flatten[T vectors.Float] struct {
+	taped[T]
+}
+
+func (f *Flatten[T]) Build(input []int, rng *rand.Rand) error { return nil }
+
+func (f *Flatten[T]) OutputShape(input []int) ([]int, error) {
+	return []int{flat(input)}, nil
+}
+
+func (f *Flatten[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if x.Dims() < 1 {
+		return nil, fmt.Errorf("flatten: input has no batch dimension")
+	}
+
+	in, err := f.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := f.tape.Reshape(in, x.Shape[0], x.Len()/max(x.Shape[0], 1))
+	if err != nil {
+		return nil, err
+	}
+
+	return f.end(out), nil
+}
+
+func (f *Flatten[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (f *Flatten[T]) Grads() []*vectors.Tensor[T] { return nil }
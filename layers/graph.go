@@ -0,0 +1,681 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/backend"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Graph is a network whose layers form a directed acyclic graph, built with
+// a functional API: Input declares an input, Apply and Merge add a layer
+// reading earlier nodes and Output picks the nodes the graph returns. A node
+// may feed any number of later ones, which gives skip connections and
+// branches:
+//
+//	g := layers.NewGraph[float64]()
+//	x := g.Input(16)
+//	h := g.Apply(&layers.Dense[float64]{Neurons: 16, ActivationFunction: activation.ReLU}, x)
+//	h = g.Merge(&layers.Add[float64]{}, x, h) // residual connection
+//	g.Output(g.Apply(&layers.Dense[float64]{Neurons: 3, ActivationFunction: activation.Softmax}, h))
+//
+// Graph is a Layer. Its input samples hold the samples of every input,
+// flattened and side by side in the order they were declared, so any input
+// shape with that many values is accepted. With one output, output samples
+// have the shape of that node; with several, they hold the outputs
+// flattened and side by side in the order given to Output.
+//
+// Nodes are numbered from 0 in the order they are added, and every layer may
+// appear in one node only. Padding masks flow along the edges as they do in
+// Sequential, a merge keeps the mask of its first input.
+type Graph[T vectors.Float] struct {
+	nodes   []graphNode[T]
+	outputs []int
+
+	// err is the first misuse of the builder methods, reported by Build
+	err error
+
+	// shapes holds the sample shape of every node as of the last Build
+	shapes        [][]int
+	inputs        int
+	params, grads []*vectors.Tensor[T]
+	counts        []int
+	rows          []Rows
+
+	// values, masks and dys hold the output, padding mask and output
+	// gradient of every node in the last Forward and Backward
+	batch   int
+	values  []*vectors.Tensor[T]
+	masks   [][]bool
+	dys     []*vectors.Tensor[T]
+	reached []bool
+
+	// input is the shape of the last Forward's batch, which the input
+	// gradient takes
+	input   []int
+	xs      []*vectors.Tensor[T]
+	shape   []int
+	scratch *vectors.Tensor[T]
+	out, dx *vectors.Tensor[T]
+}
+
+// graphNode is an input of a Graph, with its sample shape, or a layer or
+// merge layer with the nodes it reads
+type graphNode[T vectors.Float] struct {
+	layer  Layer[T]
+	merge  MergeLayer[T]
+	inputs []int
+	shape  []int
+}
+
+// stage returns the layer of the node, nil for an input
+func (n *graphNode[T]) stage() any {
+	if n.layer != nil {
+		return n.layer
+	}
+	if n.merge != nil {
+		return n.merge
+	}
+	return nil
+}
+
+// Node is a node of a Graph: one of its inputs or the output of one of its layers
+type Node[T vectors.Float] struct {
+	graph *Graph[T]
+	index int
+}
+
+// NewGraph returns an empty graph
+func NewGraph[T vectors.Float]() *Graph[T] {
+	return &Graph[T]{}
+}
+
+// fail records the first misuse of the builder methods
+func (g *Graph[T]) fail(format string, args ...any) {
+	if g.err == nil {
+		g.err = fmt.Errorf("graph: "+format, args...)
+	}
+}
+
+// add appends a node reading inputs, which must be nodes of g
+func (g *Graph[T]) add(node graphNode[T], inputs ...*Node[T]) *Node[T] {
+
+	for _, in := range inputs {
+		if in == nil || in.graph != g {
+			g.fail("node %d reads a node of another graph", len(g.nodes))
+			continue
+		}
+		node.inputs = append(node.inputs, in.index)
+	}
+
+	g.nodes = append(g.nodes, node)
+
+	return &Node[T]{graph: g, index: len(g.nodes) - 1}
+}
+
+// Input declares the next input of the graph, with samples of the given shape
+func (g *Graph[T]) Input(shape ...int) *Node[T] {
+
+	if len(shape) == 0 || flat(shape) <= 0 {
+		g.fail("input %v must have a positive size", shape)
+	}
+
+	return g.add(graphNode[T]{shape: append([]int(nil), shape...)})
+}
+
+// Apply adds a node running layer on the output of input
+func (g *Graph[T]) Apply(layer Layer[T], input *Node[T]) *Node[T] {
+
+	if layer == nil {
+		g.fail("node %d has no layer", len(g.nodes))
+	}
+
+	return g.add(graphNode[T]{layer: layer}, input)
+}
+
+// Merge adds a node running layer on the outputs of inputs, in order
+func (g *Graph[T]) Merge(layer MergeLayer[T], inputs ...*Node[T]) *Node[T] {
+
+	if layer == nil {
+		g.fail("node %d has no layer", len(g.nodes))
+	}
+
+	return g.add(graphNode[T]{merge: layer}, inputs...)
+}
+
+// Output sets the nodes the graph returns, in order
+func (g *Graph[T]) Output(nodes ...*Node[T]) {
+
+	g.outputs = g.outputs[:0]
+
+	for _, n := range nodes {
+		if n == nil || n.graph != g {
+			g.fail("output is a node of another graph")
+			continue
+		}
+		g.outputs = append(g.outputs, n.index)
+	}
+}
+
+// walk returns the sample shape of every node for graph inputs of the given
+// shape, building every layer first when build is set
+func (g *Graph[T]) walk(input []int, rng *rand.Rand, build bool) ([][]int, error) {
+
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	if len(g.outputs) == 0 {
+		return nil, fmt.Errorf("graph: no outputs")
+	}
+
+	size := 0
+	for _, n := range g.nodes {
+		if n.stage() == nil {
+			size += flat(n.shape)
+		}
+	}
+
+	if flat(input) != size {
+		return nil, fmt.Errorf("graph: inputs hold %d values, got input shape %v", size, input)
+	}
+
+	shapes := make([][]int, len(g.nodes))
+
+	for i, n := range g.nodes {
+
+		var err error
+
+		switch {
+
+		case n.layer != nil:
+			if build {
+				if err := n.layer.Build(shapes[n.inputs[0]], rng); err != nil {
+					return nil, fmt.Errorf("node %d: %v", i, err)
+				}
+			}
+			shapes[i], err = n.layer.OutputShape(shapes[n.inputs[0]])
+
+		case n.merge != nil:
+			in := make([][]int, len(n.inputs))
+			for k, j := range n.inputs {
+				in[k] = shapes[j]
+			}
+			if build {
+				if err := n.merge.Build(in, rng); err != nil {
+					return nil, fmt.Errorf("node %d: %v", i, err)
+				}
+			}
+			shapes[i], err = n.merge.OutputShape(in)
+
+		default:
+			shapes[i] = n.shape
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("node %d: %v", i, err)
+		}
+	}
+
+	return shapes, nil
+}
+
+// outputShape returns the sample shape of the graph output for node shapes
+func (g *Graph[T]) outputShape(shapes [][]int) []int {
+
+	if len(g.outputs) == 1 {
+		return shapes[g.outputs[0]]
+	}
+
+	size := 0
+	for _, o := range g.outputs {
+		size += flat(shapes[o])
+	}
+
+	return []int{size}
+}
+
+func (g *Graph[T]) Build(input []int, rng *rand.Rand) error {
+
+	// A layer keeps the state of one Forward, so it cannot run in two nodes
+	seen := make(map[any]int)
+	for i, n := range g.nodes {
+		stage := n.stage()
+		if stage == nil || !reflect.TypeOf(stage).Comparable() {
+			continue
+		}
+		if j, ok := seen[stage]; ok {
+			return fmt.Errorf("graph: nodes %d and %d share a layer", j, i)
+		}
+		seen[stage] = i
+	}
+
+	g.shapes = nil
+
+	shapes, err := g.walk(input, rng, true)
+	if err != nil {
+		return err
+	}
+
+	g.params, g.grads, g.counts = nil, nil, nil
+	g.inputs = flat(input)
+
+	for i, n := range g.nodes {
+
+		if n.stage() == nil {
+			g.counts = append(g.counts, 0)
+			continue
+		}
+
+		stage := n.stage().(interface {
+			Params() []*vectors.Tensor[T]
+			Grads() []*vectors.Tensor[T]
+		})
+
+		params, grads := stage.Params(), stage.Grads()
+		if len(params) != len(grads) {
+			return fmt.Errorf("node %d: %d parameters but %d gradients", i, len(params), len(grads))
+		}
+
+		g.params = append(g.params, params...)
+		g.grads = append(g.grads, grads...)
+		g.counts = append(g.counts, len(params))
+	}
+
+	g.shapes = shapes
+	g.batch = 0
+	g.values = make([]*vectors.Tensor[T], len(g.nodes))
+	g.masks = make([][]bool, len(g.nodes))
+	g.dys = make([]*vectors.Tensor[T], len(g.nodes))
+	g.reached = make([]bool, len(g.nodes))
+
+	return nil
+}
+
+func (g *Graph[T]) OutputShape(input []int) ([]int, error) {
+
+	shapes, err := g.walk(input, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.outputShape(shapes), nil
+}
+
+// Forward runs every node in the order they were added
+func (g *Graph[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if g.shapes == nil {
+		return nil, fmt.Errorf("graph: not built")
+	}
+
+	if x.Dims() < 2 || x.Len() != x.Shape[0]*g.inputs {
+		return nil, fmt.Errorf("graph: input has shape %v, want [batch, %d values]", x.Shape, g.inputs)
+	}
+
+	batch := x.Shape[0]
+	offset := 0
+
+	g.input = append(g.input[:0], x.Shape...)
+
+	for i, n := range g.nodes {
+
+		var out *vectors.Tensor[T]
+		var mask []bool
+		var err error
+
+		switch {
+
+		case n.layer != nil:
+			mask = g.masks[n.inputs[0]]
+			if l, ok := n.layer.(Masked); ok {
+				l.SetMask(mask)
+			}
+			out, err = n.layer.Forward(g.values[n.inputs[0]], training)
+
+		case n.merge != nil:
+			g.xs = g.xs[:0]
+			for _, j := range n.inputs {
+				g.xs = append(g.xs, g.values[j])
+			}
+			mask = g.masks[n.inputs[0]]
+			if l, ok := n.merge.(Masked); ok {
+				l.SetMask(mask)
+			}
+			out, err = n.merge.Forward(g.xs, training)
+
+		default:
+			// Inputs own their buffers, cut from the columns of x
+			size := flat(n.shape)
+			g.shape = append(append(g.shape[:0], batch), n.shape...)
+			out = vectors.Reuse(g.values[i], g.shape...)
+			for b := range batch {
+				at := b*g.inputs + offset
+				copy(out.Data[b*size:(b+1)*size], x.Data[at:at+size])
+			}
+			offset += size
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("node %d: %v", i, err)
+		}
+
+		g.values[i] = out
+		g.masks[i] = outputMask(n.stage(), out, mask)
+	}
+
+	g.batch = batch
+
+	if len(g.outputs) == 1 {
+		return g.values[g.outputs[0]], nil
+	}
+
+	g.out = vectors.Reuse(g.out, batch, flat(g.outputShape(g.shapes)))
+	width, offset := g.out.Shape[1], 0
+
+	for _, o := range g.outputs {
+		size := g.values[o].Len() / batch
+		for b := range batch {
+			copy(g.out.Data[b*width+offset:b*width+offset+size], g.values[o].Data[b*size:(b+1)*size])
+		}
+		offset += size
+	}
+
+	return g.out, nil
+}
+
+// accumulate adds grad, the gradient of the output of node i, to dys[i]
+func (g *Graph[T]) accumulate(i int, grad *vectors.Tensor[T]) error {
+
+	value := g.values[i]
+
+	if grad.Len() != value.Len() {
+		return fmt.Errorf("graph: gradient shape %v does not match output shape %v of node %d", grad.Shape, value.Shape, i)
+	}
+
+	if !g.reached[i] {
+		g.reached[i] = true
+		g.dys[i] = vectors.Reuse(g.dys[i], value.Shape...)
+		copy(g.dys[i].Data, grad.Data)
+		return nil
+	}
+
+	for k, v := range grad.Data {
+		g.dys[i].Data[k] += v
+	}
+
+	return nil
+}
+
+// Backward runs the backward pass of every node in reverse order, summing
+// the gradients of nodes that feed several others
+func (g *Graph[T]) Backward(dy *vectors.Tensor[T]) (*vectors.Tensor[T], error) {
+
+	if g.batch == 0 {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+
+	clear(g.reached)
+
+	if len(g.outputs) == 1 {
+		if err := g.accumulate(g.outputs[0], dy); err != nil {
+			return nil, err
+		}
+	} else {
+
+		if !dy.SameShape(g.out) {
+			return nil, fmt.Errorf("graph: gradient shape %v does not match output shape %v", dy.Shape, g.out.Shape)
+		}
+
+		width, offset := g.out.Shape[1], 0
+
+		for _, o := range g.outputs {
+
+			g.scratch = vectors.Reuse(g.scratch, g.values[o].Shape...)
+			size := g.scratch.Len() / g.batch
+
+			for b := range g.batch {
+				copy(g.scratch.Data[b*size:(b+1)*size], dy.Data[b*width+offset:b*width+offset+size])
+			}
+
+			if err := g.accumulate(o, g.scratch); err != nil {
+				return nil, err
+			}
+
+			offset += size
+		}
+	}
+
+	for i := len(g.nodes) - 1; i >= 0; i-- {
+
+		n := g.nodes[i]
+		if n.stage() == nil {
+			continue
+		}
+
+		// Nodes no output depends on still write their parameters' zero gradients
+		if !g.reached[i] {
+			g.reached[i] = true
+			g.dys[i] = vectors.Reuse(g.dys[i], g.values[i].Shape...)
+			g.dys[i].Zero()
+		}
+
+		if n.layer != nil {
+
+			dx, err := n.layer.Backward(g.dys[i])
+			if err != nil {
+				return nil, fmt.Errorf("node %d: %v", i, err)
+			}
+
+			if err := g.accumulate(n.inputs[0], dx); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		dxs, err := n.merge.Backward(g.dys[i])
+		if err != nil {
+			return nil, fmt.Errorf("node %d: %v", i, err)
+		}
+
+		if len(dxs) != len(n.inputs) {
+			return nil, fmt.Errorf("node %d: %d gradients for %d inputs", i, len(dxs), len(n.inputs))
+		}
+
+		for k, j := range n.inputs {
+			if err := g.accumulate(j, dxs[k]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The gradient of the graph input gathers the gradients of every input node
+	g.dx = vectors.Reuse(g.dx, g.input...)
+	offset := 0
+
+	for i, n := range g.nodes {
+
+		if n.stage() != nil {
+			continue
+		}
+
+		size := flat(n.shape)
+
+		for b := range g.batch {
+			row := g.dx.Data[b*g.inputs+offset : b*g.inputs+offset+size]
+			if g.reached[i] {
+				copy(row, g.dys[i].Data[b*size:(b+1)*size])
+			} else {
+				clear(row)
+			}
+		}
+
+		offset += size
+	}
+
+	return g.dx, nil
+}
+
+// SetBackend sets the backend of every node that runs on one
+func (g *Graph[T]) SetBackend(be backend.Backend[T]) {
+	for _, n := range g.nodes {
+		if l, ok := n.stage().(BackendSetter[T]); ok {
+			l.SetBackend(be)
+		}
+	}
+}
+
+// Params returns the parameters of every node in order, as of the last Build
+func (g *Graph[T]) Params() []*vectors.Tensor[T] { return g.params }
+
+// Grads returns the gradients of every node in order, as of the last Build
+func (g *Graph[T]) Grads() []*vectors.Tensor[T] { return g.grads }
+
+// State returns the state of every Stateful layer in node order
+func (g *Graph[T]) State() []*vectors.Tensor[T] {
+
+	var state []*vectors.Tensor[T]
+
+	for _, n := range g.nodes {
+		if l, ok := n.stage().(Stateful[T]); ok {
+			state = append(state, l.State()...)
+		}
+	}
+
+	return state
+}
+
+// SparseRows returns the rows of every Sparse layer and the zero Rows for the
+// parameters of the others, in the order of Params. It is nil when no layer
+// has sparse gradients.
+func (g *Graph[T]) SparseRows() []Rows {
+
+	if len(g.counts) != len(g.nodes) {
+		return nil
+	}
+
+	g.rows = g.rows[:0]
+	sparse := false
+
+	for i, n := range g.nodes {
+
+		var rows []Rows
+		if l, ok := n.stage().(Sparse); ok {
+			rows = l.SparseRows()
+		}
+
+		if rows == nil {
+			for range g.counts[i] {
+				g.rows = append(g.rows, Rows{})
+			}
+			continue
+		}
+
+		g.rows = append(g.rows, rows...)
+		sparse = true
+	}
+
+	if !sparse {
+		return nil
+	}
+
+	return g.rows
+}
+
+// OutputActivations returns the activation applied last by every output, ""
+// when it is not known. Models use them to pick a default loss per output.
+func (g *Graph[T]) OutputActivations() []activation.ActivationFunction {
+
+	activations := make([]activation.ActivationFunction, len(g.outputs))
+
+	for k, o := range g.outputs {
+		if layer := g.nodes[o].layer; layer != nil {
+			activations[k] = OutputActivation(layer)
+		}
+	}
+
+	return activations
+}
+
+func (g *Graph[T]) outputActivation() activation.ActivationFunction {
+	if len(g.outputs) != 1 {
+		return ""
+	}
+	return g.OutputActivations()[0]
+}
+
+// Topology describes the nodes of a Graph, which models save with their
+// parameters and check when they are loaded again
+type Topology struct {
+	Nodes []Vertex
+
+	// Outputs are the indices of the output nodes, in order
+	Outputs []int
+}
+
+// Vertex describes one node of a Topology
+type Vertex struct {
+
+	// Layer is the type name of the node's layer, such as "Dense", or
+	// "Input" for an input
+	Layer string
+
+	// Inputs are the indices of the nodes it reads, in order
+	Inputs []int
+
+	// Shape is the shape of one output sample
+	Shape []int
+
+	// Params is the number of parameter values of the node's layer
+	Params int
+}
+
+// Topology returns the topology of the graph as of the last Build, with
+// no nodes before it
+func (g *Graph[T]) Topology() Topology {
+
+	topology := Topology{Outputs: append([]int(nil), g.outputs...)}
+
+	if g.shapes == nil {
+		return topology
+	}
+
+	next := 0
+
+	for i, n := range g.nodes {
+
+		vertex := Vertex{Layer: "Input", Inputs: append([]int(nil), n.inputs...), Shape: append([]int(nil), g.shapes[i]...)}
+
+		if stage := n.stage(); stage != nil {
+			vertex.Layer = typeName(stage)
+		}
+
+		for _, p := range g.params[next : next+g.counts[i]] {
+			vertex.Params += p.Len()
+		}
+		next += g.counts[i]
+
+		topology.Nodes = append(topology.Nodes, vertex)
+	}
+
+	return topology
+}
+
+// typeName returns the name of the type of v without its package, pointer
+// or type arguments, such as "Dense" for a *Dense[float32]
+func typeName(v any) string {
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	name, _, _ := strings.Cut(t.Name(), "[")
+
+	return name
+}
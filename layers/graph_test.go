@@ -0,0 +1,251 @@
+package layers_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+func TestGraphShapes(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		graph func() *layers.Graph[float64]
+		input []int
+		want  []int // nil when the graph or input is refused
+	}{
+		{
+			name: "one output",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				g.Output(g.Apply(&layers.Dense[float64]{Neurons: 3}, g.Input(4)))
+				return g
+			},
+			input: []int{4},
+			want:  []int{3},
+		},
+		{
+			name: "inputs of any shape holding as many values",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				a := g.Input(2, 3)
+				b := g.Input(2, 1)
+				g.Output(g.Merge(&layers.Concatenate[float64]{}, a, b, a))
+				return g
+			},
+			input: []int{2, 4},
+			want:  []int{2, 7},
+		},
+		{
+			name: "two outputs side by side",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				x := g.Input(2, 3)
+				g.Output(x, g.Apply(&layers.Flatten[float64]{}, g.Apply(&layers.SimpleRNN[float64]{Units: 2}, x)))
+				return g
+			},
+			input: []int{6},
+			want:  []int{8},
+		},
+		{
+			name: "no outputs",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				g.Apply(&layers.Dense[float64]{Neurons: 3}, g.Input(4))
+				return g
+			},
+			input: []int{4},
+		},
+		{
+			name: "inputs of another size",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				g.Output(g.Input(4))
+				return g
+			},
+			input: []int{5},
+		},
+		{
+			name: "a layer in two nodes",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				dense := &layers.Dense[float64]{Neurons: 4}
+				g.Output(g.Apply(dense, g.Apply(dense, g.Input(4))))
+				return g
+			},
+			input: []int{4},
+		},
+		{
+			name: "a node of another graph",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				g.Input(4)
+				g.Output(g.Apply(&layers.Dense[float64]{Neurons: 3}, layers.NewGraph[float64]().Input(4)))
+				return g
+			},
+			input: []int{4},
+		},
+		{
+			name: "adding shapes that differ",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				x := g.Input(4)
+				g.Output(g.Merge(&layers.Add[float64]{}, x, g.Apply(&layers.Dense[float64]{Neurons: 3}, x)))
+				return g
+			},
+			input: []int{4},
+		},
+		{
+			name: "concatenating sequences of other lengths",
+			graph: func() *layers.Graph[float64] {
+				g := layers.NewGraph[float64]()
+				g.Output(g.Merge(&layers.Concatenate[float64]{}, g.Input(2, 3), g.Input(3, 2)))
+				return g
+			},
+			input: []int{12},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if tt.want == nil {
+				if err := tt.graph().Build(tt.input, rand.New(rand.NewSource(1))); err == nil {
+					t.Errorf("Build accepted the graph for samples of shape %v", tt.input)
+				}
+				return
+			}
+
+			checkShapes(t, tt.graph(), tt.input, tt.want)
+		})
+	}
+}
+
+func TestGraphValues(t *testing.T) {
+
+	// Both inputs feed two merges, so their gradients are summed
+	g := layers.NewGraph[float64]()
+	a := g.Input(2)
+	b := g.Input(2)
+	g.Output(g.Merge(&layers.Add[float64]{}, a, b), g.Merge(&layers.Concatenate[float64]{}, b, a))
+
+	if err := g.Build([]int{4}, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	x := vectors.NewTensor[float64](1, 4)
+	copy(x.Data, []float64{1, 2, 3, 4})
+
+	y, err := g.Forward(x, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []float64{4, 6, 3, 4, 1, 2}; !slices.Equal(y.Data, want) {
+		t.Errorf("output %v, want %v", y.Data, want)
+	}
+
+	dy := vectors.NewTensor[float64](1, 6)
+	copy(dy.Data, []float64{1, 2, 10, 20, 30, 40})
+
+	dx, err := g.Backward(dy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []float64{31, 42, 11, 22}; !slices.Equal(dx.Data, want) {
+		t.Errorf("input gradient %v, want %v", dx.Data, want)
+	}
+}
+
+func TestGraphMask(t *testing.T) {
+
+	// Tokens and per-token features concatenated, then averaged over the steps
+	graph := func() *layers.Graph[float64] {
+		g := layers.NewGraph[float64]()
+		tokens := g.Input(3)
+		features := g.Input(3, 2)
+		s := g.Apply(&layers.Embedding[float64]{Vocabulary: 5, Dimensions: 2, MaskZero: true}, tokens)
+		g.Output(g.Apply(&layers.GlobalAveragePooling1D[float64]{}, g.Merge(&layers.Concatenate[float64]{}, s, features)))
+		return g
+	}
+
+	g := graph()
+	if err := g.Build([]int{9}, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last step is padding
+	x := vectors.NewTensor[float64](1, 9)
+	copy(x.Data, []float64{3, 1, 0, 0.5, -1, 2, 0.25, 7, 7})
+
+	y, err := g.Forward(x, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := slices.Clone(y.Data)
+
+	// The concatenation keeps the mask of the tokens, so the features of the padding step are left out
+	x.Data[7], x.Data[8] = -3, 100
+
+	if y, err = g.Forward(x, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(y.Data, before) {
+		t.Errorf("output changed with the features of a padding step: %v, want %v", y.Data, before)
+	}
+
+	if want := []float64{(0.5 + 2) / 2, (-1 + 0.25) / 2}; !slices.Equal(y.Data[2:], want) {
+		t.Errorf("averaged features %v, want %v", y.Data[2:], want)
+	}
+}
+
+func TestGraphTopology(t *testing.T) {
+
+	g := layers.NewGraph[float64]()
+	x := g.Input(4)
+	h := g.Apply(&layers.Dense[float64]{Neurons: 4, ActivationFunction: activation.Tanh}, x)
+	h = g.Merge(&layers.Add[float64]{}, x, h)
+	g.Output(g.Apply(&layers.Dense[float64]{Neurons: 2, ActivationFunction: activation.Softmax}, h))
+
+	if got := g.Topology(); len(got.Nodes) != 0 || !slices.Equal(got.Outputs, []int{3}) {
+		t.Errorf("topology before Build is %+v, want outputs [3] alone", got)
+	}
+
+	if err := g.Build([]int{4}, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []layers.Vertex{
+		{Layer: "Input", Shape: []int{4}},
+		{Layer: "Dense", Inputs: []int{0}, Shape: []int{4}, Params: 20},
+		{Layer: "Add", Inputs: []int{0, 1}, Shape: []int{4}},
+		{Layer: "Dense", Inputs: []int{2}, Shape: []int{2}, Params: 10},
+	}
+
+	got := g.Topology()
+
+	if len(got.Nodes) != len(want) {
+		t.Fatalf("topology has %d nodes, want %d", len(got.Nodes), len(want))
+	}
+
+	for i, v := range got.Nodes {
+		if v.Layer != want[i].Layer || !slices.Equal(v.Inputs, want[i].Inputs) || !slices.Equal(v.Shape, want[i].Shape) || v.Params != want[i].Params {
+			t.Errorf("node %d is %+v, want %+v", i, v, want[i])
+		}
+	}
+
+	if !slices.Equal(got.Outputs, []int{3}) {
+		t.Errorf("outputs %v, want [3]", got.Outputs)
+	}
+
+	if act := g.OutputActivations(); !slices.Equal(act, []activation.ActivationFunction{activation.Softmax}) {
+		t.Errorf("output activations %v, want [softmax]", act)
+	}
+}
@@ -0,0 +1,61 @@
+package layers
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Initialization represents weight initialization strategy
+type Initialization string
+
+const (
+	XavierUniform  Initialization = "xavier_uniform"
+	XavierNormal   Initialization = "xavier_normal"
+	KaimingUniform Initialization = "kaiming_uniform"
+	KaimingNormal  Initialization = "kaiming_normal"
+)
+
+// Initialize fills weights using the given strategy for a layer with fanIn
+// inputs and fanOut outputs per weight. Unknown strategies fall back to
+// Xavier Normal. Values are drawn from rng, or from the global source when
+// rng is nil.
+func Initialize[T vectors.Float](weights []T, fanIn, fanOut int, init Initialization, rng *rand.Rand) {
+
+	normal, uniform := rand.NormFloat64, rand.Float64
+	if rng != nil {
+		normal, uniform = rng.NormFloat64, rng.Float64
+	}
+
+	switch init {
+
+	// Kaiming Normal: w ~ N(0, sqrt(2/fan_in))
+	case KaimingNormal:
+		std := math.Sqrt(2.0 / float64(fanIn))
+		for k := range weights {
+			weights[k] = T(normal() * std)
+		}
+
+	// Kaiming Uniform: w ~ U(-limit, limit), limit = sqrt(6/fan_in)
+	case KaimingUniform:
+		limit := math.Sqrt(6.0 / float64(fanIn))
+		for k := range weights {
+			weights[k] = T((uniform()*2 - 1) * limit)
+		}
+
+	// Xavier Uniform: w ~ U(-limit, limit), limit = sqrt(6/(fan_in+fan_out))
+	case XavierUniform:
+		limit := math.Sqrt(6.0 / float64(fanIn+fanOut))
+		for k := range weights {
+			weights[k] = T((uniform()*2 - 1) * limit)
+		}
+
+	// Xavier Normal: w ~ N(0, sqrt(2/(fan_in+fan_out))), also the default
+	default:
+		std := math.Sqrt(2.0 / float64(fanIn+fanOut))
+		for k := range weights {
+			weights[k] = T(normal() * std)
+		}
+	}
+}
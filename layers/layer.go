@@ -0,0 +1,201 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/autodiff"
+	"github.com/ThakurMayank5/gonn/backend"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Layer is one stage of a network. Tensors passed between layers hold a
+// batch: the first dimension is the sample and the rest is the shape of one
+// sample, e.g. [batch, features].
+//
+// A layer keeps what its backward pass needs from the last Forward, so a
+// layer value must not be used by several networks or goroutines at once.
+type Layer[T vectors.Float] interface {
+
+	// Build sizes and initializes the parameters for input samples of the
+	// given shape, drawing random values from rng. It is called before the
+	// first Forward and again whenever the network is re-initialized.
+	Build(input []int, rng *rand.Rand) error
+
+	// OutputShape returns the shape of one output sample for input samples
+	// of the given shape
+	OutputShape(input []int) ([]int, error)
+
+	// Forward computes the output for the batch x. With training set, layers
+	// such as dropout behave as they do while learning. The output is owned
+	// by the layer and valid until the next Forward.
+	Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error)
+
+	// Backward takes dy, the gradient of the loss with respect to the output
+	// of the last Forward, writes the gradients of the parameters to Grads
+	// and returns the gradient with respect to the input of that Forward
+	Backward(dy *vectors.Tensor[T]) (*vectors.Tensor[T], error)
+
+	// Params returns the trainable parameters and Grads their gradients, in
+	// the same order. Both are empty for layers without parameters.
+	Params() []*vectors.Tensor[T]
+	Grads() []*vectors.Tensor[T]
+}
+
+// Stateful is implemented by layers with values that are not trained by
+// gradient descent but must be saved with the parameters, such as the
+// running statistics of batch normalization
+type Stateful[T vectors.Float] interface {
+	State() []*vectors.Tensor[T]
+}
+
+// Sparse is implemented by layers whose parameters only receive gradient in
+// some rows on each step, such as the table of an Embedding, whose rows are
+// the vectors of the IDs in the batch. Optimizers that support it update only
+// those rows.
+type Sparse interface {
+
+	// SparseRows returns, in the order of Params, the rows of every parameter
+	// that Backward last wrote gradient to. The gradient of every other row
+	// is zero. A nil result means every parameter is dense.
+	SparseRows() []Rows
+}
+
+// Rows lists rows of a parameter viewed as a matrix of Width columns. The
+// zero Rows stands for the whole parameter.
+type Rows struct {
+	Width   int
+	Indices []int
+}
+
+// Dense reports whether r stands for the whole parameter
+func (r Rows) Dense() bool { return r.Width == 0 }
+
+// Masking is implemented by layers that know which steps of the sequences
+// they output are padding, such as an Embedding with MaskZero
+type Masking interface {
+
+	// OutputMask returns a flag per sample and step of the output of the
+	// last Forward, false for padding, or nil when every step is real
+	OutputMask() []bool
+}
+
+// Masked is implemented by layers that leave out the padding steps of their
+// input sequences, such as attention. Sequential passes them the mask of
+// their input before every Forward.
+type Masked interface {
+
+	// SetMask sets the flags of the next input's steps, one per sample and
+	// step, false for padding. nil means every step is real.
+	SetMask(mask []bool)
+}
+
+// BackendSetter is implemented by layers that run their array operations on
+// a backend. Containers pass the backend on to their layers.
+type BackendSetter[T vectors.Float] interface {
+
+	// SetBackend makes the next passes run on be, nil for backend.Default
+	SetBackend(be backend.Backend[T])
+}
+
+// OutputActivation returns the activation applied last by layer, or "" when
+// it is not known. Models use it to pick a default loss.
+func OutputActivation[T vectors.Float](layer Layer[T]) activation.ActivationFunction {
+	if l, ok := layer.(interface {
+		outputActivation() activation.ActivationFunction
+	}); ok {
+		return l.outputActivation()
+	}
+	return ""
+}
+
+// taped runs the forward pass of a built-in layer on its own autodiff tape,
+// so the backward pass is the tape's reverse walk. Layers embed it and
+// record their ops between begin and end.
+type taped[T vectors.Float] struct {
+	tape   autodiff.Tape[T]
+	input  *autodiff.Node[T]
+	output *autodiff.Node[T]
+}
+
+func (l *taped[T]) SetBackend(be backend.Backend[T]) {
+	l.tape.Backend = be
+}
+
+// begin resets the tape and records x as the input whose gradient Backward returns
+func (l *taped[T]) begin(x *vectors.Tensor[T]) (*autodiff.Node[T], error) {
+
+	l.tape.Reset()
+	l.output = nil
+
+	input, err := l.tape.Param(x, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	l.input = input
+
+	return input, nil
+}
+
+// end marks out as the output of the forward pass and returns its value
+func (l *taped[T]) end(out *autodiff.Node[T]) *vectors.Tensor[T] {
+	l.output = out
+	return out.Value
+}
+
+func (l *taped[T]) Backward(dy *vectors.Tensor[T]) (*vectors.Tensor[T], error) {
+
+	if l.output == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+
+	if err := l.tape.BackwardWith(l.output, dy); err != nil {
+		return nil, err
+	}
+
+	return l.input.Grad, nil
+}
+
+// matrix views the batch in as a [batch, values] matrix for the row-wise
+// ops of the tape, leaving 2D inputs as they are
+func (l *taped[T]) matrix(in *autodiff.Node[T]) (*autodiff.Node[T], error) {
+	if in.Value.Dims() == 2 {
+		return in, nil
+	}
+	return l.tape.Reshape(in, in.Value.Shape[0], in.Value.Len()/in.Value.Shape[0])
+}
+
+// restore views out with the shape of the input batch again
+func (l *taped[T]) restore(out *autodiff.Node[T]) (*autodiff.Node[T], error) {
+	if out.Value.Dims() == l.input.Value.Dims() {
+		return out, nil
+	}
+	return l.tape.Reshape(out, l.input.Value.Shape...)
+}
+
+// flat returns the number of values in a sample of the given shape
+func flat(shape []int) int {
+	n := 1
+	for _, d := range shape {
+		n *= d
+	}
+	return n
+}
+
+// checkSample returns an error unless x is a batch of samples of the given shape
+func checkSample[T vectors.Float](layer string, x *vectors.Tensor[T], shape []int) error {
+
+	if x.Dims() != len(shape)+1 {
+		return fmt.Errorf("%s: input has shape %v, want [batch %v]", layer, x.Shape, shape)
+	}
+
+	for i, d := range shape {
+		if x.Shape[i+1] != d {
+			return fmt.Errorf("%s: input has shape %v, want [batch %v]", layer, x.Shape, shape)
+		}
+	}
+
+	return nil
+}
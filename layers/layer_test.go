@@ -0,0 +1,60 @@
+package layers_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// randomTensor returns a tensor of the given shape with standard normal values
+func randomTensor(rng *rand.Rand, shape ...int) *vectors.Tensor[float64] {
+	t := vectors.NewTensor[float64](shape...)
+	for i := range t.Data {
+		t.Data[i] = rng.NormFloat64()
+	}
+	return t
+}
+
+// checkShapes builds layer for input samples, checks that OutputShape is want
+// and that a forward and backward pass over a batch of 2 keep to the shapes
+func checkShapes(t *testing.T, layer layers.Layer[float64], input, want []int) {
+	t.Helper()
+
+	rng := rand.New(rand.NewSource(1))
+
+	if err := layer.Build(input, rng); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := layer.OutputShape(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("output shape %v, want %v", got, want)
+	}
+
+	x := randomTensor(rng, append([]int{2}, input...)...)
+
+	y, err := layer.Forward(x, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(y.Shape, append([]int{2}, want...)) {
+		t.Fatalf("forward output has shape %v, want [2 %v]", y.Shape, want)
+	}
+
+	dx, err := layer.Backward(randomTensor(rng, y.Shape...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(dx.Shape, x.Shape) {
+		t.Errorf("input gradient has shape %v, want %v", dx.Shape, x.Shape)
+	}
+}
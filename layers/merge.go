@@ -0,0 +1,235 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// MergeLayer is a stage of a Graph with several inputs, such as the sum of a
+// residual connection or the concatenation of branches. It works like a
+// Layer with one batch per input.
+type MergeLayer[T vectors.Float] interface {
+
+	// Build sizes the parameters for inputs of the given sample shapes
+	Build(inputs [][]int, rng *rand.Rand) error
+
+	// OutputShape returns the shape of one output sample for inputs of the
+	// given sample shapes
+	OutputShape(inputs [][]int) ([]int, error)
+
+	// Forward computes the output for the batches xs, one per input. The
+	// output is owned by the layer and valid until the next Forward.
+	Forward(xs []*vectors.Tensor[T], training bool) (*vectors.Tensor[T], error)
+
+	// Backward takes the gradient of the loss with respect to the output of
+	// the last Forward and returns the gradient with respect to each of its
+	// inputs, which callers must not modify
+	Backward(dy *vectors.Tensor[T]) ([]*vectors.Tensor[T], error)
+
+	Params() []*vectors.Tensor[T]
+	Grads() []*vectors.Tensor[T]
+}
+
+// Add sums inputs of the same shape element-wise, as the residual connection
+// of x + f(x)
+type Add[T vectors.Float] struct {
+	out *vectors.Tensor[T]
+	dxs []*vectors.Tensor[T]
+}
+
+func (a *Add[T]) Build(inputs [][]int, rng *rand.Rand) error {
+	_, err := a.OutputShape(inputs)
+	return err
+}
+
+func (a *Add[T]) OutputShape(inputs [][]int) ([]int, error) {
+
+	if len(inputs) < 2 {
+		return nil, fmt.Errorf("add: needs at least 2 inputs, got %d", len(inputs))
+	}
+
+	for _, shape := range inputs[1:] {
+		if !slices.Equal(shape, inputs[0]) {
+			return nil, fmt.Errorf("add: input shapes differ, %v and %v", inputs[0], shape)
+		}
+	}
+
+	return inputs[0], nil
+}
+
+func (a *Add[T]) Forward(xs []*vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if len(xs) < 2 {
+		return nil, fmt.Errorf("add: needs at least 2 inputs, got %d", len(xs))
+	}
+
+	for _, x := range xs[1:] {
+		if !x.SameShape(xs[0]) {
+			return nil, fmt.Errorf("add: input shapes differ, %v and %v", xs[0].Shape, x.Shape)
+		}
+	}
+
+	a.out = vectors.Reuse(a.out, xs[0].Shape...)
+	copy(a.out.Data, xs[0].Data)
+
+	for _, x := range xs[1:] {
+		for k, v := range x.Data {
+			a.out.Data[k] += v
+		}
+	}
+
+	a.dxs = slices.Grow(a.dxs[:0], len(xs))[:len(xs)]
+
+	return a.out, nil
+}
+
+// Backward passes dy to every input unchanged
+func (a *Add[T]) Backward(dy *vectors.Tensor[T]) ([]*vectors.Tensor[T], error) {
+
+	if a.out == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+
+	if !dy.SameShape(a.out) {
+		return nil, fmt.Errorf("add: gradient shape %v does not match output shape %v", dy.Shape, a.out.Shape)
+	}
+
+	for i := range a.dxs {
+		a.dxs[i] = dy
+	}
+
+	return a.dxs, nil
+}
+
+func (a *Add[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (a *Add[T]) Grads() []*vectors.Tensor[T] { return nil }
+
+// Concatenate joins its inputs along the last dimension of their samples,
+// which must agree on every other dimension: [steps, 8] and [steps, 4] give
+// [steps, 12]
+type Concatenate[T vectors.Float] struct {
+
+	// widths is the last dimension of every input of the last Forward,
+	// shape holds the shapes of the output and of the input gradients
+	widths []int
+	shape  []int
+
+	out *vectors.Tensor[T]
+	dxs []*vectors.Tensor[T]
+}
+
+func (c *Concatenate[T]) Build(inputs [][]int, rng *rand.Rand) error {
+	_, err := c.OutputShape(inputs)
+	return err
+}
+
+func (c *Concatenate[T]) OutputShape(inputs [][]int) ([]int, error) {
+
+	if len(inputs) < 2 {
+		return nil, fmt.Errorf("concatenate: needs at least 2 inputs, got %d", len(inputs))
+	}
+
+	first := inputs[0]
+	if len(first) == 0 {
+		return nil, fmt.Errorf("concatenate: input samples must have a dimension")
+	}
+
+	out := slices.Clone(first)
+
+	for _, shape := range inputs[1:] {
+		if len(shape) != len(first) || !slices.Equal(shape[:len(shape)-1], first[:len(first)-1]) {
+			return nil, fmt.Errorf("concatenate: input shapes %v and %v differ before the last dimension", first, shape)
+		}
+		out[len(out)-1] += shape[len(shape)-1]
+	}
+
+	return out, nil
+}
+
+func (c *Concatenate[T]) Forward(xs []*vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if len(xs) < 2 {
+		return nil, fmt.Errorf("concatenate: needs at least 2 inputs, got %d", len(xs))
+	}
+
+	first := xs[0]
+	if first.Dims() < 2 {
+		return nil, fmt.Errorf("concatenate: input has shape %v, want [batch, ...]", first.Shape)
+	}
+
+	last := first.Dims() - 1
+	width := 0
+
+	for _, x := range xs {
+		if x.Dims() != first.Dims() || !slices.Equal(x.Shape[:last], first.Shape[:last]) {
+			return nil, fmt.Errorf("concatenate: input shapes %v and %v differ before the last dimension", first.Shape, x.Shape)
+		}
+		width += x.Shape[last]
+	}
+
+	c.shape = append(c.shape[:0], first.Shape...)
+	c.shape[last] = width
+	c.out = vectors.Reuse(c.out, c.shape...)
+	c.widths = c.widths[:0]
+
+	// Every input is a matrix of rows of its last dimension, all with the same number of rows
+	rows := c.out.Len() / width
+	at := 0
+
+	for _, x := range xs {
+
+		w := x.Shape[last]
+		c.widths = append(c.widths, w)
+
+		for r := range rows {
+			copy(c.out.Data[r*width+at:r*width+at+w], x.Data[r*w:(r+1)*w])
+		}
+
+		at += w
+	}
+
+	return c.out, nil
+}
+
+// Backward splits dy back into the inputs' parts
+func (c *Concatenate[T]) Backward(dy *vectors.Tensor[T]) ([]*vectors.Tensor[T], error) {
+
+	if c.out == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+
+	if !dy.SameShape(c.out) {
+		return nil, fmt.Errorf("concatenate: gradient shape %v does not match output shape %v", dy.Shape, c.out.Shape)
+	}
+
+	if len(c.dxs) != len(c.widths) {
+		c.dxs = make([]*vectors.Tensor[T], len(c.widths))
+	}
+
+	width := dy.Shape[dy.Dims()-1]
+	rows := dy.Len() / width
+	at := 0
+
+	for i, w := range c.widths {
+
+		c.shape = append(c.shape[:0], dy.Shape...)
+		c.shape[len(c.shape)-1] = w
+		c.dxs[i] = vectors.Reuse(c.dxs[i], c.shape...)
+
+		for r := range rows {
+			copy(c.dxs[i].Data[r*w:(r+1)*w], dy.Data[r*width+at:r*width+at+w])
+		}
+
+		at += w
+	}
+
+	return c.dxs, nil
+}
+
+func (c *Concatenate[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (c *Concatenate[T]) Grads() []*vectors.Tensor[T] { return nil }
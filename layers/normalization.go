@@ -0,0 +1,242 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/autodiff"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+const (
+	defaultEpsilon = 1e-5
+
+	// defaultMomentum is the weight of the current batch in the running statistics
+	defaultMomentum = 0.1
+)
+
+// normalized holds the learned scale (gamma) and shift (beta) shared by the
+// normalization layers, one of each per value of a sample
+type normalized[T vectors.Float] struct {
+	gamma, beta         *vectors.Tensor[T]
+	gradGamma, gradBeta *vectors.Tensor[T]
+
+	// bound is set when gamma and beta were given by SetParams
+	bound bool
+
+	taped[T]
+}
+
+// SetParams makes the layer use gamma and beta, one value per value of a
+// sample, owned by the caller instead of tensors of its own. Neither is
+// copied. Build then keeps them and only allocates the gradients.
+func (n *normalized[T]) SetParams(gamma, beta *vectors.Tensor[T]) error {
+
+	if gamma.Dims() != 1 || !gamma.SameShape(beta) {
+		return fmt.Errorf("normalization: gamma of shape %v and beta of shape %v, want two vectors of the same length", gamma.Shape, beta.Shape)
+	}
+
+	n.gamma, n.beta = gamma, beta
+	n.bound = true
+
+	return nil
+}
+
+// build creates gamma = 1 and beta = 0 for samples of size values, or
+// checks the size of those given by SetParams
+func (n *normalized[T]) build(size int) error {
+
+	n.gradGamma = vectors.NewTensor[T](size)
+	n.gradBeta = vectors.NewTensor[T](size)
+
+	if n.bound {
+		if n.gamma.Len() != size {
+			return fmt.Errorf("normalization: %d values of gamma for samples of %d values", n.gamma.Len(), size)
+		}
+		return nil
+	}
+
+	n.gamma = vectors.NewTensor[T](size)
+	n.gamma.Fill(1)
+	n.beta = vectors.NewTensor[T](size)
+
+	return nil
+}
+
+// record starts the forward pass over x and returns it as a matrix together
+// with the gamma and beta nodes
+func (n *normalized[T]) record(layer string, x *vectors.Tensor[T]) (in, gamma, beta *autodiff.Node[T], err error) {
+
+	if n.gamma == nil {
+		return nil, nil, nil, fmt.Errorf("%s: layer is not built", layer)
+	}
+
+	if x.Dims() < 2 || x.Len()/x.Shape[0] != n.gamma.Len() {
+		return nil, nil, nil, fmt.Errorf("%s: input has shape %v, want samples of %d values", layer, x.Shape, n.gamma.Len())
+	}
+
+	if in, err = n.begin(x); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if in, err = n.matrix(in); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if gamma, err = n.tape.Param(n.gamma, n.gradGamma); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if beta, err = n.tape.Param(n.beta, n.gradBeta); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return in, gamma, beta, nil
+}
+
+func (n *normalized[T]) OutputShape(input []int) ([]int, error) {
+	return input, nil
+}
+
+func (n *normalized[T]) Params() []*vectors.Tensor[T] {
+	if n.gamma == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{n.gamma, n.beta}
+}
+
+func (n *normalized[T]) Grads() []*vectors.Tensor[T] {
+	if n.gamma == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{n.gradGamma, n.gradBeta}
+}
+
+// BatchNorm normalizes each value of a sample over the batch, then scales and
+// shifts it by learned gamma and beta. While training it uses the batch
+// statistics and folds them into running statistics, which are used
+// otherwise.
+type BatchNorm[T vectors.Float] struct {
+
+	// Momentum is the weight of the current batch in the running statistics, 0.1 when zero
+	Momentum float64
+
+	// Epsilon is added to the variance, 1e-5 when zero
+	Epsilon float64
+
+	runningMean, runningVariance *vectors.Tensor[T]
+
+	// boundState is set when the running statistics were given by SetState
+	boundState bool
+
+	normalized[T]
+}
+
+// SetState makes the layer keep its running statistics in mean and
+// variance, owned by the caller, the way SetParams does for gamma and beta
+func (b *BatchNorm[T]) SetState(mean, variance *vectors.Tensor[T]) error {
+
+	if mean.Dims() != 1 || !mean.SameShape(variance) {
+		return fmt.Errorf("batch norm: running mean of shape %v and variance of shape %v, want two vectors of the same length", mean.Shape, variance.Shape)
+	}
+
+	b.runningMean, b.runningVariance = mean, variance
+	b.boundState = true
+
+	return nil
+}
+
+func (b *BatchNorm[T]) Build(input []int, rng *rand.Rand) error {
+
+	size := flat(input)
+
+	if err := b.build(size); err != nil {
+		return err
+	}
+
+	if b.boundState {
+		if b.runningMean.Len() != size {
+			return fmt.Errorf("batch norm: %d running statistics for samples of %d values", b.runningMean.Len(), size)
+		}
+		return nil
+	}
+
+	b.runningMean = vectors.NewTensor[T](size)
+	b.runningVariance = vectors.NewTensor[T](size)
+	b.runningVariance.Fill(1)
+
+	return nil
+}
+
+func (b *BatchNorm[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	in, gamma, beta, err := b.record("batch norm", x)
+	if err != nil {
+		return nil, err
+	}
+
+	momentum, epsilon := b.Momentum, b.Epsilon
+	if momentum == 0 {
+		momentum = defaultMomentum
+	}
+	if epsilon == 0 {
+		epsilon = defaultEpsilon
+	}
+
+	out, err := b.tape.BatchNorm(in, gamma, beta, b.runningMean.Data, b.runningVariance.Data, training, momentum, epsilon)
+	if err != nil {
+		return nil, err
+	}
+
+	if out, err = b.restore(out); err != nil {
+		return nil, err
+	}
+
+	return b.end(out), nil
+}
+
+// State returns the running mean and variance
+func (b *BatchNorm[T]) State() []*vectors.Tensor[T] {
+	if b.runningMean == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{b.runningMean, b.runningVariance}
+}
+
+// LayerNorm normalizes the values of each sample to zero mean and unit
+// variance, then scales and shifts them by learned gamma and beta
+type LayerNorm[T vectors.Float] struct {
+
+	// Epsilon is added to the variance, 1e-5 when zero
+	Epsilon float64
+
+	normalized[T]
+}
+
+func (l *LayerNorm[T]) Build(input []int, rng *rand.Rand) error {
+	return l.build(flat(input))
+}
+
+func (l *LayerNorm[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	in, gamma, beta, err := l.record("layer norm", x)
+	if err != nil {
+		return nil, err
+	}
+
+	epsilon := l.Epsilon
+	if epsilon == 0 {
+		epsilon = defaultEpsilon
+	}
+
+	out, err := l.tape.LayerNorm(in, gamma, beta, epsilon)
+	if err != nil {
+		return nil, err
+	}
+
+	if out, err = l.restore(out); err != nil {
+		return nil, err
+	}
+
+	return l.end(out), nil
+}
@@ -0,0 +1,256 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/autodiff"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// poolWindow returns the window of a pooling layer, whose stride defaults
+// to the kernel size
+func poolWindow(kernel, stride, padding [2]int) autodiff.Window {
+
+	w := autodiff.Window{Kernel: kernel, Stride: stride, Padding: padding}
+
+	for i, s := range w.Stride {
+		if s == 0 {
+			w.Stride[i] = w.Kernel[i]
+		}
+	}
+
+	return w
+}
+
+// poolShape returns the output shape of a pooling layer for [height, width, channels] samples
+func poolShape(layer string, input []int, w autodiff.Window) ([]int, error) {
+
+	if len(input) != 3 {
+		return nil, fmt.Errorf("%s: input samples must be [height, width, channels], got shape %v", layer, input)
+	}
+
+	height, width, err := w.Output(input[0], input[1])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", layer, err)
+	}
+
+	return []int{height, width, input[2]}, nil
+}
+
+// MaxPool2D keeps the largest value of each channel of [height, width,
+// channels] images under every position of a window
+type MaxPool2D[T vectors.Float] struct {
+
+	// Kernel is the height and width of the window
+	Kernel [2]int
+
+	// Stride is the step between windows along height and width, zero means
+	// the kernel size so windows do not overlap
+	Stride [2]int
+
+	// Padding is the number of rows and columns added on each side, which
+	// are left out of the maximum
+	Padding [2]int
+
+	taped[T]
+}
+
+func (m *MaxPool2D[T]) Build(input []int, rng *rand.Rand) error {
+	_, err := m.OutputShape(input)
+	return err
+}
+
+func (m *MaxPool2D[T]) OutputShape(input []int) ([]int, error) {
+	return poolShape("max pool", input, poolWindow(m.Kernel, m.Stride, m.Padding))
+}
+
+func (m *MaxPool2D[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	in, err := m.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := m.tape.MaxPool2D(in, poolWindow(m.Kernel, m.Stride, m.Padding))
+	if err != nil {
+		return nil, err
+	}
+
+	return m.end(out), nil
+}
+
+func (m *MaxPool2D[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (m *MaxPool2D[T]) Grads() []*vectors.Tensor[T] { return nil }
+
+// AvgPool2D keeps the mean of each channel of [height, width, channels]
+// images under every position of a window
+type AvgPool2D[T vectors.Float] struct {
+
+	// Kernel is the height and width of the window
+	Kernel [2]int
+
+	// Stride is the step between windows along height and width, zero means
+	// the kernel size so windows do not overlap
+	Stride [2]int
+
+	// Padding is the number of rows and columns added on each side, which
+	// are left out of the mean
+	Padding [2]int
+
+	taped[T]
+}
+
+func (a *AvgPool2D[T]) Build(input []int, rng *rand.Rand) error {
+	_, err := a.OutputShape(input)
+	return err
+}
+
+func (a *AvgPool2D[T]) OutputShape(input []int) ([]int, error) {
+	return poolShape("avg pool", input, poolWindow(a.Kernel, a.Stride, a.Padding))
+}
+
+func (a *AvgPool2D[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	in, err := a.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := a.tape.AvgPool2D(in, poolWindow(a.Kernel, a.Stride, a.Padding))
+	if err != nil {
+		return nil, err
+	}
+
+	return a.end(out), nil
+}
+
+func (a *AvgPool2D[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (a *AvgPool2D[T]) Grads() []*vectors.Tensor[T] { return nil }
+
+// GlobalAveragePooling2D averages each channel over the whole image, turning
+// [height, width, channels] samples into vectors of channels values
+type GlobalAveragePooling2D[T vectors.Float] struct {
+	taped[T]
+}
+
+func (g *GlobalAveragePooling2D[T]) Build(input []int, rng *rand.Rand) error {
+	_, err := g.OutputShape(input)
+	return err
+}
+
+func (g *GlobalAveragePooling2D[T]) OutputShape(input []int) ([]int, error) {
+	if len(input) != 3 {
+		return nil, fmt.Errorf("global average pooling: input samples must be [height, width, channels], got shape %v", input)
+	}
+	return []int{input[2]}, nil
+}
+
+func (g *GlobalAveragePooling2D[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if x.Dims() != 4 {
+		return nil, fmt.Errorf("global average pooling: input has shape %v, want [batch, height, width, channels]", x.Shape)
+	}
+
+	in, err := g.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := g.tape.AvgPool2D(in, autodiff.Window{Kernel: [2]int{x.Shape[1], x.Shape[2]}})
+	if err != nil {
+		return nil, err
+	}
+
+	if out, err = g.tape.Reshape(out, x.Shape[0], x.Shape[3]); err != nil {
+		return nil, err
+	}
+
+	return g.end(out), nil
+}
+
+func (g *GlobalAveragePooling2D[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (g *GlobalAveragePooling2D[T]) Grads() []*vectors.Tensor[T] { return nil }
+
+// GlobalAveragePooling1D averages [steps, dims] sequences over their steps,
+// turning them into vectors of dims values. Padding steps flagged by the
+// mask of an earlier layer are left out of the mean.
+type GlobalAveragePooling1D[T vectors.Float] struct {
+	mask []bool
+
+	// weights is the [batch, batch*steps] matrix averaging the steps of every sample
+	weights *vectors.Tensor[T]
+
+	taped[T]
+}
+
+func (g *GlobalAveragePooling1D[T]) Build(input []int, rng *rand.Rand) error {
+	_, err := g.OutputShape(input)
+	return err
+}
+
+func (g *GlobalAveragePooling1D[T]) OutputShape(input []int) ([]int, error) {
+	if len(input) != 2 {
+		return nil, fmt.Errorf("global average pooling: input samples must be [steps, dims] sequences, got shape %v", input)
+	}
+	return []int{input[1]}, nil
+}
+
+func (g *GlobalAveragePooling1D[T]) SetMask(mask []bool) { g.mask = mask }
+
+func (g *GlobalAveragePooling1D[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	if x.Dims() != 3 {
+		return nil, fmt.Errorf("global average pooling: input has shape %v, want [batch, steps, dims]", x.Shape)
+	}
+
+	batch, steps, dims := x.Shape[0], x.Shape[1], x.Shape[2]
+
+	if g.mask != nil && len(g.mask) != batch*steps {
+		return nil, fmt.Errorf("global average pooling: mask has %d flags for %d steps", len(g.mask), batch*steps)
+	}
+
+	g.weights = vectors.Reuse(g.weights, batch, batch*steps)
+	g.weights.Zero()
+
+	for b := range batch {
+
+		kept := 0
+		for s := range steps {
+			if g.mask == nil || g.mask[b*steps+s] {
+				kept++
+			}
+		}
+
+		// A sample of padding alone averages to zeros
+		row := g.weights.Row(b)
+		for s := range steps {
+			if g.mask == nil || g.mask[b*steps+s] {
+				row[b*steps+s] = 1 / T(kept)
+			}
+		}
+	}
+
+	in, err := g.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	if in, err = g.tape.Reshape(in, batch*steps, dims); err != nil {
+		return nil, err
+	}
+
+	out, err := g.tape.MatMul(g.tape.Constant(g.weights), in, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.end(out), nil
+}
+
+func (g *GlobalAveragePooling1D[T]) Params() []*vectors.Tensor[T] { return nil }
+
+func (g *GlobalAveragePooling1D[T]) Grads() []*vectors.Tensor[T] { return nil }
@@ -0,0 +1,516 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/autodiff"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// recurrent holds what the recurrent layers share: the input weights W,
+// the recurrent weights U and the bias b of every gate stacked, and the
+// unrolling of a cell over the steps of [steps, features] sequences.
+type recurrent[T vectors.Float] struct {
+	input        []int
+	units, gates int
+
+	w, u, b             *vectors.Tensor[T]
+	gradW, gradU, gradB *vectors.Tensor[T]
+
+	// zeros is the initial state, outputs the hidden state of every step.
+	// uParam is U recorded once for all the steps of the pass, since the tape
+	// accumulates gradients per node.
+	zeros   *vectors.Tensor[T]
+	outputs []*autodiff.Node[T]
+	uParam  *autodiff.Node[T]
+
+	taped[T]
+}
+
+// cell computes the hidden state h and the cell state c of one step from
+// xw, the input's share x·Wᵀ + b of every gate, and the previous states
+type cell[T vectors.Float] func(xw, h, c *autodiff.Node[T]) (*autodiff.Node[T], *autodiff.Node[T], error)
+
+func (r *recurrent[T]) outputShape(layer string, input []int, units int, sequences bool) ([]int, error) {
+
+	if len(input) != 2 {
+		return nil, fmt.Errorf("%s: input samples must be [steps, features] sequences, got shape %v", layer, input)
+	}
+
+	if units <= 0 {
+		return nil, fmt.Errorf("%s: units must be positive, got %d", layer, units)
+	}
+
+	if sequences {
+		return []int{input[0], units}, nil
+	}
+
+	return []int{units}, nil
+}
+
+// build allocates and initializes the weights of gates gates of units units
+// for [steps, features] inputs. Biases start at zero.
+func (r *recurrent[T]) build(input []int, units, gates int, init Initialization, rng *rand.Rand) {
+
+	r.input = append([]int(nil), input...)
+	r.units, r.gates = units, gates
+
+	features := input[1]
+
+	r.w = vectors.NewTensor[T](gates*units, features)
+	r.u = vectors.NewTensor[T](gates*units, units)
+	r.b = vectors.NewTensor[T](gates * units)
+	r.gradW = vectors.NewTensor[T](gates*units, features)
+	r.gradU = vectors.NewTensor[T](gates*units, units)
+	r.gradB = vectors.NewTensor[T](gates * units)
+
+	Initialize(r.w.Data, features, gates*units, init, rng)
+	Initialize(r.u.Data, units, gates*units, init, rng)
+}
+
+// unroll records cell over every step of the batch x, starting from zero
+// states. With truncation positive, the states are cut off from the tape
+// every truncation steps counted back from the last one, so the last state
+// gets gradient from the last truncation steps only. It returns the last hidden state, or with sequences set the hidden
+// states of every step as [batch, steps, units].
+func (r *recurrent[T]) unroll(layer string, x *vectors.Tensor[T], truncation int, sequences bool, step cell[T]) (*vectors.Tensor[T], error) {
+
+	if r.w == nil {
+		return nil, fmt.Errorf("%s: layer is not built", layer)
+	}
+
+	if err := checkSample(layer, x, r.input); err != nil {
+		return nil, err
+	}
+
+	tape := &r.tape
+	batch, steps, features := x.Shape[0], r.input[0], r.input[1]
+	width := r.gates * r.units
+
+	in, err := r.begin(x)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := tape.Param(r.w, r.gradW)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := tape.Param(r.b, r.gradB)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.uParam, err = tape.Param(r.u, r.gradU); err != nil {
+		return nil, err
+	}
+
+	// The input's share of every step in one product: row s of sample i is
+	// x[i, s]·Wᵀ + b, viewed as the columns of step s
+	xw, err := tape.Reshape(in, batch*steps, features)
+	if err != nil {
+		return nil, err
+	}
+
+	if xw, err = tape.MatMul(xw, w, false, true); err != nil {
+		return nil, err
+	}
+
+	if xw, err = tape.AddRow(xw, b); err != nil {
+		return nil, err
+	}
+
+	if xw, err = tape.Reshape(xw, batch, steps*width); err != nil {
+		return nil, err
+	}
+
+	r.zeros = vectors.Reuse(r.zeros, batch, r.units)
+	r.zeros.Zero()
+
+	h, c := tape.Constant(r.zeros), tape.Constant(r.zeros)
+	r.outputs = r.outputs[:0]
+
+	for s := 0; s < steps; s++ {
+
+		// Truncated backpropagation through time: carry the values on, not the gradients
+		if truncation > 0 && s > 0 && (steps-s)%truncation == 0 {
+			h, c = tape.Constant(h.Value), tape.Constant(c.Value)
+		}
+
+		xs, err := tape.Slice(xw, s*width, (s+1)*width)
+		if err != nil {
+			return nil, err
+		}
+
+		if h, c, err = step(xs, h, c); err != nil {
+			return nil, err
+		}
+
+		r.outputs = append(r.outputs, h)
+	}
+
+	out := h
+
+	if sequences {
+		if out, err = tape.Concat(r.outputs...); err != nil {
+			return nil, err
+		}
+		if out, err = tape.Reshape(out, batch, steps, r.units); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.end(out), nil
+}
+
+// recurrence records h·Uᵀ for the previous hidden state h
+func (r *recurrent[T]) recurrence(h *autodiff.Node[T]) (*autodiff.Node[T], error) {
+	return r.tape.MatMul(h, r.uParam, false, true)
+}
+
+// gate records the activation of columns [i*units, (i+1)*units) of z
+func (r *recurrent[T]) gate(z *autodiff.Node[T], i int, name activation.ActivationFunction) (*autodiff.Node[T], error) {
+
+	g, err := r.tape.Slice(z, i*r.units, (i+1)*r.units)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.tape.Activate(g, name)
+}
+
+func (r *recurrent[T]) Params() []*vectors.Tensor[T] {
+	if r.w == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{r.w, r.u, r.b}
+}
+
+func (r *recurrent[T]) Grads() []*vectors.Tensor[T] {
+	if r.w == nil {
+		return nil
+	}
+	return []*vectors.Tensor[T]{r.gradW, r.gradU, r.gradB}
+}
+
+// SimpleRNN is a fully connected recurrent layer over [steps, features]
+// sequences: h_s = activation(x_s·Wᵀ + h_{s-1}·Uᵀ + b), from h_0 = 0.
+// It returns the last hidden state, [units], or with ReturnSequences the
+// state of every step, [steps, units].
+type SimpleRNN[T vectors.Float] struct {
+	Units int
+
+	// ActivationFunction is applied to the new state, tanh when empty
+	ActivationFunction activation.ActivationFunction
+
+	// Initialization of W and U, Xavier Normal when empty. The bias starts at zero.
+	Initialization Initialization
+
+	ReturnSequences bool
+
+	// Truncation, if positive, limits backpropagation through time to that
+	// many steps: counting back from the last step, gradients stop at the
+	// state carried across every Truncation-th step
+	Truncation int
+
+	recurrent[T]
+}
+
+func (l *SimpleRNN[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := l.OutputShape(input); err != nil {
+		return err
+	}
+
+	l.build(input, l.Units, 1, l.Initialization, rng)
+
+	return nil
+}
+
+func (l *SimpleRNN[T]) OutputShape(input []int) ([]int, error) {
+	return l.outputShape("simple rnn", input, l.Units, l.ReturnSequences)
+}
+
+func (l *SimpleRNN[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	name := l.ActivationFunction
+	if name == "" {
+		name = activation.Tanh
+	}
+
+	return l.unroll("simple rnn", x, l.Truncation, l.ReturnSequences, func(xw, h, c *autodiff.Node[T]) (*autodiff.Node[T], *autodiff.Node[T], error) {
+
+		hu, err := l.recurrence(h)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		z, err := l.tape.Add(xw, hu)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		h, err = l.tape.Activate(z, name)
+
+		return h, c, err
+	})
+}
+
+func (l *SimpleRNN[T]) outputActivation() activation.ActivationFunction {
+	if l.ActivationFunction == "" {
+		return activation.Tanh
+	}
+	return l.ActivationFunction
+}
+
+// LSTM is a long short-term memory layer over [steps, features] sequences.
+// Every step computes the input, forget and output gates i, f, o and the
+// candidate g from x_s·Wᵀ + h_{s-1}·Uᵀ + b, then
+//
+//	c_s = f * c_{s-1} + i * g
+//	h_s = o * tanh(c_s)
+//
+// from zero states. W, U and b stack the gates in the order i, f, g, o; the
+// forget gate bias starts at 1. It returns the last hidden state, [units],
+// or with ReturnSequences the state of every step, [steps, units].
+type LSTM[T vectors.Float] struct {
+	Units int
+
+	// Initialization of W and U, Xavier Normal when empty
+	Initialization Initialization
+
+	ReturnSequences bool
+
+	// Truncation, if positive, limits backpropagation through time to that
+	// many steps: counting back from the last step, gradients stop at the
+	// states carried across every Truncation-th step
+	Truncation int
+
+	recurrent[T]
+}
+
+func (l *LSTM[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := l.OutputShape(input); err != nil {
+		return err
+	}
+
+	l.build(input, l.Units, 4, l.Initialization, rng)
+
+	// Remember by default until the gate learns otherwise
+	for j := l.Units; j < 2*l.Units; j++ {
+		l.b.Data[j] = 1
+	}
+
+	return nil
+}
+
+func (l *LSTM[T]) OutputShape(input []int) ([]int, error) {
+	return l.outputShape("lstm", input, l.Units, l.ReturnSequences)
+}
+
+func (l *LSTM[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	tape := &l.tape
+
+	return l.unroll("lstm", x, l.Truncation, l.ReturnSequences, func(xw, h, c *autodiff.Node[T]) (*autodiff.Node[T], *autodiff.Node[T], error) {
+
+		hu, err := l.recurrence(h)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		z, err := tape.Add(xw, hu)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var gates [4]*autodiff.Node[T]
+		for k, name := range [4]activation.ActivationFunction{activation.Sigmoid, activation.Sigmoid, activation.Tanh, activation.Sigmoid} {
+			if gates[k], err = l.gate(z, k, name); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		input, forget, candidate, output := gates[0], gates[1], gates[2], gates[3]
+
+		kept, err := tape.Mul(forget, c)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		written, err := tape.Mul(input, candidate)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if c, err = tape.Add(kept, written); err != nil {
+			return nil, nil, err
+		}
+
+		squashed, err := tape.Activate(c, activation.Tanh)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		h, err = tape.Mul(output, squashed)
+
+		return h, c, err
+	})
+}
+
+// GRU is a gated recurrent unit layer over [steps, features] sequences.
+// Every step computes, with the recurrent bias bu added to h_{s-1}·Uᵀ,
+//
+//	r = sigmoid(x_s·W_rᵀ + b_r + h_{s-1}·U_rᵀ + bu_r)
+//	z = sigmoid(x_s·W_zᵀ + b_z + h_{s-1}·U_zᵀ + bu_z)
+//	n = tanh(x_s·W_nᵀ + b_n + r * (h_{s-1}·U_nᵀ + bu_n))
+//	h_s = (1 - z) * n + z * h_{s-1}
+//
+// from h_0 = 0. W, U, b and bu stack the gates in the order r, z, n. It
+// returns the last hidden state, [units], or with ReturnSequences the state
+// of every step, [steps, units].
+type GRU[T vectors.Float] struct {
+	Units int
+
+	// Initialization of W and U, Xavier Normal when empty
+	Initialization Initialization
+
+	ReturnSequences bool
+
+	// Truncation, if positive, limits backpropagation through time to that
+	// many steps: counting back from the last step, gradients stop at the
+	// state carried across every Truncation-th step
+	Truncation int
+
+	// bu is the recurrent bias
+	bu, gradBu *vectors.Tensor[T]
+
+	recurrent[T]
+}
+
+func (l *GRU[T]) Build(input []int, rng *rand.Rand) error {
+
+	if _, err := l.OutputShape(input); err != nil {
+		return err
+	}
+
+	l.build(input, l.Units, 3, l.Initialization, rng)
+
+	l.bu = vectors.NewTensor[T](3 * l.Units)
+	l.gradBu = vectors.NewTensor[T](3 * l.Units)
+
+	return nil
+}
+
+func (l *GRU[T]) OutputShape(input []int) ([]int, error) {
+	return l.outputShape("gru", input, l.Units, l.ReturnSequences)
+}
+
+func (l *GRU[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	tape := &l.tape
+	units := l.Units
+
+	var bu *autodiff.Node[T]
+
+	return l.unroll("gru", x, l.Truncation, l.ReturnSequences, func(xw, h, c *autodiff.Node[T]) (*autodiff.Node[T], *autodiff.Node[T], error) {
+
+		var err error
+
+		if bu == nil {
+			if bu, err = tape.Param(l.bu, l.gradBu); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		hu, err := l.recurrence(h)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if hu, err = tape.AddRow(hu, bu); err != nil {
+			return nil, nil, err
+		}
+
+		// The reset and update gates take both shares together
+		xrz, err := tape.Slice(xw, 0, 2*units)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		hrz, err := tape.Slice(hu, 0, 2*units)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rz, err := tape.Add(xrz, hrz)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		reset, err := l.gate(rz, 0, activation.Sigmoid)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		update, err := l.gate(rz, 1, activation.Sigmoid)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		xn, err := tape.Slice(xw, 2*units, 3*units)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		hn, err := tape.Slice(hu, 2*units, 3*units)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if hn, err = tape.Mul(reset, hn); err != nil {
+			return nil, nil, err
+		}
+
+		candidate, err := tape.Add(xn, hn)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if candidate, err = tape.Activate(candidate, activation.Tanh); err != nil {
+			return nil, nil, err
+		}
+
+		// h_s = n + z * (h_{s-1} - n)
+		delta, err := tape.Sub(h, candidate)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if delta, err = tape.Mul(update, delta); err != nil {
+			return nil, nil, err
+		}
+
+		h, err = tape.Add(candidate, delta)
+
+		return h, c, err
+	})
+}
+
+func (l *GRU[T]) Params() []*vectors.Tensor[T] {
+	if l.bu == nil {
+		return nil
+	}
+	return append(l.recurrent.Params(), l.bu)
+}
+
+func (l *GRU[T]) Grads() []*vectors.Tensor[T] {
+	if l.bu == nil {
+		return nil
+	}
+	return append(l.recurrent.Grads(), l.gradBu)
+}
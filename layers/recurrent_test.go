@@ -0,0 +1,162 @@
+package layers_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ThakurMayank5/gonn/layers"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+func TestRecurrentShapes(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		layer layers.Layer[float64]
+		input []int
+		want  []int // nil when the input is refused
+	}{
+		{"simple rnn", &layers.SimpleRNN[float64]{Units: 4}, []int{5, 3}, []int{4}},
+		{"simple rnn sequences", &layers.SimpleRNN[float64]{Units: 4, ReturnSequences: true}, []int{5, 3}, []int{5, 4}},
+		{"lstm", &layers.LSTM[float64]{Units: 2}, []int{6, 1}, []int{2}},
+		{"lstm sequences", &layers.LSTM[float64]{Units: 2, ReturnSequences: true}, []int{6, 1}, []int{6, 2}},
+		{"gru", &layers.GRU[float64]{Units: 3}, []int{1, 4}, []int{3}},
+		{"gru sequences", &layers.GRU[float64]{Units: 3, ReturnSequences: true, Truncation: 2}, []int{4, 2}, []int{4, 3}},
+		{"flat input", &layers.LSTM[float64]{Units: 2}, []int{6}, nil},
+		{"no units", &layers.GRU[float64]{}, []int{4, 2}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if tt.want == nil {
+				if err := tt.layer.Build(tt.input, rand.New(rand.NewSource(1))); err == nil {
+					t.Errorf("Build accepted samples of shape %v", tt.input)
+				}
+				return
+			}
+
+			checkShapes(t, tt.layer, tt.input, tt.want)
+		})
+	}
+}
+
+func TestRecurrentReturnSequences(t *testing.T) {
+
+	const batch, steps, features, units = 2, 4, 3, 5
+
+	tests := []struct {
+		name  string
+		layer func(sequences bool) layers.Layer[float64]
+	}{
+		{"simple rnn", func(sequences bool) layers.Layer[float64] {
+			return &layers.SimpleRNN[float64]{Units: units, ReturnSequences: sequences}
+		}},
+		{"lstm", func(sequences bool) layers.Layer[float64] {
+			return &layers.LSTM[float64]{Units: units, ReturnSequences: sequences}
+		}},
+		{"gru", func(sequences bool) layers.Layer[float64] {
+			return &layers.GRU[float64]{Units: units, ReturnSequences: sequences}
+		}},
+	}
+
+	x := randomTensor(rand.New(rand.NewSource(2)), batch, steps, features)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Built from the same seed, both layers hold the same weights
+			var outputs [2]*vectors.Tensor[float64]
+			for i, sequences := range []bool{false, true} {
+
+				layer := tt.layer(sequences)
+				if err := layer.Build([]int{steps, features}, rand.New(rand.NewSource(1))); err != nil {
+					t.Fatal(err)
+				}
+
+				y, err := layer.Forward(x, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				outputs[i] = y
+			}
+
+			// The last state is the last step of the sequence
+			for i := range batch {
+				last := outputs[1].Data[(i*steps+steps-1)*units : (i*steps+steps)*units]
+				if state := outputs[0].Row(i); !slices.Equal(last, state) {
+					t.Errorf("sample %d: last step %v, want the final state %v", i, last, state)
+				}
+			}
+		})
+	}
+}
+
+func TestRecurrentTruncation(t *testing.T) {
+
+	const batch, steps, features, units = 2, 5, 3, 4
+
+	tests := []struct {
+		name  string
+		layer func(truncation int) layers.Layer[float64]
+	}{
+		{"simple rnn", func(truncation int) layers.Layer[float64] {
+			return &layers.SimpleRNN[float64]{Units: units, Truncation: truncation}
+		}},
+		{"lstm", func(truncation int) layers.Layer[float64] {
+			return &layers.LSTM[float64]{Units: units, Truncation: truncation}
+		}},
+		{"gru", func(truncation int) layers.Layer[float64] {
+			return &layers.GRU[float64]{Units: units, Truncation: truncation}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// The gradient of the last state reaches back Truncation steps,
+			// all of them without truncation
+			for _, truncation := range []int{0, 1, 2, 3, 5, 7} {
+
+				reach := steps
+				if truncation > 0 {
+					reach = min(truncation, steps)
+				}
+
+				rng := rand.New(rand.NewSource(1))
+
+				layer := tt.layer(truncation)
+				if err := layer.Build([]int{steps, features}, rng); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := layer.Forward(randomTensor(rng, batch, steps, features), true); err != nil {
+					t.Fatal(err)
+				}
+
+				dy := vectors.NewTensor[float64](batch, units)
+				dy.Fill(1)
+
+				dx, err := layer.Backward(dy)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for s := range steps {
+
+					reached := false
+					for i := range batch {
+						row := dx.Data[(i*steps+s)*features : (i*steps+s+1)*features]
+						reached = reached || slices.ContainsFunc(row, func(v float64) bool { return v != 0 })
+					}
+
+					if want := s >= steps-reach; reached != want {
+						t.Errorf("truncation %d: step %d gets gradient: %v, want %v", truncation, s, reached, want)
+					}
+				}
+			}
+		})
+	}
+}
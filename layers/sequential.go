@@ -0,0 +1,219 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ThakurMayank5/gonn/activation"
+	"github.com/ThakurMayank5/gonn/backend"
+	"github.com/ThakurMayank5/gonn/vectors"
+)
+
+// Sequential chains layers, feeding the output of each to the next. It is a
+// Layer itself, so sequential blocks can be nested.
+type Sequential[T vectors.Float] struct {
+	Layers []Layer[T]
+
+	params, grads []*vectors.Tensor[T]
+
+	// counts is the number of parameters of every layer as of the last Build
+	counts []int
+	rows   []Rows
+
+	// mask flags the padding steps of the input and output sequences
+	mask, outputMask []bool
+}
+
+// NewSequential returns a container running layers in order
+func NewSequential[T vectors.Float](layers ...Layer[T]) *Sequential[T] {
+	return &Sequential[T]{Layers: layers}
+}
+
+// Add appends a layer. The container must be built again before it is used.
+func (s *Sequential[T]) Add(layer Layer[T]) {
+	s.Layers = append(s.Layers, layer)
+}
+
+func (s *Sequential[T]) Build(input []int, rng *rand.Rand) error {
+
+	s.params, s.grads, s.counts = nil, nil, nil
+
+	shape := input
+
+	for i, layer := range s.Layers {
+
+		if err := layer.Build(shape, rng); err != nil {
+			return fmt.Errorf("layer %d: %v", i+1, err)
+		}
+
+		params, grads := layer.Params(), layer.Grads()
+		if len(params) != len(grads) {
+			return fmt.Errorf("layer %d: %d parameters but %d gradients", i+1, len(params), len(grads))
+		}
+
+		s.params = append(s.params, params...)
+		s.grads = append(s.grads, grads...)
+		s.counts = append(s.counts, len(params))
+
+		next, err := layer.OutputShape(shape)
+		if err != nil {
+			return fmt.Errorf("layer %d: %v", i+1, err)
+		}
+
+		shape = next
+	}
+
+	return nil
+}
+
+func (s *Sequential[T]) OutputShape(input []int) ([]int, error) {
+
+	shape := input
+
+	for i, layer := range s.Layers {
+		next, err := layer.OutputShape(shape)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %v", i+1, err)
+		}
+		shape = next
+	}
+
+	return shape, nil
+}
+
+// Forward runs the layers in order. The padding mask of a Masking layer is
+// passed to every Masked layer after it for as long as the outputs stay
+// sequences of the same number of steps.
+func (s *Sequential[T]) Forward(x *vectors.Tensor[T], training bool) (*vectors.Tensor[T], error) {
+
+	mask := s.mask
+
+	for i, layer := range s.Layers {
+
+		if l, ok := layer.(Masked); ok {
+			l.SetMask(mask)
+		}
+
+		out, err := layer.Forward(x, training)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %v", i+1, err)
+		}
+
+		mask = outputMask(layer, out, mask)
+		x = out
+	}
+
+	s.outputMask = mask
+
+	return x, nil
+}
+
+// outputMask returns the padding mask of out, the output of layer for an
+// input with the given mask: the mask layer makes if it is Masking, else the
+// input mask for as long as out holds sequences of the same number of steps
+func outputMask[T vectors.Float](layer any, out *vectors.Tensor[T], mask []bool) []bool {
+
+	if l, ok := layer.(Masking); ok {
+		return l.OutputMask()
+	}
+
+	if out.Dims() < 3 || out.Shape[0]*out.Shape[1] != len(mask) {
+		return nil
+	}
+
+	return mask
+}
+
+// SetMask sets the padding mask of the input of the next Forward
+func (s *Sequential[T]) SetMask(mask []bool) { s.mask = mask }
+
+// OutputMask returns the padding mask of the output of the last Forward
+func (s *Sequential[T]) OutputMask() []bool { return s.outputMask }
+
+func (s *Sequential[T]) Backward(dy *vectors.Tensor[T]) (*vectors.Tensor[T], error) {
+
+	for i := len(s.Layers) - 1; i >= 0; i-- {
+
+		dx, err := s.Layers[i].Backward(dy)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %v", i+1, err)
+		}
+
+		dy = dx
+	}
+
+	return dy, nil
+}
+
+// SetBackend sets the backend of every layer that runs on one
+func (s *Sequential[T]) SetBackend(be backend.Backend[T]) {
+	for _, layer := range s.Layers {
+		if l, ok := layer.(BackendSetter[T]); ok {
+			l.SetBackend(be)
+		}
+	}
+}
+
+// Params returns the parameters of every layer in order, as of the last Build
+func (s *Sequential[T]) Params() []*vectors.Tensor[T] { return s.params }
+
+// Grads returns the gradients of every layer in order, as of the last Build
+func (s *Sequential[T]) Grads() []*vectors.Tensor[T] { return s.grads }
+
+// State returns the state of every Stateful layer in order
+func (s *Sequential[T]) State() []*vectors.Tensor[T] {
+
+	var state []*vectors.Tensor[T]
+
+	for _, layer := range s.Layers {
+		if l, ok := layer.(Stateful[T]); ok {
+			state = append(state, l.State()...)
+		}
+	}
+
+	return state
+}
+
+// SparseRows returns the rows of every Sparse layer and the zero Rows for the
+// parameters of the others, in the order of Params. It is nil when no layer
+// has sparse gradients.
+func (s *Sequential[T]) SparseRows() []Rows {
+
+	if len(s.counts) != len(s.Layers) {
+		return nil
+	}
+
+	s.rows = s.rows[:0]
+	sparse := false
+
+	for i, layer := range s.Layers {
+
+		var rows []Rows
+		if l, ok := layer.(Sparse); ok {
+			rows = l.SparseRows()
+		}
+
+		if rows == nil {
+			for range s.counts[i] {
+				s.rows = append(s.rows, Rows{})
+			}
+			continue
+		}
+
+		s.rows = append(s.rows, rows...)
+		sparse = true
+	}
+
+	if !sparse {
+		return nil
+	}
+
+	return s.rows
+}
+
+func (s *Sequential[T]) outputActivation() activation.ActivationFunction {
+	if len(s.Layers) == 0 {
+		return ""
+	}
+	return OutputActivation(s.Layers[len(s.Layers)-1])
+}
@@ -0,0 +1,111 @@
+package losses
+
+import "fmt"
+
+// Combined is the loss of a model with several outputs laid side by side in
+// every row: output i owns the next Predictions[i] values of a prediction
+// row and the next Targets[i] values of a target row, and its loss is
+// Losses[i] scaled by Weights[i]. The combined loss is the sum.
+type Combined struct {
+	Losses      []Loss
+	Predictions []int
+	Targets     []int
+
+	// Weights scales the loss of every output, nil weighs them all 1
+	Weights []float64
+}
+
+// check returns an error unless predictions and targets split into the outputs
+func (c Combined) check(predictions, targets []float64) error {
+
+	if len(c.Predictions) != len(c.Losses) || len(c.Targets) != len(c.Losses) {
+		return fmt.Errorf("combined loss has %d losses for %d prediction and %d target widths", len(c.Losses), len(c.Predictions), len(c.Targets))
+	}
+
+	if c.Weights != nil && len(c.Weights) != len(c.Losses) {
+		return fmt.Errorf("combined loss has %d weights for %d losses", len(c.Weights), len(c.Losses))
+	}
+
+	p, t := 0, 0
+	for i := range c.Losses {
+		p += c.Predictions[i]
+		t += c.Targets[i]
+	}
+
+	if p != len(predictions) || t != len(targets) {
+		return fmt.Errorf("combined loss expects %d predictions and %d targets, got %d and %d", p, t, len(predictions), len(targets))
+	}
+
+	return nil
+}
+
+func (c Combined) weight(i int) float64 {
+	if c.Weights == nil {
+		return 1
+	}
+	return c.Weights[i]
+}
+
+func (c Combined) Value(predictions, targets []float64) (float64, error) {
+
+	if err := c.check(predictions, targets); err != nil {
+		return 0, err
+	}
+
+	total := 0.0
+	p, t := 0, 0
+
+	for i, loss := range c.Losses {
+
+		value, err := loss.Value(predictions[p:p+c.Predictions[i]], targets[t:t+c.Targets[i]])
+		if err != nil {
+			return 0, fmt.Errorf("output %d: %v", i+1, err)
+		}
+
+		total += c.weight(i) * value
+		p += c.Predictions[i]
+		t += c.Targets[i]
+	}
+
+	return total, nil
+}
+
+func (c Combined) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(c, predictions, targets)
+}
+
+func (c Combined) GradientInto(grad, predictions, targets []float64) error {
+
+	if err := c.check(predictions, targets); err != nil {
+		return err
+	}
+
+	p, t := 0, 0
+
+	for i, loss := range c.Losses {
+
+		out := grad[p : p+c.Predictions[i]]
+		prediction, target := predictions[p:p+c.Predictions[i]], targets[t:t+c.Targets[i]]
+
+		if writer, ok := loss.(GradientWriter); ok {
+			if err := writer.GradientInto(out, prediction, target); err != nil {
+				return fmt.Errorf("output %d: %v", i+1, err)
+			}
+		} else {
+			g, err := loss.Gradient(prediction, target)
+			if err != nil {
+				return fmt.Errorf("output %d: %v", i+1, err)
+			}
+			copy(out, g)
+		}
+
+		for j := range out {
+			out[j] *= c.weight(i)
+		}
+
+		p += c.Predictions[i]
+		t += c.Targets[i]
+	}
+
+	return nil
+}
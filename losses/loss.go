@@ -0,0 +1,352 @@
+package losses
+
+import (
+	"fmt"
+	"math"
+)
+
+// epsilon clips probabilities away from 0 and 1 before taking logarithms
+const epsilon = 1e-15
+
+// Loss is a differentiable loss function for a single sample.
+// Gradient returns dLoss/dPrediction for each element of predictions.
+type Loss interface {
+	Value(predictions, targets []float64) (float64, error)
+	Gradient(predictions, targets []float64) ([]float64, error)
+}
+
+// GradientWriter is implemented by losses that can write their gradient into
+// a caller-owned slice of len(predictions), so training steps do not allocate.
+// Every built-in loss implements it.
+type GradientWriter interface {
+	GradientInto(grad, predictions, targets []float64) error
+}
+
+var registry = map[string]Loss{
+	"mse":                             MeanSquaredErrorLoss{},
+	"mae":                             MeanAbsoluteErrorLoss{},
+	"huber":                           HuberLoss{Delta: 1.0},
+	"log_cosh":                        LogCoshLoss{},
+	"hinge":                           HingeLoss{},
+	"kl_divergence":                   KLDivergenceLoss{},
+	"binary_crossentropy":             BinaryCrossEntropyLoss{},
+	"categorical_crossentropy":        CategoricalCrossEntropyLoss{},
+	"sparse_categorical_crossentropy": SparseCategoricalCrossEntropyLoss{},
+}
+
+// Get returns the loss registered under name
+func Get(name string) (Loss, error) {
+	loss, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown loss function: %q", name)
+	}
+	return loss, nil
+}
+
+// gradient allocates the gradient of a loss that writes it in place
+func gradient(loss GradientWriter, predictions, targets []float64) ([]float64, error) {
+	grad := make([]float64, len(predictions))
+	if err := loss.GradientInto(grad, predictions, targets); err != nil {
+		return nil, err
+	}
+	return grad, nil
+}
+
+func checkLengths(predictions, targets []float64) error {
+	if len(predictions) != len(targets) {
+		return fmt.Errorf("predictions and targets must be of the same length")
+	}
+	return nil
+}
+
+func clip(p float64) float64 {
+	return math.Max(epsilon, math.Min(1.0-epsilon, p))
+}
+
+// MeanSquaredErrorLoss is mean((p - t)^2)
+type MeanSquaredErrorLoss struct{}
+
+func (MeanSquaredErrorLoss) Value(predictions, targets []float64) (float64, error) {
+	return MeanSquaredError(predictions, targets)
+}
+
+func (l MeanSquaredErrorLoss) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(l, predictions, targets)
+}
+
+func (MeanSquaredErrorLoss) GradientInto(grad, predictions, targets []float64) error {
+	if err := checkLengths(predictions, targets); err != nil {
+		return err
+	}
+	n := float64(len(predictions))
+	for i := range predictions {
+		grad[i] = 2.0 * (predictions[i] - targets[i]) / n
+	}
+	return nil
+}
+
+// MeanAbsoluteErrorLoss is mean(|p - t|)
+type MeanAbsoluteErrorLoss struct{}
+
+func (MeanAbsoluteErrorLoss) Value(predictions, targets []float64) (float64, error) {
+	if err := checkLengths(predictions, targets); err != nil {
+		return 0, err
+	}
+	loss := 0.0
+	for i := range predictions {
+		loss += math.Abs(predictions[i] - targets[i])
+	}
+	return loss / float64(len(predictions)), nil
+}
+
+func (l MeanAbsoluteErrorLoss) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(l, predictions, targets)
+}
+
+func (MeanAbsoluteErrorLoss) GradientInto(grad, predictions, targets []float64) error {
+	if err := checkLengths(predictions, targets); err != nil {
+		return err
+	}
+	n := float64(len(predictions))
+	for i := range predictions {
+		grad[i] = 0
+		diff := predictions[i] - targets[i]
+		if diff > 0 {
+			grad[i] = 1.0 / n
+		} else if diff < 0 {
+			grad[i] = -1.0 / n
+		}
+	}
+	return nil
+}
+
+// HuberLoss is quadratic for errors up to Delta and linear beyond it
+type HuberLoss struct {
+	Delta float64
+}
+
+func (h HuberLoss) Value(predictions, targets []float64) (float64, error) {
+	if err := checkLengths(predictions, targets); err != nil {
+		return 0, err
+	}
+	loss := 0.0
+	for i := range predictions {
+		diff := math.Abs(predictions[i] - targets[i])
+		if diff <= h.Delta {
+			loss += 0.5 * diff * diff
+		} else {
+			loss += h.Delta * (diff - 0.5*h.Delta)
+		}
+	}
+	return loss / float64(len(predictions)), nil
+}
+
+func (h HuberLoss) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(h, predictions, targets)
+}
+
+func (h HuberLoss) GradientInto(grad, predictions, targets []float64) error {
+	if err := checkLengths(predictions, targets); err != nil {
+		return err
+	}
+	n := float64(len(predictions))
+	for i := range predictions {
+		diff := predictions[i] - targets[i]
+		grad[i] = math.Max(-h.Delta, math.Min(h.Delta, diff)) / n
+	}
+	return nil
+}
+
+// LogCoshLoss is mean(log(cosh(p - t)))
+type LogCoshLoss struct{}
+
+func (LogCoshLoss) Value(predictions, targets []float64) (float64, error) {
+	if err := checkLengths(predictions, targets); err != nil {
+		return 0, err
+	}
+	loss := 0.0
+	for i := range predictions {
+		// log(cosh(x)) = |x| + log(1 + e^(-2|x|)) - log(2), stable for large |x|
+		x := math.Abs(predictions[i] - targets[i])
+		loss += x + math.Log1p(math.Exp(-2.0*x)) - math.Ln2
+	}
+	return loss / float64(len(predictions)), nil
+}
+
+func (l LogCoshLoss) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(l, predictions, targets)
+}
+
+func (LogCoshLoss) GradientInto(grad, predictions, targets []float64) error {
+	if err := checkLengths(predictions, targets); err != nil {
+		return err
+	}
+	n := float64(len(predictions))
+	for i := range predictions {
+		grad[i] = math.Tanh(predictions[i]-targets[i]) / n
+	}
+	return nil
+}
+
+// HingeLoss is mean(max(0, 1 - t*p)) for targets in {-1, 1}.
+// Targets of 0 are treated as -1, so 0/1 labels work as well.
+type HingeLoss struct{}
+
+func hingeTarget(t float64) float64 {
+	if t == 0 {
+		return -1.0
+	}
+	return t
+}
+
+func (HingeLoss) Value(predictions, targets []float64) (float64, error) {
+	if err := checkLengths(predictions, targets); err != nil {
+		return 0, err
+	}
+	loss := 0.0
+	for i := range predictions {
+		loss += math.Max(0, 1.0-hingeTarget(targets[i])*predictions[i])
+	}
+	return loss / float64(len(predictions)), nil
+}
+
+func (l HingeLoss) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(l, predictions, targets)
+}
+
+func (HingeLoss) GradientInto(grad, predictions, targets []float64) error {
+	if err := checkLengths(predictions, targets); err != nil {
+		return err
+	}
+	n := float64(len(predictions))
+	for i := range predictions {
+		grad[i] = 0
+		t := hingeTarget(targets[i])
+		if 1.0-t*predictions[i] > 0 {
+			grad[i] = -t / n
+		}
+	}
+	return nil
+}
+
+// KLDivergenceLoss is sum(t * log(t / p)) between a target and a predicted distribution
+type KLDivergenceLoss struct{}
+
+func (KLDivergenceLoss) Value(predictions, targets []float64) (float64, error) {
+	if err := checkLengths(predictions, targets); err != nil {
+		return 0, err
+	}
+	loss := 0.0
+	for i := range predictions {
+		if targets[i] > 0 {
+			loss += targets[i] * math.Log(clip(targets[i])/clip(predictions[i]))
+		}
+	}
+	return loss, nil
+}
+
+func (l KLDivergenceLoss) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(l, predictions, targets)
+}
+
+func (KLDivergenceLoss) GradientInto(grad, predictions, targets []float64) error {
+	if err := checkLengths(predictions, targets); err != nil {
+		return err
+	}
+	for i := range predictions {
+		grad[i] = -targets[i] / clip(predictions[i])
+	}
+	return nil
+}
+
+// BinaryCrossEntropyLoss is mean(-(t*log(p) + (1-t)*log(1-p))) over independent outputs
+type BinaryCrossEntropyLoss struct{}
+
+func (BinaryCrossEntropyLoss) Value(predictions, targets []float64) (float64, error) {
+	if err := checkLengths(predictions, targets); err != nil {
+		return 0, err
+	}
+	loss := 0.0
+	for i := range predictions {
+		p := clip(predictions[i])
+		loss += -(targets[i]*math.Log(p) + (1.0-targets[i])*math.Log(1.0-p))
+	}
+	return loss / float64(len(predictions)), nil
+}
+
+func (l BinaryCrossEntropyLoss) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(l, predictions, targets)
+}
+
+func (BinaryCrossEntropyLoss) GradientInto(grad, predictions, targets []float64) error {
+	if err := checkLengths(predictions, targets); err != nil {
+		return err
+	}
+	n := float64(len(predictions))
+	for i := range predictions {
+		p := clip(predictions[i])
+		grad[i] = (p - targets[i]) / (p * (1.0 - p)) / n
+	}
+	return nil
+}
+
+// CategoricalCrossEntropyLoss is sum(-t * log(p)) for one-hot targets
+type CategoricalCrossEntropyLoss struct{}
+
+func (CategoricalCrossEntropyLoss) Value(predictions, targets []float64) (float64, error) {
+	return CategoricalCrossEntropy(predictions, targets)
+}
+
+func (l CategoricalCrossEntropyLoss) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(l, predictions, targets)
+}
+
+func (CategoricalCrossEntropyLoss) GradientInto(grad, predictions, targets []float64) error {
+	if err := checkLengths(predictions, targets); err != nil {
+		return err
+	}
+	for i := range predictions {
+		grad[i] = -targets[i] / clip(predictions[i])
+	}
+	return nil
+}
+
+// SparseCategoricalCrossEntropyLoss is -log(p[c]) where the target holds the
+// class index c as its single element instead of a one-hot vector
+type SparseCategoricalCrossEntropyLoss struct{}
+
+// SparseClass returns the class index stored in a sparse target
+func SparseClass(predictions, targets []float64) (int, error) {
+	if len(targets) != 1 {
+		return 0, fmt.Errorf("sparse targets must hold a single class index, got %d values", len(targets))
+	}
+	class := int(targets[0])
+	if class < 0 || class >= len(predictions) {
+		return 0, fmt.Errorf("class index %d is out of range for %d outputs", class, len(predictions))
+	}
+	return class, nil
+}
+
+func (SparseCategoricalCrossEntropyLoss) Value(predictions, targets []float64) (float64, error) {
+	class, err := SparseClass(predictions, targets)
+	if err != nil {
+		return 0, err
+	}
+	return -math.Log(clip(predictions[class])), nil
+}
+
+func (l SparseCategoricalCrossEntropyLoss) Gradient(predictions, targets []float64) ([]float64, error) {
+	return gradient(l, predictions, targets)
+}
+
+func (SparseCategoricalCrossEntropyLoss) GradientInto(grad, predictions, targets []float64) error {
+	class, err := SparseClass(predictions, targets)
+	if err != nil {
+		return err
+	}
+	for i := range grad {
+		grad[i] = 0
+	}
+	grad[class] = -1.0 / clip(predictions[class])
+	return nil
+}
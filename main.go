@@ -6,6 +6,7 @@ import (
 	activ "github.com/ThakurMayank5/gonn/activation"
 	"github.com/ThakurMayank5/gonn/dataloader"
 	"github.com/ThakurMayank5/gonn/dataset"
+	"github.com/ThakurMayank5/gonn/layers"
 	nn "github.com/ThakurMayank5/gonn/neuralnetwork"
 )
 
@@ -23,6 +24,21 @@ var classNames = []string{
 	"Ankle boot",  // 9
 }
 
+// fashionCNN returns the convolutional network classifying 28×28 grayscale
+// images, stored as [height, width, channels]
+func fashionCNN() layers.Layer[float64] {
+	return layers.NewSequential[float64](
+		&layers.Conv2D[float64]{Filters: 16, Kernel: [2]int{3, 3}, Padding: [2]int{1, 1}, ActivationFunction: activ.ReLU, Initialization: layers.KaimingNormal},
+		&layers.MaxPool2D[float64]{Kernel: [2]int{2, 2}},
+		&layers.Conv2D[float64]{Filters: 32, Kernel: [2]int{3, 3}, Padding: [2]int{1, 1}, ActivationFunction: activ.ReLU, Initialization: layers.KaimingNormal},
+		&layers.MaxPool2D[float64]{Kernel: [2]int{2, 2}},
+		&layers.Flatten[float64]{},
+		&layers.Dense[float64]{Neurons: 128, ActivationFunction: activ.ReLU, Initialization: layers.KaimingNormal},
+		&layers.Dropout[float64]{Rate: 0.3},
+		&layers.Dense[float64]{Neurons: 10, ActivationFunction: activ.Softmax},
+	)
+}
+
 func main() {
 
 	// Fashion MNIST Clothing Classification
@@ -31,40 +47,19 @@ func main() {
 	//   Col  0      : label  (int 0-9)
 	//   Col  1-784  : pixel values (0-255)
 	//
-	// Architecture:
-	//   Input  : 784   (28×28 pixels)
-	//   Hidden : 256   → ReLU   (Kaiming Normal)
-	//   Hidden : 128   → ReLU   (Kaiming Normal)
-	//   Hidden :  64   → ReLU   (Kaiming Normal)
-	//   Output :  10   → Softmax (Kaiming Normal)
+	// Architecture (see fashionCNN):
+	//   Input  : 28×28×1 image
+	//   Conv   : 16 filters 3×3 → ReLU, 2×2 max pool → 14×14×16
+	//   Conv   : 32 filters 3×3 → ReLU, 2×2 max pool → 7×7×32
+	//   Dense  : 128 → ReLU, dropout 0.3
+	//   Output : 10  → Softmax
 
 	model := nn.Model{
 		NeuralNetwork: nn.NeuralNetwork{
 			InputLayer: nn.InputLayer{
-				Neurons: 784,
-			},
-			Layers: []nn.Layer{
-				{
-					Neurons:            256,
-					ActivationFunction: activ.ReLU,
-					Initialization:     nn.KaimingNormalInitializer,
-				},
-				{
-					Neurons:            128,
-					ActivationFunction: activ.ReLU,
-					Initialization:     nn.KaimingNormalInitializer,
-				},
-				{
-					Neurons:            64,
-					ActivationFunction: activ.ReLU,
-					Initialization:     nn.KaimingNormalInitializer,
-				},
-			},
-			OutputLayer: nn.OutputLayer{
-				Neurons:            10,
-				ActivationFunction: activ.Softmax,
-				Initialization:     nn.KaimingNormalInitializer,
+				Shape: []int{28, 28, 1},
 			},
+			Network: fashionCNN(),
 		},
 		TrainingConfig: nn.TrainingConfig{
 			Epochs:       30,
@@ -75,8 +70,6 @@ func main() {
 		},
 	}
 
-	model.NeuralNetwork.Summary()
-
 	// --- Step 1: Initialize weights ---
 
 	err := model.InitializeWeights()
@@ -85,6 +78,8 @@ func main() {
 		return
 	}
 
+	model.NeuralNetwork.Summary()
+
 	// Build pixel column slice (cols 1-784)
 	pixelCols := make([]int, 784)
 	for i := range pixelCols {
@@ -124,7 +119,7 @@ func main() {
 
 	// --- Step 4: Train ---
 
-	err = model.Fit(train, test)
+	_, err = model.Fit(train, test)
 	if err != nil {
 		fmt.Println("Training error:", err)
 		return
@@ -149,17 +144,9 @@ func main() {
 	loadedModel := nn.Model{
 		NeuralNetwork: nn.NeuralNetwork{
 			InputLayer: nn.InputLayer{
-				Neurons: 784,
-			},
-			Layers: []nn.Layer{
-				{Neurons: 256, ActivationFunction: activ.ReLU},
-				{Neurons: 128, ActivationFunction: activ.ReLU},
-				{Neurons: 64, ActivationFunction: activ.ReLU},
-			},
-			OutputLayer: nn.OutputLayer{
-				Neurons:            10,
-				ActivationFunction: activ.Softmax,
+				Shape: []int{28, 28, 1},
 			},
+			Network: fashionCNN(),
 		},
 	}
 